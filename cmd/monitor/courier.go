@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// delivery is what a poll hands over to be sent: the snapshot read, the events of its evaluation and every alert.
+type delivery struct {
+	snapshot *sensors.Snapshot
+	events   []*alert.Event
+	alerts   []*alert.Alert
+}
+
+// courier sends deliveries on a goroutine of its own, so that a notifier or output that is slow or down does not hold
+// up the reading of the sensors. Deliveries handed over while it is sending are sent together once it is done, so
+// none is dropped and, as every batch is given up on after the timeout, the backlog stays bounded.
+type courier struct {
+	send    func(ctx context.Context, deliveries []*delivery)
+	timeout time.Duration
+
+	// ctx is the context deliveries are sent with, canceled when the courier is closed without time to finish.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	pending []*delivery
+
+	// wake is signaled when a delivery is handed over, stop when the courier is closed and done once it has stopped.
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newCourier starts a courier that sends deliveries with send, giving up on each batch after the timeout.
+func newCourier(send func(ctx context.Context, deliveries []*delivery), timeout time.Duration) *courier {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &courier{
+		send:    send,
+		timeout: timeout,
+		ctx:     ctx,
+		cancel:  cancel,
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go c.run()
+	return c
+}
+
+// add hands a delivery over to be sent, without waiting for it to be.
+func (c *courier) add(d *delivery) {
+	c.mu.Lock()
+	c.pending = append(c.pending, d)
+	c.mu.Unlock()
+
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// close sends the deliveries still pending and stops the courier. If the context is done first, the delivery being
+// sent is abandoned.
+func (c *courier) close(ctx context.Context) {
+	close(c.stop)
+
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		c.cancel()
+		<-c.done
+	}
+
+	c.cancel()
+}
+
+// run sends the pending deliveries whenever some are handed over, until the courier is closed.
+func (c *courier) run() {
+	defer close(c.done)
+
+	for {
+		select {
+		case <-c.wake:
+			c.flush()
+		case <-c.stop:
+			c.flush()
+			return
+		}
+	}
+}
+
+// flush sends the pending deliveries, if any, as one batch.
+func (c *courier) flush() {
+	c.mu.Lock()
+	deliveries := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+
+	if len(deliveries) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
+	defer cancel()
+
+	c.send(ctx, deliveries)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCourier(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu      sync.Mutex
+		batches [][]*delivery
+	)
+	sending := make(chan struct{})
+	release := make(chan struct{})
+	c := newCourier(func(_ context.Context, deliveries []*delivery) {
+		mu.Lock()
+		batches = append(batches, deliveries)
+		first := len(batches) == 1
+		mu.Unlock()
+
+		if first {
+			close(sending)
+			<-release
+		}
+	}, time.Minute)
+
+	first, second, third := &delivery{}, &delivery{}, &delivery{}
+	c.add(first)
+	<-sending
+
+	// Deliveries handed over while the first is being sent are not held up by it, and are sent together after it.
+	c.add(second)
+	c.add(third)
+	close(release)
+
+	c.close(t.Context())
+	require.Equal(t, [][]*delivery{{first}, {second, third}}, batches)
+}
+
+func TestCourier_Close_Timeout(t *testing.T) {
+	t.Parallel()
+
+	sending := make(chan struct{})
+	c := newCourier(func(ctx context.Context, _ []*delivery) {
+		close(sending)
+		<-ctx.Done()
+	}, time.Hour)
+
+	c.add(&delivery{})
+	<-sending
+
+	// A delivery that outlasts the context of close is abandoned rather than waited on.
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		c.close(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "close did not abandon the delivery")
+	}
+}
+
+func TestCourier_Timeout(t *testing.T) {
+	t.Parallel()
+
+	errs := make(chan error, 1)
+	c := newCourier(func(ctx context.Context, _ []*delivery) {
+		<-ctx.Done()
+		errs <- ctx.Err()
+	}, 10*time.Millisecond)
+
+	c.add(&delivery{})
+	require.ErrorIs(t, <-errs, context.DeadlineExceeded)
+	c.close(t.Context())
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/report"
+)
+
+// listCommand lists every sensor found by the configured sources with its id, kind, unit, limits and the rule that
+// applies to it. The ids are what the match patterns of rules are matched against.
+func listCommand(ctx context.Context, args []string) int {
+	f := newFlags("list", true)
+	if code, ok := f.parse(args); !ok {
+		return code
+	}
+
+	cfg, err := f.loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return exitError
+	}
+
+	source, err := newSource(ctx, cfg.Sources, cfg.Health)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating sensor source: %v\n", err)
+		return exitError
+	}
+
+	snapshot, err := readSnapshot(ctx, source, cfg.ReadTimeout.Std())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading sensors: %v\n", err)
+	}
+	if snapshot == nil {
+		return exitError
+	}
+
+	if err := report.Write(os.Stdout, f.outputFormat(), sensorTable(snapshot, cfg.Rules)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		return exitError
+	}
+
+	return exitOK
+}
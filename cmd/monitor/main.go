@@ -1,106 +1,173 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"os/exec"
+	"io"
+	"io/fs"
+	"os"
 	"strings"
-	"time"
 
-	"github.com/gen2brain/beeep"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/report"
 )
 
+// The exit codes of the monitor.
 const (
-	// appName is the name of the application used for notifications.
-	appName = "CPU Temp Monitor"
+	// exitOK means the command succeeded, and for once that no sensor is breaching its rule.
+	exitOK = 0
 
-	// crashTemperature is the temperature in Celsius at which the system is considered to be in a critical state.
-	crashTemperature = 100.0 // Temperature in Celsius at which the system crashes
+	// exitError means the command failed, e.g. the configuration is invalid, the sensors could not be read or a
+	// notification could not be sent.
+	exitError = 1
+
+	// exitUsage means the command line is invalid.
+	exitUsage = 2
+
+	// exitWarning means once found a sensor breaching the warning threshold of its rule.
+	exitWarning = 3
+
+	// exitCritical means once found a sensor breaching the critical threshold of its rule.
+	exitCritical = 4
 )
 
-func readCPUTemp() (float64, error) {
-	cmd := exec.Command("sensors", "-j")
-	output, err := cmd.Output()
-	if err != nil {
-		return 0, fmt.Errorf("failed to execute sensors command: %w", err)
-	}
+// command is a subcommand of the monitor.
+type command struct {
+	name    string
+	summary string
 
-	sensorData := new(sensor)
-	if err := json.NewDecoder(strings.NewReader(string(output))).Decode(sensorData); err != nil {
-		return 0, fmt.Errorf("failed to decode sensors output: %w", err)
-	}
+	// run runs the command with its arguments, returning the exit code.
+	run func(ctx context.Context, args []string) int
+}
 
-	return sensorData.DellDdvVirtual0.CPU.Temp1Input, nil
+// commands are every subcommand, in the order they are listed in the usage.
+var commands = []*command{
+	{name: "run", summary: "Monitor the sensors until stopped (the default)", run: runCommand},
+	{name: "once", summary: "Read the sensors once and print them with the state of their rules", run: onceCommand},
+	{name: "list", summary: "List every sensor with its id, kind, unit and limits", run: listCommand},
+	{name: "watch", summary: "Show the sensors in a table refreshed every poll", run: watchCommand},
+	{name: "test-notify", summary: "Send a test alert through every configured notifier", run: testNotifyCommand},
 }
 
-func notifyUser(currentTemp float64) error {
-	if currentTemp >= crashTemperature {
-		if err := beeep.Alert(
-			"🔥 CPU Temperature Critical!",
-			fmt.Sprintf("CPU has reached %.1f°C — system will crash soon!", currentTemp),
-			"",
-		); err != nil {
-			return fmt.Errorf("failed to send critical notification: %w", err)
-		}
+func main() {
+	os.Exit(dispatch(context.Background(), os.Args[1:]))
+}
 
-		return nil
+// dispatch runs the command named by the first argument with the rest of the arguments. Without a command, e.g.
+// "monitor -config monitor.yaml", the monitor runs as it always has.
+func dispatch(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		return runCommand(ctx, args)
 	}
 
-	if err := beeep.Notify(
-		"⚠ CPU Temperature Alert",
-		fmt.Sprintf("CPU temperature is at %.1f°C — please check your system!", currentTemp),
-		"",
-	); err != nil {
-		return fmt.Errorf("failed to send beep notification: %w", err)
+	switch name := args[0]; name {
+	case "help", "-h", "-help", "--help":
+		usage(os.Stdout)
+		return exitOK
+	default:
+		if strings.HasPrefix(name, "-") {
+			return runCommand(ctx, args)
+		}
 	}
 
-	return nil
-}
-
-func shouldNotify(currentTemp, lastTemp, crashTemp float64) bool {
-	if lastTemp == 0 {
-		return false // No previous temperature to compare
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(ctx, args[1:])
+		}
 	}
 
-	crashWorryThreshold := crashTemp * 0.85 // 85% of crash temperature
-	if currentTemp < crashWorryThreshold {
-		return false // Current temperature is below the threshold for concern
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", args[0])
+	usage(os.Stderr)
+	return exitUsage
+}
+
+// usage writes the commands of the monitor.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: monitor <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-12s %s\n", c.name, c.summary)
 	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run \"monitor <command> -help\" for the flags of a command.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Exit codes:")
+	fmt.Fprintln(w, "  0  success, and for once no sensor breaching its rule")
+	fmt.Fprintln(w, "  1  failure, e.g. an invalid configuration, unreadable sensors or a notification not sent")
+	fmt.Fprintln(w, "  2  invalid command line")
+	fmt.Fprintln(w, "  3  once found a sensor at its warning threshold")
+	fmt.Fprintln(w, "  4  once found a sensor at its critical threshold")
+}
 
-	// Calculate the threshold for notification
-	threshold := lastTemp * 1.05 // 5% increase from the last temperature
+// flags are the flags shared by the commands.
+type flags struct {
+	*flag.FlagSet
 
-	// Notify if the current temperature is significantly higher than the last recorded temperature
-	return currentTemp > threshold || currentTemp >= crashTemp
+	configPath string
+	format     string
 }
 
-func main() {
-	var lastTemp float64
-	beeep.AppName = appName
-
-	for {
-		temp, err := readCPUTemp()
-		if err != nil {
-			fmt.Printf("Error reading CPU temperature: %v\n", err)
-			return
+// newFlags creates the flags of a command, with the config flag and, if withFormat is set, the format flag.
+func newFlags(name string, withFormat bool) *flags {
+	f := &flags{FlagSet: flag.NewFlagSet(name, flag.ContinueOnError)}
+	f.StringVar(&f.configPath, "config", config.DefaultPath, "path to the configuration file")
+	if withFormat {
+		formats := make([]string, 0, len(report.Formats))
+		for _, format := range report.Formats {
+			formats = append(formats, string(format))
 		}
+		f.StringVar(&f.format, "format", string(report.FormatTable), "output format: "+strings.Join(formats, ", "))
+	}
 
-		if lastTemp == 0 {
-			lastTemp = temp
-			fmt.Printf("Initial CPU temperature: %.2f°C\n", temp)
-			continue
-		}
+	return f
+}
+
+// parse parses the arguments of the command, returning the exit code to stop with if they are invalid or help was
+// asked for.
+func (f *flags) parse(args []string) (int, bool) {
+	if err := f.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return exitOK, false
+	} else if err != nil {
+		return exitUsage, false
+	}
 
-		if shouldNotify(temp, lastTemp, crashTemperature) {
-			if err := notifyUser(temp); err != nil {
-				fmt.Printf("Error sending notification: %v\n", err)
-				return
-			}
-		} else {
-			fmt.Printf("CPU temperature is stable: %.2f°C\n", temp)
+	if f.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Unexpected arguments: %s\n", strings.Join(f.Args(), " "))
+		return exitUsage, false
+	}
+
+	if f.format != "" {
+		if _, err := report.ParseFormat(f.format); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid -format: %v\n", err)
+			return exitUsage, false
 		}
+	}
+
+	return exitOK, true
+}
 
-		lastTemp = temp
-		time.Sleep(500 * time.Millisecond) // Sleep for 250 milliseconds
+// outputFormat returns the output format given by the format flag.
+func (f *flags) outputFormat() report.Format {
+	return report.Format(f.format)
+}
+
+// loadConfig loads the configuration file given by the config flag. A missing file is only an error if the flag was
+// given explicitly, otherwise the defaults are used.
+func (f *flags) loadConfig() (*config.Config, error) {
+	explicit := false
+	f.Visit(func(fl *flag.Flag) {
+		explicit = explicit || fl.Name == "config"
+	})
+
+	cfg, err := config.Load(f.configPath)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return config.Default(), nil
+	} else if err != nil {
+		return nil, err
 	}
+
+	return cfg, nil
 }
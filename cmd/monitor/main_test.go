@@ -1,92 +1,102 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
 )
 
-func TestShouldNotify(t *testing.T) {
+func TestDispatch(t *testing.T) {
 	t.Parallel()
+
+	invalid := filepath.Join(t.TempDir(), "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalid, []byte("poll_interval: 0s\n"), 0o600))
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+
 	tests := []struct {
-		name        string
-		currentTemp float64
-		lastTemp    float64
-		expected    bool
+		name string
+		args []string
+		want int
 	}{
 		{
-			name:        "no previous temperature (lastTemp is 0)",
-			currentTemp: 90.0,
-			lastTemp:    0.0,
-			expected:    false,
-		},
-		{
-			name:        "current temp below worry threshold (85% of crash temp)",
-			currentTemp: 80.0,
-			lastTemp:    75.0,
-			expected:    false,
-		},
-		{
-			name:        "current temp at worry threshold but no significant increase",
-			currentTemp: 85.0,
-			lastTemp:    85.0,
-			expected:    false,
+			name: "help",
+			args: []string{"help"},
+			want: exitOK,
 		},
 		{
-			name:        "current temp above worry threshold with 5% increase",
-			currentTemp: 89.26, // 85 * 1.05 = 89.25, so 89.26 > 89.25
-			lastTemp:    85.0,
-			expected:    true,
+			name: "unknown command",
+			args: []string{"frobnicate"},
+			want: exitUsage,
 		},
 		{
-			name:        "current temp above worry threshold with slight increase (under 5%)",
-			currentTemp: 87.0,
-			lastTemp:    85.0,
-			expected:    false,
+			name: "command help",
+			args: []string{"once", "-help"},
+			want: exitOK,
 		},
 		{
-			name:        "current temp at crash temperature",
-			currentTemp: 100.0,
-			lastTemp:    90.0,
-			expected:    true,
+			name: "unknown flag",
+			args: []string{"once", "-verbose"},
+			want: exitUsage,
 		},
 		{
-			name:        "current temp above crash temperature",
-			currentTemp: 105.0,
-			lastTemp:    90.0,
-			expected:    true,
+			name: "unexpected argument",
+			args: []string{"list", "coretemp"},
+			want: exitUsage,
 		},
 		{
-			name:        "current temp at crash temp but below last temp",
-			currentTemp: 100.0,
-			lastTemp:    110.0,
-			expected:    true,
+			name: "invalid format",
+			args: []string{"once", "-format", "xml"},
+			want: exitUsage,
 		},
 		{
-			name:        "edge case: exactly at worry threshold",
-			currentTemp: 85.0,
-			lastTemp:    80.0,
-			expected:    true, // 80 * 1.05 = 84, so 85 > 84 and 85 >= 85 (worry threshold)
+			name: "missing config",
+			args: []string{"once", "-config", missing},
+			want: exitError,
 		},
 		{
-			name:        "edge case: just above 5% threshold at worry level",
-			currentTemp: 84.1, // 80 * 1.05 = 84, so 84.1 > 84
-			lastTemp:    80.0,
-			expected:    false, // below worry threshold (85)
+			name: "invalid config",
+			args: []string{"list", "-config", invalid},
+			want: exitError,
 		},
 		{
-			name:        "large temperature drop but still above worry threshold",
-			currentTemp: 90.0,
-			lastTemp:    95.0,
-			expected:    false, // 95 * 1.05 = 99.75, 90 < 99.75 and 90 < 100
+			name: "run with invalid config",
+			args: []string{"-config", invalid},
+			want: exitError,
 		},
 	}
 
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			result := shouldNotify(test.currentTemp, test.lastTemp, crashTemperature)
-			require.Equal(t, test.expected, result)
+
+			require.Equal(t, tt.want, dispatch(t.Context(), tt.args))
 		})
 	}
 }
+
+func TestFlags_LoadConfig(t *testing.T) {
+	t.Parallel()
+
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+
+	// A missing configuration file falls back on the defaults unless it was given explicitly.
+	f := newFlags("once", true)
+	_, ok := f.parse(nil)
+	require.True(t, ok)
+	f.configPath = missing
+
+	cfg, err := f.loadConfig()
+	require.NoError(t, err)
+	require.Equal(t, config.Default(), cfg)
+
+	f = newFlags("once", true)
+	_, ok = f.parse([]string{"-config", missing})
+	require.True(t, ok)
+
+	_, err = f.loadConfig()
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
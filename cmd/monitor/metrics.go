@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/metrics"
+)
+
+// serveMetrics serves the metrics of the exporter on the configured address in the background, until the returned
+// server is shut down. The address is listened on straight away so that a port that is in use stops the monitor from
+// starting.
+func serveMetrics(cfg config.Metrics, exporter *metrics.Exporter) (*http.Server, error) {
+	listener, err := net.Listen("tcp", cfg.Listen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", cfg.Listen, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(cfg.Path, exporter)
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("Error serving metrics: %v\n", err)
+		}
+	}()
+
+	fmt.Printf("Serving metrics on http://%s%s\n", listener.Addr(), cfg.Path)
+	return server, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/alertmanager"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/desktop"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/discord"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/email"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/gotify"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/matrix"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/ntfy"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/opsgenie"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/pagerduty"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/pushover"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/slack"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/teams"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/telegram"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/webhook"
+)
+
+// newRegistry creates the registry of every notifier type the monitor supports.
+func newRegistry() *notify.Registry {
+	registry := notify.NewRegistry()
+	registry.Register(config.NotifierDesktop, desktop.New)
+	registry.Register(config.NotifierWebhook, webhook.New)
+	registry.Register(config.NotifierEmail, email.New)
+	registry.Register(config.NotifierSlack, slack.New)
+	registry.Register(config.NotifierDiscord, discord.New)
+	registry.Register(config.NotifierTeams, teams.New)
+	registry.Register(config.NotifierMatrix, matrix.New)
+	registry.Register(config.NotifierNtfy, ntfy.New)
+	registry.Register(config.NotifierGotify, gotify.New)
+	registry.Register(config.NotifierPushover, pushover.New)
+	registry.Register(config.NotifierTelegram, telegram.New)
+	registry.Register(config.NotifierPagerDuty, pagerduty.New)
+	registry.Register(config.NotifierOpsgenie, opsgenie.New)
+	registry.Register(config.NotifierAlertmanager, alertmanager.New)
+	return registry
+}
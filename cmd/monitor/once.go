@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/report"
+)
+
+// onceCommand reads the sensors once and prints them with the state of the alert of their rule. The exit code tells
+// whether any sensor is breaching its rule, and how severely, so that it can be used as a health check. A failure to
+// read any of the sensors is an error, even if the others were read.
+func onceCommand(ctx context.Context, args []string) int {
+	f := newFlags("once", true)
+	if code, ok := f.parse(args); !ok {
+		return code
+	}
+
+	cfg, err := f.loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return exitError
+	}
+
+	source, err := newSource(ctx, cfg.Sources, cfg.Health)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating sensor source: %v\n", err)
+		return exitError
+	}
+
+	// Sensors that were read are still printed when others could not be, but the exit code is that of a failure so
+	// that a health check does not pass on the sensors it could not see.
+	snapshot, readErr := readSnapshot(ctx, source, cfg.ReadTimeout.Std())
+	if readErr != nil {
+		fmt.Fprintf(os.Stderr, "Error reading sensors: %v\n", readErr)
+	}
+	if snapshot == nil {
+		return exitError
+	}
+
+	// A single evaluation cannot last for the "for" duration of a rule, so alerts breaching it are only pending. Nor
+	// can a sensor go missing or stale, so the watchdog only raises pending alerts on impossible values and on
+	// expected patterns that match no sensor.
+	evaluator := alert.NewEvaluator(cfg.Rules)
+	evaluator.Evaluate(snapshot)
+	fans := alert.NewFans(cfg.Fans, alert.SystemClock{})
+	fans.Evaluate(snapshot)
+	watchdog := alert.NewWatchdog(cfg.Watchdog, alert.SystemClock{})
+	watchdog.Evaluate(snapshot)
+
+	alerts := slices.Concat(evaluator.Alerts(), fans.Alerts(), watchdog.Alerts())
+	if err := report.Write(os.Stdout, f.outputFormat(), stateTable(snapshot, alerts)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		return exitError
+	}
+
+	if readErr != nil {
+		return exitError
+	}
+
+	return severityExitCode(alerts)
+}
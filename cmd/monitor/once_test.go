@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeSysfs creates a sysfs tree in a temporary directory, returning its root. Values starting with "->" are created
+// as symlinks.
+func writeSysfs(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+
+		if target, ok := strings.CutPrefix(content, "->"); ok {
+			require.NoError(t, os.Symlink(target, full))
+			continue
+		}
+
+		require.NoError(t, os.WriteFile(full, []byte(content+"\n"), 0o600))
+	}
+
+	return root
+}
+
+// writeConfig writes a configuration file reading the hwmon chips of the sysfs root, followed by the rest of the
+// configuration, returning its path.
+func writeConfig(t *testing.T, path, root, rest string) string {
+	t.Helper()
+
+	if path == "" {
+		path = filepath.Join(t.TempDir(), "monitor.yaml")
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+sources:
+  - type: hwmon
+    sysfs_root: `+root+`
+`+rest), 0o600))
+
+	return path
+}
+
+// writeFixture creates a sysfs hwmon tree with a CPU package at the given temperature in millidegrees and the extra
+// files, and a configuration reading it with a rule warning at 80°C and critical at 90°C. It returns the path of the
+// configuration.
+func writeFixture(t *testing.T, millidegrees string, extra map[string]string) string {
+	t.Helper()
+
+	files := map[string]string{
+		"class/hwmon/hwmon0/name":        "coretemp",
+		"class/hwmon/hwmon0/temp1_label": "Package id 0",
+		"class/hwmon/hwmon0/temp1_input": millidegrees,
+	}
+	for path, content := range extra {
+		files[path] = content
+	}
+
+	return writeConfig(t, "", writeSysfs(t, files), `
+rules:
+  - name: cpu
+    match: coretemp-*
+    warn: 80
+    critical: 90
+`)
+}
+
+func TestOnceCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		millidegrees string
+		extra        map[string]string
+		want         int
+	}{
+		{
+			name:         "ok",
+			millidegrees: "55000",
+			want:         exitOK,
+		},
+		{
+			name:         "warning",
+			millidegrees: "85000",
+			want:         exitWarning,
+		},
+		{
+			name:         "critical",
+			millidegrees: "95000",
+			want:         exitCritical,
+		},
+		{
+			name:         "partial read",
+			millidegrees: "55000",
+			extra: map[string]string{
+				"class/hwmon/hwmon1/name":          "broken",
+				"class/hwmon/hwmon1/device":        "->../../../devices/platform/broken.0",
+				"devices/platform/broken.0/uevent": "",
+			},
+			want: exitError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := writeFixture(t, tt.millidegrees, tt.extra)
+			require.Equal(t, tt.want, onceCommand(t.Context(), []string{"-config", path, "-format", "csv"}))
+		})
+	}
+}
+
+func TestOnceCommand_NoChips(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfig(t, "", t.TempDir(), "")
+	require.Equal(t, exitError, onceCommand(t.Context(), []string{"-config", path}))
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/output"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/output/graphite"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/output/influxdb"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/output/mqtt"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/output/otlp"
+)
+
+// newOutputRegistry creates the registry of every output type the monitor supports.
+func newOutputRegistry() *output.Registry {
+	registry := output.NewRegistry()
+	registry.Register(config.OutputInfluxDB, influxdb.New)
+	registry.Register(config.OutputGraphite, graphite.New)
+	registry.Register(config.OutputOTLP, otlp.New)
+	registry.Register(config.OutputMQTT, mqtt.New)
+	return registry
+}
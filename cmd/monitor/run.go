@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/gen2brain/beeep"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/history"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/metrics"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/output"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// monitor is what the run command builds from the configuration. It is built again when the configuration is
+// reloaded, taking over the notifiers and outputs that have not changed, while the evaluator, and with it the state of
+// every alert, is kept.
+type monitor struct {
+	cfg        *config.Config
+	source     sensors.Source
+	dispatcher *notify.Dispatcher
+	pusher     *output.Pusher
+
+	// notifications and readings send what each poll hands over in the background, once the monitor is started.
+	notifications *courier
+	readings      *courier
+}
+
+// state is what the run command keeps for as long as it runs, across reloads of the configuration.
+type state struct {
+	evaluator *alert.Evaluator
+	fans      *alert.Fans
+	watchdog  *alert.Watchdog
+	degraded  *alert.Degraded
+	exporter  *metrics.Exporter
+	store     *history.Store
+
+	// metrics serves the metrics of the exporter, if configured.
+	metrics *http.Server
+
+	// health is the health of the sources at the previous read, so that only changes to it are logged.
+	health []*sensors.Health
+}
+
+// runCommand monitors the sensors until it is stopped with SIGINT or SIGTERM, notifying on alerts and pushing readings
+// to every configured output. Sources that fail to read are retried rather than stopping the monitor. SIGHUP reloads
+// the configuration.
+func runCommand(ctx context.Context, args []string) int {
+	f := newFlags("run", false)
+	if code, ok := f.parse(args); !ok {
+		return code
+	}
+
+	cfg, err := f.loadConfig()
+	if err != nil {
+		fmt.Printf("Error loading configuration: %v\n", err)
+		return exitError
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+
+	m, err := newMonitor(ctx, cfg)
+	if err != nil {
+		fmt.Printf("Error starting monitor: %v\n", err)
+		return exitError
+	}
+	fmt.Printf("Reading sensors from %s\n", m.source.Name())
+
+	s, err := newState(ctx, cfg)
+	if err != nil {
+		fmt.Printf("Error starting monitor: %v\n", err)
+		return exitError
+	}
+
+	m.start(s.store)
+	ticker := time.NewTicker(cfg.PollInterval.Std())
+	defer ticker.Stop()
+
+	for {
+		m.poll(ctx, s)
+
+		select {
+		case <-ctx.Done():
+			m.shutdown(ctx, s)
+			return exitOK
+		case <-ticker.C:
+		case <-reload:
+			next, err := m.reload(ctx, f, s)
+			if err != nil {
+				fmt.Printf("Error reloading configuration, keeping the current one: %v\n", err)
+				continue
+			}
+
+			ticker.Reset(next.cfg.PollInterval.Std())
+			m = next
+			fmt.Printf("Reloaded configuration, reading sensors from %s\n", m.source.Name())
+		}
+	}
+}
+
+// newMonitor creates the source, notifiers and outputs described by the configuration.
+func newMonitor(ctx context.Context, cfg *config.Config) (*monitor, error) {
+	readCtx, cancel := context.WithTimeout(ctx, cfg.ReadTimeout.Std())
+	defer cancel()
+
+	source, err := newSource(readCtx, cfg.Sources, cfg.Health)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sensor source: %w", err)
+	}
+
+	dispatcher, err := notify.NewDispatcher(newRegistry(), cfg.Notifiers, alert.SystemClock{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create notifiers: %w", err)
+	}
+
+	pusher, err := output.NewPusher(newOutputRegistry(), cfg.Outputs, alert.SystemClock{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create outputs: %w", err)
+	}
+
+	beeep.AppName = cfg.AppName
+	return &monitor{
+		cfg:        cfg,
+		source:     source,
+		dispatcher: dispatcher,
+		pusher:     pusher,
+	}, nil
+}
+
+// newState creates what the run command keeps across reloads: the alerts, the metrics exporter and, if configured,
+// the history and the metrics endpoint.
+func newState(ctx context.Context, cfg *config.Config) (*state, error) {
+	s := &state{
+		evaluator: alert.NewEvaluator(cfg.Rules, alert.WithMissingFor(cfg.Watchdog.MissingFor.Std())),
+		fans:      alert.NewFans(cfg.Fans, alert.SystemClock{}),
+		watchdog:  alert.NewWatchdog(cfg.Watchdog, alert.SystemClock{}),
+		degraded:  alert.NewDegraded(cfg.Health.DegradedAfter, alert.SystemClock{}),
+		exporter:  metrics.NewExporter(),
+	}
+
+	if cfg.History.Path != "" {
+		store, err := history.Open(ctx, cfg.History, alert.SystemClock{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to open history: %w", err)
+		}
+
+		s.store = store
+		fmt.Printf("Recording history to %s\n", cfg.History.Path)
+	}
+
+	if cfg.Metrics.Listen != "" {
+		server, err := serveMetrics(cfg.Metrics, s.exporter)
+		if err != nil {
+			if s.store != nil {
+				_ = s.store.Close(ctx)
+			}
+			return nil, fmt.Errorf("failed to serve metrics: %w", err)
+		}
+
+		s.metrics = server
+	}
+
+	return s, nil
+}
+
+// start starts sending the notifications and readings of every poll in the background, recording them in the history
+// if there is one.
+func (m *monitor) start(store *history.Store) {
+	if store != nil {
+		m.dispatcher.SetRecorder(store)
+	}
+
+	m.notifications = newCourier(m.notify, m.cfg.DeliveryTimeout.Std())
+	m.readings = newCourier(func(ctx context.Context, deliveries []*delivery) {
+		m.write(ctx, store, deliveries)
+	}, m.cfg.DeliveryTimeout.Std())
+}
+
+// poll reads the sensors once and evaluates the alerts of their readings and of the health of the sources. The
+// notifications of the transitions, the readings pushed to the outputs and the history are sent in the background, so
+// that a notifier or output that is down does not hold up the next poll.
+func (m *monitor) poll(ctx context.Context, s *state) {
+	start := time.Now()
+	snapshot, err := readSnapshot(ctx, m.source, m.cfg.ReadTimeout.Std())
+	s.exporter.ObserveRead(start, time.Since(start), err)
+	if ctx.Err() != nil {
+		return
+	}
+
+	health := sensors.HealthOf(m.source)
+	s.exporter.ObserveHealth(health)
+	logHealth(s.health, health)
+	s.health = health
+
+	// When no source could be read at all the alerts of the sensors are left as they are, so that the sources are
+	// alerted on as degraded rather than every one of their sensors as missing.
+	var events []*alert.Event
+	if snapshot == nil {
+		snapshot = sensors.NewSnapshot(time.Now(), nil)
+	} else {
+		events = slices.Concat(
+			s.evaluator.Evaluate(snapshot),
+			s.fans.Evaluate(snapshot),
+			s.watchdog.Evaluate(snapshot),
+		)
+	}
+
+	events = append(events, s.degraded.Evaluate(health)...)
+	alerts := slices.Concat(s.evaluator.Alerts(), s.fans.Alerts(), s.watchdog.Alerts(), s.degraded.Alerts())
+	s.exporter.ObserveSnapshot(snapshot)
+	s.exporter.ObserveAlerts(events, alerts)
+
+	// The alerts are copied, as they carry on changing with the next poll while they are being sent.
+	d := &delivery{snapshot: snapshot, events: events, alerts: make([]*alert.Alert, 0, len(alerts))}
+	for _, a := range alerts {
+		c := *a
+		d.alerts = append(d.alerts, &c)
+	}
+	m.notifications.add(d)
+	m.readings.add(d)
+
+	if err == nil && len(alerts) == 0 {
+		fmt.Printf("All %d sensors are stable\n", len(snapshot.Readings))
+	}
+}
+
+// notify sends the notifications for the events of the deliveries, and reminders for the alerts still firing as of the
+// latest of them.
+func (m *monitor) notify(ctx context.Context, deliveries []*delivery) {
+	events := make([]*alert.Event, 0)
+	for _, d := range deliveries {
+		events = append(events, d.events...)
+	}
+
+	if err := m.dispatcher.Dispatch(ctx, events, deliveries[len(deliveries)-1].alerts); err != nil {
+		fmt.Printf("Error sending notifications: %v\n", err)
+	}
+}
+
+// write pushes the snapshots of the deliveries to the outputs and records them in the history, if there is one.
+func (m *monitor) write(ctx context.Context, store *history.Store, deliveries []*delivery) {
+	for _, d := range deliveries {
+		if err := m.pusher.Push(ctx, d.snapshot); err != nil {
+			fmt.Printf("Error writing readings: %v\n", err)
+		}
+
+		if store != nil {
+			if err := store.Record(ctx, d.snapshot, d.events); err != nil {
+				fmt.Printf("Error recording history: %v\n", err)
+			}
+		}
+	}
+}
+
+// logHealth logs the changes in the health of the sources since the previous read: a read that failed, a source that
+// recovered, and chips that could not be read. Reads skipped while a source backs off are not logged.
+func logHealth(prev, health []*sensors.Health) {
+	bySource := make(map[string]*sensors.Health, len(prev))
+	for _, h := range prev {
+		bySource[h.Source] = h
+	}
+
+	for _, h := range health {
+		before, ok := bySource[h.Source]
+		if !ok {
+			before = &sensors.Health{Source: h.Source}
+		}
+
+		switch {
+		case h.ConsecutiveFailures > before.ConsecutiveFailures:
+			fmt.Printf("Error reading %s after %d failed reads in a row, retrying at %s: %v\n",
+				h.Source, h.ConsecutiveFailures, h.RetryAt.Format(time.TimeOnly), h.Err)
+		case h.ConsecutiveFailures == 0 && before.ConsecutiveFailures > 0:
+			fmt.Printf("Reading %s again after %d failures\n", h.Source, before.ConsecutiveFailures)
+		}
+
+		if h.Status() == sensors.StatusPartial && (before.Err == nil || before.Err.Error() != h.Err.Error()) {
+			fmt.Printf("Error reading %s: %v\n", h.Source, h.Err)
+		}
+	}
+}
+
+// reload loads the configuration again and builds a monitor from it, which takes over from the current one once it
+// has been built, so that an invalid configuration leaves the current one running. Notifiers and outputs whose
+// configuration has not changed are carried over with their state, and the alerts are evaluated with the new rules
+// from then on. The metrics endpoint and the history are not reloaded.
+func (m *monitor) reload(ctx context.Context, f *flags, s *state) (*monitor, error) {
+	cfg, err := f.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := newMonitor(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sources whose configuration has not changed are kept, so that their health and backoff carry on.
+	if cfg.Health == m.cfg.Health && slices.EqualFunc(cfg.Sources, m.cfg.Sources, func(a, b *config.Source) bool {
+		return *a == *b
+	}) {
+		next.source = m.source
+	}
+
+	if cfg.Metrics != m.cfg.Metrics || cfg.History != m.cfg.History {
+		fmt.Println("Changes to metrics and history take effect when the monitor is restarted")
+		next.cfg.Metrics, next.cfg.History = m.cfg.Metrics, m.cfg.History
+	}
+
+	// What the polls handed over is sent before the notifiers and outputs are taken over, so that nothing is sent to
+	// them from two places at once.
+	m.notifications.close(ctx)
+	m.readings.close(ctx)
+
+	if err := next.dispatcher.Adopt(ctx, m.dispatcher); err != nil {
+		fmt.Printf("Error sending notifications: %v\n", err)
+	}
+
+	if err := next.pusher.Adopt(ctx, m.pusher); err != nil {
+		fmt.Printf("Error writing readings: %v\n", err)
+	}
+
+	next.start(s.store)
+	s.evaluator.SetRules(cfg.Rules)
+	s.evaluator.SetMissingFor(cfg.Watchdog.MissingFor.Std())
+	s.fans.SetRules(cfg.Fans)
+	s.watchdog.SetConfig(cfg.Watchdog)
+	s.degraded.SetAfter(cfg.Health.DegradedAfter)
+	return next, nil
+}
+
+// shutdown sends the notifications and readings that are still pending, closes the history and stops serving
+// metrics, giving up after the shutdown timeout. It is called once the context is done, so it waits on a context of
+// its own.
+func (m *monitor) shutdown(ctx context.Context, s *state) {
+	fmt.Println("Shutting down")
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.cfg.ShutdownTimeout.Std())
+	defer cancel()
+
+	m.close(ctx)
+	if s.store != nil {
+		if err := s.store.Close(ctx); err != nil {
+			fmt.Printf("Error closing history: %v\n", err)
+		}
+	}
+
+	if s.metrics != nil {
+		if err := s.metrics.Shutdown(ctx); err != nil {
+			fmt.Printf("Error stopping metrics: %v\n", err)
+		}
+	}
+}
+
+// close waits for the notifications and readings handed over by the polls to be sent, then sends those still batched
+// and closes the outputs.
+func (m *monitor) close(ctx context.Context) {
+	m.notifications.close(ctx)
+	m.readings.close(ctx)
+
+	if err := m.dispatcher.Flush(ctx, true); err != nil {
+		fmt.Printf("Error sending notifications: %v\n", err)
+	}
+
+	if err := m.pusher.Close(ctx); err != nil {
+		fmt.Printf("Error writing readings: %v\n", err)
+	}
+}
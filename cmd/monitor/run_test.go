@@ -0,0 +1,131 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonitor_Reload(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		notifier string
+		want     int32
+	}{
+		{
+			// The webhook keeps the cooldown of the alert, so the alert firing again within it is not sent.
+			name: "unchanged notifier",
+			want: 2,
+		},
+		{
+			// The webhook is replaced, so the alert firing again is sent to the new one.
+			name:     "changed notifier",
+			notifier: "      method: PUT\n",
+			want:     3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var received atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				received.Add(1)
+			}))
+			t.Cleanup(server.Close)
+
+			root := writeSysfs(t, map[string]string{
+				"class/hwmon/hwmon0/name":        "coretemp",
+				"class/hwmon/hwmon0/temp1_label": "Package id 0",
+				"class/hwmon/hwmon0/temp1_input": "95000",
+			})
+			setTemp := func(millidegrees string) {
+				path := filepath.Join(root, "class/hwmon/hwmon0/temp1_input")
+				require.NoError(t, os.WriteFile(path, []byte(millidegrees+"\n"), 0o600))
+			}
+
+			rest := `
+rules:
+  - name: cpu
+    match: coretemp-*
+    critical: 90
+    cooldown: 1h
+notifiers:
+  - name: hook
+    type: webhook
+    webhook:
+      url: ` + server.URL + `
+`
+			path := writeConfig(t, "", root, rest)
+
+			f := newFlags("run", false)
+			_, ok := f.parse([]string{"-config", path})
+			require.True(t, ok)
+
+			cfg, err := f.loadConfig()
+			require.NoError(t, err)
+
+			m, err := newMonitor(t.Context(), cfg)
+			require.NoError(t, err)
+
+			s, err := newState(t.Context(), cfg)
+			require.NoError(t, err)
+			m.start(s.store)
+
+			// The alert fires and resolves before the configuration is reloaded, with the rules unchanged.
+			m.poll(t.Context(), s)
+			setTemp("55000")
+			m.poll(t.Context(), s)
+
+			writeConfig(t, path, root, "poll_interval: 1s\n"+rest+tt.notifier)
+			m, err = m.reload(t.Context(), f, s)
+			require.NoError(t, err)
+			require.Equal(t, int32(2), received.Load(), "what was handed over is sent before reloading")
+
+			setTemp("95000")
+			m.poll(t.Context(), s)
+			m.shutdown(t.Context(), s)
+			require.Equal(t, tt.want, received.Load())
+		})
+	}
+}
+
+func TestMonitor_Reload_Invalid(t *testing.T) {
+	t.Parallel()
+
+	root := writeSysfs(t, map[string]string{
+		"class/hwmon/hwmon0/name":        "coretemp",
+		"class/hwmon/hwmon0/temp1_input": "55000",
+	})
+	path := writeConfig(t, "", root, "")
+
+	f := newFlags("run", false)
+	_, ok := f.parse([]string{"-config", path})
+	require.True(t, ok)
+
+	cfg, err := f.loadConfig()
+	require.NoError(t, err)
+
+	m, err := newMonitor(t.Context(), cfg)
+	require.NoError(t, err)
+
+	s, err := newState(t.Context(), cfg)
+	require.NoError(t, err)
+	m.start(s.store)
+
+	// An invalid configuration leaves the current monitor running.
+	writeConfig(t, path, root, "poll_interval: 0s\n")
+	_, err = m.reload(t.Context(), f, s)
+	require.ErrorContains(t, err, "poll_interval: must be greater than zero")
+
+	m.poll(t.Context(), s)
+	m.shutdown(t.Context(), s)
+}
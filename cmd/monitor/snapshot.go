@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/report"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// readSnapshot reads a snapshot of the sensors of the source, giving up after the timeout. When some chips could not
+// be read, the snapshot of the others is returned with the error; the snapshot is nil only if none could be read.
+func readSnapshot(ctx context.Context, source sensors.Source, timeout time.Duration) (*sensors.Snapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	chips, err := source.Read(ctx)
+	if err != nil && len(chips) == 0 {
+		return nil, err
+	}
+
+	return sensors.NewSnapshot(time.Now(), chips), err
+}
+
+// describeHealth describes the health of every source, e.g. "hwmon ok, lm-sensors failing after 3 failed reads in a
+// row, retrying at 12:00:05".
+func describeHealth(health []*sensors.Health) string {
+	parts := make([]string, 0, len(health))
+	for _, h := range health {
+		switch h.Status() {
+		case sensors.StatusFailing:
+			parts = append(parts, fmt.Sprintf("%s failing after %d failed reads in a row, retrying at %s",
+				h.Source, h.ConsecutiveFailures, h.RetryAt.Format(time.TimeOnly)))
+		default:
+			parts = append(parts, h.Source+" "+string(h.Status()))
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// stateTable returns a row for every reading of the snapshot with the state of its most severe alert. Alerts on
+// sensors that are not in the snapshot, such as missing sensors, are added after them.
+func stateTable(snapshot *sensors.Snapshot, alerts []*alert.Alert) *report.Table {
+	byID := make(map[string]*alert.Alert, len(alerts))
+	for _, a := range alerts {
+		if existing, ok := byID[a.Reading.ID()]; !ok || a.Severity > existing.Severity {
+			byID[a.Reading.ID()] = a
+		}
+	}
+
+	table := report.NewTable("id", "kind", "value", "unit", "state", "severity", "reason")
+	for _, r := range snapshot.Readings {
+		var value any
+		if v, ok := r.Input(); ok {
+			value = v
+		}
+
+		state, severity, reason := alert.StateOK.String(), alert.SeverityNone.String(), ""
+		if a, ok := byID[r.ID()]; ok {
+			state, severity, reason = a.State.String(), a.Severity.String(), a.Reason
+			delete(byID, r.ID())
+		}
+
+		table.Add(r.ID(), r.Kind.String(), value, r.Kind.Unit(), state, severity, reason)
+	}
+
+	for _, a := range alerts {
+		if byID[a.Reading.ID()] != a {
+			continue
+		}
+
+		table.Add(a.Reading.ID(), a.Reading.Kind.String(), nil, a.Reading.Kind.Unit(), a.State.String(),
+			a.Severity.String(), a.Reason)
+	}
+
+	return table
+}
+
+// sensorTable returns a row for every reading of the snapshot describing the sensor: its kind, unit, current value,
+// the limits and alarm flags reported by the hardware, and the rule that applies to it.
+func sensorTable(snapshot *sensors.Snapshot, rules []*config.Rule) *report.Table {
+	table := report.NewTable("id", "chip", "adapter", "feature", "kind", "unit", "value", "limits", "alarms", "rule")
+	for _, r := range snapshot.Readings {
+		var value any
+		if v, ok := r.Input(); ok {
+			value = v
+		}
+
+		limits := make(map[string]float64)
+		alarms := make(map[string]float64)
+		for sf, v := range r.Values {
+			switch {
+			case sf == sensors.SubfeatureInput:
+			case strings.HasSuffix(string(sf), string(sensors.SubfeatureAlarm)):
+				alarms[string(sf)] = v
+			default:
+				limits[string(sf)] = v
+			}
+		}
+
+		rule := ""
+		for _, rl := range rules {
+			if rl.Matches(r) {
+				rule = rl.Name
+				break
+			}
+		}
+
+		table.Add(r.ID(), r.Chip, r.Adapter, r.Name, r.Kind.String(), r.Kind.Unit(), value, limits, alarms, rule)
+	}
+
+	return table
+}
+
+// severityExitCode returns the exit code of the most severe of the alerts.
+func severityExitCode(alerts []*alert.Alert) int {
+	code := exitOK
+	for _, a := range alerts {
+		switch a.Severity {
+		case alert.SeverityCritical:
+			return exitCritical
+		case alert.SeverityWarning:
+			code = exitWarning
+		default:
+		}
+	}
+
+	return code
+}
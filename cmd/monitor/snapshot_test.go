@@ -0,0 +1,115 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/report"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// testSnapshot is a snapshot of a CPU package and core temperature and a fan without a reading.
+func testSnapshot() *sensors.Snapshot {
+	return sensors.NewSnapshot(time.Unix(0, 0), []*sensors.Chip{
+		{
+			Name: "coretemp-isa-0000",
+			Features: []*sensors.Feature{
+				{
+					Name:   "Package id 0",
+					Kind:   sensors.KindTemperature,
+					Values: map[sensors.Subfeature]float64{sensors.SubfeatureInput: 91, "crit": 100},
+				},
+				{
+					Name:   "Core 0",
+					Kind:   sensors.KindTemperature,
+					Values: map[sensors.Subfeature]float64{sensors.SubfeatureInput: 55},
+				},
+			},
+		},
+		{
+			Name: "dell_smm-virtual-0",
+			Features: []*sensors.Feature{
+				{Name: "fan1", Kind: sensors.KindFan, Values: map[sensors.Subfeature]float64{}},
+			},
+		},
+	})
+}
+
+func TestStateTable(t *testing.T) {
+	t.Parallel()
+
+	snapshot := testSnapshot()
+	pkg := snapshot.Readings[0]
+	missing := &sensors.Reading{
+		Feature: &sensors.Feature{Name: "Composite", Kind: sensors.KindTemperature},
+		Chip:    "nvme-pci-0100",
+	}
+
+	table := stateTable(snapshot, []*alert.Alert{
+		{Reading: pkg, State: alert.StatePending, Severity: alert.SeverityWarning, Reason: "above 85.0°C"},
+		{Reading: pkg, State: alert.StateFiring, Severity: alert.SeverityCritical, Reason: "above 90.0°C"},
+		{Reading: missing, State: alert.StatePending, Severity: alert.SeverityWarning, Reason: "not reported"},
+	})
+
+	// The most severe alert of a reading is shown, and alerts on sensors missing from the snapshot are added last.
+	require.Equal(t, &report.Table{
+		Columns: []string{"id", "kind", "value", "unit", "state", "severity", "reason"},
+		Rows: [][]any{
+			{"coretemp-isa-0000/Package id 0", "temp", 91.0, "°C", "firing", "critical", "above 90.0°C"},
+			{"coretemp-isa-0000/Core 0", "temp", 55.0, "°C", "ok", "none", ""},
+			{"dell_smm-virtual-0/fan1", "fan", nil, "RPM", "ok", "none", ""},
+			{"nvme-pci-0100/Composite", "temp", nil, "°C", "pending", "warning", "not reported"},
+		},
+	}, table)
+}
+
+func TestSeverityExitCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		severities []alert.Severity
+		want       int
+	}{
+		{
+			name: "no alerts",
+			want: exitOK,
+		},
+		{
+			name:       "warning",
+			severities: []alert.Severity{alert.SeverityWarning},
+			want:       exitWarning,
+		},
+		{
+			name:       "critical",
+			severities: []alert.Severity{alert.SeverityCritical},
+			want:       exitCritical,
+		},
+		{
+			name:       "most severe",
+			severities: []alert.Severity{alert.SeverityWarning, alert.SeverityCritical, alert.SeverityWarning},
+			want:       exitCritical,
+		},
+		{
+			name:       "no severity",
+			severities: []alert.Severity{alert.SeverityNone},
+			want:       exitOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			alerts := make([]*alert.Alert, 0, len(tt.severities))
+			for _, s := range tt.severities {
+				alerts = append(alerts, &alert.Alert{Severity: s})
+			}
+
+			require.Equal(t, tt.want, severityExitCode(alerts))
+		})
+	}
+}
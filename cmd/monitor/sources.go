@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// newSource creates the sources described by the configuration, combining them if more than one is given. Every
+// source backs off when it fails and tracks its health.
+func newSource(ctx context.Context, cfgs []*config.Source, health config.Health) (sensors.Source, error) {
+	sources := make([]sensors.Source, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		var source sensors.Source
+		switch cfg.Type {
+		case config.SourceAuto:
+			source = detectSource(ctx, cfg.SysfsRoot)
+		case config.SourceHwmon:
+			source = sensors.NewHwmon(cfg.SysfsRoot)
+		case config.SourceThermal:
+			source = sensors.NewThermal(cfg.SysfsRoot)
+		case config.SourceLMSensors:
+			source = sensors.NewLMSensors()
+		default:
+			return nil, fmt.Errorf("unknown source type %q", cfg.Type)
+		}
+
+		sources = append(sources, sensors.NewRetrying(source, health.Backoff.Std(), health.MaxBackoff.Std()))
+	}
+
+	if len(sources) == 1 {
+		return sources[0], nil
+	}
+
+	return sensors.NewMulti(sources...), nil
+}
+
+// detectSource picks the best source available on the host. The sysfs hwmon interface is preferred as it does not
+// need to fork a process on every read. Hosts without any hwmon chips fall back to the kernel thermal zones, and
+// finally to lm-sensors when sysfs is not available at all. A source that reads some of its chips is picked even if
+// others fail, as they are reported by its health rather than being a reason to fall back.
+func detectSource(ctx context.Context, sysfsRoot string) sensors.Source {
+	hwmon := sensors.NewHwmon(sysfsRoot)
+	if chips, _ := hwmon.Read(ctx); len(chips) > 0 {
+		return hwmon
+	}
+
+	thermal := sensors.NewThermal(sysfsRoot)
+	if chips, _ := thermal.Read(ctx); len(chips) > 0 {
+		return thermal
+	}
+
+	return sensors.NewLMSensors()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+func TestDetectSource(t *testing.T) {
+	t.Parallel()
+
+	coretemp := map[string]string{
+		"class/hwmon/hwmon0/name":        "coretemp",
+		"class/hwmon/hwmon0/temp1_input": "55000",
+	}
+	broken := map[string]string{
+		"class/hwmon/hwmon0/name":          "broken",
+		"class/hwmon/hwmon0/device":        "->../../../devices/platform/broken.0",
+		"devices/platform/broken.0/uevent": "",
+	}
+	thermal := map[string]string{
+		"class/thermal/thermal_zone0/type": "cpu-thermal",
+		"class/thermal/thermal_zone0/temp": "47236",
+	}
+
+	tests := []struct {
+		name  string
+		files []map[string]string
+		want  sensors.Source
+	}{
+		{
+			name:  "hwmon",
+			files: []map[string]string{coretemp, thermal},
+			want:  &sensors.Hwmon{},
+		},
+		{
+			name: "hwmon with an unreadable chip",
+			files: []map[string]string{coretemp, thermal, {
+				"class/hwmon/hwmon1/name":          "broken",
+				"class/hwmon/hwmon1/device":        "->../../../devices/platform/broken.0",
+				"devices/platform/broken.0/uevent": "",
+			}},
+			want: &sensors.Hwmon{},
+		},
+		{
+			name:  "thermal",
+			files: []map[string]string{broken, thermal},
+			want:  &sensors.Thermal{},
+		},
+		{
+			name: "lm-sensors",
+			want: &sensors.LMSensors{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			files := make(map[string]string)
+			for _, f := range tt.files {
+				for path, content := range f {
+					files[path] = content
+				}
+			}
+
+			require.IsType(t, tt.want, detectSource(t.Context(), writeSysfs(t, files)))
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/report"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// testNotifyCommand sends a synthetic alert through every configured notifier, or only the one named, bypassing their
+// minimum severity and rate limits, and reports which of them sent it.
+func testNotifyCommand(ctx context.Context, args []string) int {
+	f := newFlags("test-notify", true)
+	name := f.String("notifier", "", "only notify the notifier with this name")
+	severityName := f.String("severity", alert.SeverityCritical.String(), "severity of the test alert: warning, critical")
+	if code, ok := f.parse(args); !ok {
+		return code
+	}
+
+	severity, err := alert.ParseSeverity(*severityName)
+	if err != nil || severity == alert.SeverityNone {
+		fmt.Fprintf(os.Stderr, "Invalid -severity %q, must be one of warning, critical\n", *severityName)
+		return exitUsage
+	}
+
+	cfg, err := f.loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return exitError
+	}
+
+	targets := make([]*config.Notifier, 0, len(cfg.Notifiers))
+	for _, n := range cfg.Notifiers {
+		if *name == "" || n.Name == *name {
+			targets = append(targets, n)
+		}
+	}
+
+	if len(targets) == 0 {
+		if *name != "" {
+			fmt.Fprintf(os.Stderr, "No notifier named %q is configured\n", *name)
+			return exitUsage
+		}
+
+		fmt.Fprintln(os.Stderr, "No notifiers are configured")
+		return exitError
+	}
+
+	registry := newRegistry()
+	n := testNotification(severity, time.Now())
+	table := report.NewTable("notifier", "type", "status", "error")
+	code := exitOK
+	for _, target := range targets {
+		err := sendTest(ctx, registry, target, n)
+		if err != nil {
+			table.Add(target.Name, string(target.Type), "failed", err.Error())
+			code = exitError
+			continue
+		}
+
+		table.Add(target.Name, string(target.Type), "sent", nil)
+	}
+
+	if err := report.Write(os.Stdout, f.outputFormat(), table); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		return exitError
+	}
+
+	return code
+}
+
+// sendTest creates the notifier and sends the notification through it, flushing notifiers that batch so that it is
+// sent straight away.
+func sendTest(ctx context.Context, registry *notify.Registry, cfg *config.Notifier, n *alert.Notification) error {
+	notifier, err := registry.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := notifier.Notify(ctx, n); err != nil {
+		return err
+	}
+
+	if f, ok := notifier.(notify.Flusher); ok {
+		return f.Flush(ctx, true)
+	}
+
+	return nil
+}
+
+// testNotification returns a firing alert of the severity on a made up temperature sensor.
+func testNotification(severity alert.Severity, now time.Time) *alert.Notification {
+	const value, threshold = 101.0, 100.0
+
+	return &alert.Notification{
+		Event: &alert.Event{
+			Alert: alert.Alert{
+				Reading: &sensors.Reading{
+					Feature: &sensors.Feature{
+						Name:   "test",
+						Kind:   sensors.KindTemperature,
+						Values: map[sensors.Subfeature]float64{sensors.SubfeatureInput: value},
+					},
+					Chip: "sensor-monitor",
+				},
+				Rule:      &config.Rule{Name: "test-notify"},
+				State:     alert.StateFiring,
+				Severity:  severity,
+				Value:     value,
+				Threshold: threshold,
+				Reason:    "test alert sent by monitor test-notify",
+				StartedAt: now,
+				FiredAt:   now,
+			},
+			From: alert.StateOK,
+			Time: now,
+		},
+	}
+}
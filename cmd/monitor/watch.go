@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/report"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// clearScreen moves the cursor to the top left of the terminal and clears it.
+const clearScreen = "\x1b[H\x1b[2J"
+
+// watchCommand reads the sensors every poll and prints them with the state of the alert of their rule until
+// interrupted. A table is redrawn in place; other formats are written one after another.
+func watchCommand(ctx context.Context, args []string) int {
+	f := newFlags("watch", true)
+	interval := f.Duration("interval", 0, "how often the sensors are read (default the poll interval)")
+	if code, ok := f.parse(args); !ok {
+		return code
+	}
+
+	cfg, err := f.loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return exitError
+	}
+
+	if *interval <= 0 {
+		*interval = cfg.PollInterval.Std()
+	}
+
+	source, err := newSource(ctx, cfg.Sources, cfg.Health)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating sensor source: %v\n", err)
+		return exitError
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	evaluator := alert.NewEvaluator(cfg.Rules, alert.WithMissingFor(cfg.Watchdog.MissingFor.Std()))
+	fans := alert.NewFans(cfg.Fans, alert.SystemClock{})
+	watchdog := alert.NewWatchdog(cfg.Watchdog, alert.SystemClock{})
+	for {
+		// A source that fails is retried with backoff, so a watch is not stopped by a read failing.
+		snapshot, err := readSnapshot(ctx, source, cfg.ReadTimeout.Std())
+		if ctx.Err() != nil {
+			return exitOK
+		} else if snapshot == nil {
+			snapshot = sensors.NewSnapshot(time.Now(), nil)
+		} else {
+			evaluator.Evaluate(snapshot)
+			fans.Evaluate(snapshot)
+			watchdog.Evaluate(snapshot)
+		}
+
+		if f.outputFormat() == report.FormatTable {
+			fmt.Print(clearScreen)
+			fmt.Printf("%s at %s, every %s\n", source.Name(), snapshot.Time.Format(time.TimeOnly), *interval)
+			fmt.Printf("Sources: %s\n", describeHealth(sensors.HealthOf(source)))
+			if err != nil {
+				fmt.Printf("Error reading sensors: %v\n", err)
+			}
+			fmt.Println()
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading sensors: %v\n", err)
+		}
+
+		alerts := slices.Concat(evaluator.Alerts(), fans.Alerts(), watchdog.Alerts())
+		if err := report.Write(os.Stdout, f.outputFormat(), stateTable(snapshot, alerts)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			return exitError
+		}
+
+		select {
+		case <-ctx.Done():
+			return exitOK
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package alert
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// Severity is how serious an alert is.
+type Severity int
+
+const (
+	// SeverityNone means the sensor is healthy.
+	SeverityNone Severity = iota
+
+	// SeverityWarning means the sensor needs attention.
+	SeverityWarning
+
+	// SeverityCritical means the system is at risk.
+	SeverityCritical
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityNone:
+		return "none"
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// ParseSeverity returns the severity with the given name.
+func ParseSeverity(name string) (Severity, error) {
+	switch name {
+	case "none":
+		return SeverityNone, nil
+	case "warning":
+		return SeverityWarning, nil
+	case "critical":
+		return SeverityCritical, nil
+	default:
+		return SeverityNone, fmt.Errorf("unknown severity %q", name)
+	}
+}
+
+// State is where an alert is in its lifecycle.
+type State int
+
+const (
+	// StateOK means the sensor is not breaching its rule.
+	StateOK State = iota
+
+	// StatePending means the sensor is breaching its rule, but has not done so for long enough to fire.
+	StatePending
+
+	// StateFiring means the alert is active and has been notified on.
+	StateFiring
+
+	// StateResolved means the alert has just cleared. It moves back to StateOK on the next evaluation.
+	StateResolved
+)
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case StateOK:
+		return "ok"
+	case StatePending:
+		return "pending"
+	case StateFiring:
+		return "firing"
+	case StateResolved:
+		return "resolved"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// Alert tracks a rule being breached by a reading.
+type Alert struct {
+	// Reading is the latest reading of the sensor.
+	Reading *sensors.Reading
+
+	// Rule is the rule that was breached.
+	Rule *config.Rule
+
+	// State is where the alert is in its lifecycle.
+	State State
+
+	// Severity is how serious the alert is.
+	Severity Severity
+
+	// PeakSeverity is the highest severity the alert has fired at since it last fired. It decides who is told of the
+	// alert resolving, as the alert may have become less severe before it resolved.
+	PeakSeverity Severity
+
+	// Value is the latest value of the reading.
+	Value float64
+
+	// Threshold is the value that was crossed. It is zero for alerts raised by a hardware alarm flag.
+	Threshold float64
+
+	// Reason describes why the alert was raised, e.g. "at or above max-5 (95.0°C)".
+	Reason string
+
+	// StartedAt is when the breach began.
+	StartedAt time.Time
+
+	// FiredAt is when the alert fired. It is zero until the alert fires.
+	FiredAt time.Time
+
+	// ResolvedAt is when the alert resolved. It is zero until the alert resolves.
+	ResolvedAt time.Time
+
+	// direction is how the breach was raised, deciding how the alert clears.
+	direction direction
+
+	// seenAt is when the sensor was last reported.
+	seenAt time.Time
+
+	// absent is set when the alert resolved because the sensor is no longer reported.
+	absent bool
+}
+
+// Key identifies the alert of a rule on a sensor, e.g. "cores:coretemp-isa-0000/Core 0".
+func (a *Alert) Key() string {
+	return a.Rule.Name + ":" + a.Reading.ID()
+}
+
+// Thresholds returns the value of every threshold of the rule for the reading, keyed by the rule field, e.g. "warn"
+// or "critical". Thresholds relative to a limit the sensor does not report are left out.
+func (a *Alert) Thresholds() map[string]float64 {
+	feature := limits(a.Reading)
+	thresholds := make(map[string]float64, 5)
+	for name, t := range map[string]*config.Threshold{
+		"warn":           a.Rule.Warn,
+		"critical":       a.Rule.Critical,
+		"warn_below":     a.Rule.WarnBelow,
+		"critical_below": a.Rule.CriticalBelow,
+		"clear":          a.Rule.Clear,
+	} {
+		if t == nil {
+			continue
+		}
+
+		if v, ok := t.Resolve(feature); ok {
+			thresholds[name] = v
+		}
+	}
+
+	return thresholds
+}
+
+// Message returns a human readable description of the alert naming the sensor that raised it.
+func (a *Alert) Message() string {
+	if a.State == StateResolved && a.absent {
+		return fmt.Sprintf("%s is no longer reported, resolving after %s: %s",
+			a.Reading.ID(), a.ResolvedAt.Sub(a.FiredAt).Round(time.Second), a.Reason)
+	}
+
+	if a.State == StateResolved {
+		return fmt.Sprintf("%s has recovered at %s after %s",
+			a.Reading.ID(), a.Reading.Kind.Format(a.Value), a.ResolvedAt.Sub(a.FiredAt).Round(time.Second))
+	}
+
+	if a.Rule.Name == MissingRule {
+		return fmt.Sprintf("%s is missing: %s", a.Reading.ID(), a.Reason)
+	}
+
+	return fmt.Sprintf("%s is at %s: %s", a.Reading.ID(), a.Reading.Kind.Format(a.Value), a.Reason)
+}
+
+// Event is a transition of an alert from one state to another. It holds a copy of the alert as it was at the
+// transition, so State is the state the alert moved to.
+type Event struct {
+	Alert
+
+	// From is the state the alert moved from. It is StateFiring when a firing alert becomes more severe.
+	From State
+
+	// Time is when the transition happened.
+	Time time.Time
+}
+
+// Notify reports whether the event should be sent to notifiers. Only firing, escalating and resolving alerts are.
+func (e *Event) Notify() bool {
+	return e.State == StateFiring || e.State == StateResolved
+}
@@ -0,0 +1,131 @@
+package alert
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+const (
+	// MonitorChip is the chip of the alerts the monitor raises about itself. The feature is the name of the source.
+	MonitorChip = "sensor-monitor"
+
+	// DegradedRule is the name of the rule of the alert raised when a source keeps failing to read.
+	DegradedRule = "monitor-degraded"
+)
+
+// Degraded raises a critical alert for every source that has failed to read a number of times in a row, so that a
+// monitor that can no longer see its sensors does not go quiet. The alert resolves once the source is read again.
+type Degraded struct {
+	// after is how many reads in a row must fail to raise the alert.
+	after int
+
+	// rule is the rule the alerts are raised under.
+	rule *config.Rule
+
+	// clock times the alert transitions.
+	clock Clock
+
+	// alerts holds the alerts that are not in StateOK, keyed by source.
+	alerts map[string]*Alert
+}
+
+// NewDegraded creates a tracker raising an alert for a source once after reads of it in a row have failed.
+func NewDegraded(after int, clock Clock) *Degraded {
+	return &Degraded{
+		after:  after,
+		rule:   &config.Rule{Name: DegradedRule},
+		clock:  clock,
+		alerts: make(map[string]*Alert),
+	}
+}
+
+// SetAfter changes how many reads in a row must fail to raise the alert, e.g. when the configuration is reloaded.
+func (d *Degraded) SetAfter(after int) {
+	d.after = after
+}
+
+// Alerts returns the alerts that are firing or have just resolved, ordered by source.
+func (d *Degraded) Alerts() []*Alert {
+	alerts := make([]*Alert, 0, len(d.alerts))
+	for _, source := range slices.Sorted(maps.Keys(d.alerts)) {
+		alerts = append(alerts, d.alerts[source])
+	}
+
+	return alerts
+}
+
+// Evaluate checks the health of every source and returns the transitions of their alerts. The alert of a source that
+// is no longer read, e.g. after the configuration is reloaded, resolves.
+func (d *Degraded) Evaluate(health []*sensors.Health) []*Event {
+	now := d.clock.Now()
+	events := make([]*Event, 0)
+	seen := make(map[string]bool, len(health))
+	for _, h := range health {
+		seen[h.Source] = true
+
+		a, ok := d.alerts[h.Source]
+		switch {
+		case h.ConsecutiveFailures >= d.after && (!ok || a.State == StateResolved):
+			a = &Alert{
+				Reading:   d.reading(h),
+				Rule:      d.rule,
+				Severity:  SeverityCritical,
+				Value:     float64(h.ConsecutiveFailures),
+				Threshold: float64(d.after),
+				Reason:    degradedReason(h),
+				StartedAt: now,
+				FiredAt:   now,
+			}
+			d.alerts[h.Source] = a
+			events = append(events, a.moveTo(StateFiring, now))
+		case ok && a.State == StateFiring && h.ConsecutiveFailures >= d.after:
+			a.Reading = d.reading(h)
+			a.Value = float64(h.ConsecutiveFailures)
+			a.Reason = degradedReason(h)
+		case ok:
+			events = append(events, d.resolve(a, now)...)
+		}
+	}
+
+	for source, a := range d.alerts {
+		if !seen[source] {
+			events = append(events, d.resolve(a, now)...)
+		}
+	}
+
+	return events
+}
+
+// resolve resolves a firing alert, or forgets one that resolved at the previous evaluation.
+func (d *Degraded) resolve(a *Alert, now time.Time) []*Event {
+	if a.State == StateResolved {
+		delete(d.alerts, a.Reading.Name)
+		return nil
+	}
+
+	a.Value = 0
+	a.ResolvedAt = now
+	return []*Event{a.moveTo(StateResolved, now)}
+}
+
+// reading returns the reading the alert of a source is raised on: the source as a feature of the monitor chip, valued
+// at its failures in a row.
+func (d *Degraded) reading(h *sensors.Health) *sensors.Reading {
+	return &sensors.Reading{
+		Chip: MonitorChip,
+		Feature: &sensors.Feature{
+			Name:   h.Source,
+			Values: map[sensors.Subfeature]float64{sensors.SubfeatureInput: float64(h.ConsecutiveFailures)},
+		},
+	}
+}
+
+// degradedReason describes why the source is degraded.
+func degradedReason(h *sensors.Health) string {
+	return fmt.Sprintf("%d failed reads in a row: %v", h.ConsecutiveFailures, h.Err)
+}
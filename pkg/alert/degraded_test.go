@@ -0,0 +1,59 @@
+package alert
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+func TestDegraded_Evaluate(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	d := NewDegraded(3, clock)
+	err := errors.New("exit status 1")
+
+	type result struct {
+		from, to State
+	}
+
+	step := func(health ...*sensors.Health) []result {
+		clock.Advance(time.Second)
+		got := make([]result, 0)
+		for _, event := range d.Evaluate(health) {
+			require.Equal(t, "monitor-degraded:sensor-monitor/lm-sensors", event.Key())
+			got = append(got, result{from: event.From, to: event.State})
+		}
+		return got
+	}
+
+	hwmon := &sensors.Health{Source: "hwmon"}
+	require.Empty(t, step(hwmon, &sensors.Health{Source: "lm-sensors", ConsecutiveFailures: 2, Err: err}))
+
+	// The alert fires once the source has failed enough times in a row, and does not fire again while it keeps failing.
+	require.Equal(t, []result{{StateOK, StateFiring}},
+		step(hwmon, &sensors.Health{Source: "lm-sensors", ConsecutiveFailures: 3, Err: err}))
+	require.Empty(t, step(hwmon, &sensors.Health{Source: "lm-sensors", ConsecutiveFailures: 4, Err: err}))
+
+	alerts := d.Alerts()
+	require.Len(t, alerts, 1)
+	require.Equal(t, SeverityCritical, alerts[0].Severity)
+	require.Equal(t, "sensor-monitor/lm-sensors is at 4: 4 failed reads in a row: exit status 1",
+		alerts[0].Message())
+
+	// It resolves once the source is read again, and is forgotten at the next evaluation.
+	require.Equal(t, []result{{StateFiring, StateResolved}}, step(hwmon, &sensors.Health{Source: "lm-sensors"}))
+	require.Empty(t, step(hwmon, &sensors.Health{Source: "lm-sensors"}))
+	require.Empty(t, d.Alerts())
+
+	// A source that is no longer read resolves too.
+	require.Equal(t, []result{{StateOK, StateFiring}},
+		step(&sensors.Health{Source: "lm-sensors", ConsecutiveFailures: 3, Err: err}))
+	require.Equal(t, []result{{StateFiring, StateResolved}}, step(hwmon))
+	require.Empty(t, step(hwmon))
+	require.Empty(t, d.Alerts())
+}
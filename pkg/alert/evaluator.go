@@ -0,0 +1,438 @@
+package alert
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// DefaultCrashTemperature is the temperature in Celsius at which a sensor without a hardware critical limit is
+// considered to be in a critical state.
+const DefaultCrashTemperature = 100.0
+
+// Clock tells the time. It is replaced in tests to step alerts through their states.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the wall clock.
+type SystemClock struct{}
+
+// Now returns the current time.
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Option configures an Evaluator.
+type Option func(*Evaluator)
+
+// WithClock sets the clock alert transitions are timed by.
+func WithClock(clock Clock) Option {
+	return func(e *Evaluator) {
+		e.clock = clock
+	}
+}
+
+// WithMissingFor sets how long a sensor with an alert may go unreported before its alert is resolved. Zero keeps the
+// alerts of sensors that are no longer reported as they are.
+func WithMissingFor(d time.Duration) Option {
+	return func(e *Evaluator) {
+		e.missingFor = d
+	}
+}
+
+// Evaluator checks snapshots against a set of rules. It tracks an alert for every sensor breaching its rule so that
+// each one moves through its states independently.
+type Evaluator struct {
+	// rules decide when a reading is alerted on. The first rule matching a reading is used.
+	rules []*config.Rule
+
+	// clock times the alert transitions.
+	clock Clock
+
+	// missingFor is how long a sensor with an alert may go unreported before its alert is resolved.
+	missingFor time.Duration
+
+	// alerts holds the alerts that are not in StateOK, keyed by Alert.Key.
+	alerts map[string]*Alert
+}
+
+// NewEvaluator creates a new evaluator for the given rules.
+func NewEvaluator(rules []*config.Rule, opts ...Option) *Evaluator {
+	e := &Evaluator{
+		rules:      rules,
+		clock:      SystemClock{},
+		missingFor: config.DefaultWatchdogMissingFor.Std(),
+		alerts:     make(map[string]*Alert),
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// SetRules replaces the rules, e.g. when the configuration is reloaded. Alerts of rules that are still configured keep
+// their state and move on under the new rule at the next evaluation; alerts of rules that are no longer configured are
+// dropped.
+func (e *Evaluator) SetRules(rules []*config.Rule) {
+	byName := make(map[string]*config.Rule, len(rules))
+	for _, rule := range rules {
+		byName[rule.Name] = rule
+	}
+
+	for key, a := range e.alerts {
+		rule, ok := byName[a.Rule.Name]
+		if !ok {
+			delete(e.alerts, key)
+			continue
+		}
+		a.Rule = rule
+	}
+
+	e.rules = rules
+}
+
+// SetMissingFor changes how long a sensor with an alert may go unreported before its alert is resolved, e.g. when the
+// configuration is reloaded.
+func (e *Evaluator) SetMissingFor(d time.Duration) {
+	e.missingFor = d
+}
+
+// RuleFor returns the first rule matching the reading, or nil if none match.
+func (e *Evaluator) RuleFor(reading *sensors.Reading) *config.Rule {
+	for _, rule := range e.rules {
+		if rule.Matches(reading) {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+// Alerts returns the alerts that are pending, firing or have just resolved, ordered by key.
+func (e *Evaluator) Alerts() []*Alert {
+	keys := slices.Sorted(maps.Keys(e.alerts))
+	alerts := make([]*Alert, 0, len(keys))
+	for _, key := range keys {
+		alerts = append(alerts, e.alerts[key])
+	}
+
+	return alerts
+}
+
+// Evaluate checks every reading in the snapshot and returns the transitions of the alerts it caused.
+//
+// An alert moves from ok to pending when a reading breaches its rule, and from pending to firing once the breach has
+// lasted for the rule's "for" duration. A firing alert fires again when it becomes more severe, and resolves once the
+// reading crosses back over the rule's clear threshold, or stops breaching the rule when there is none. A breach that
+// ends while pending returns to ok without firing.
+//
+// The alert of a sensor that is no longer reported, e.g. a drive that dropped off the bus, resolves once the sensor has
+// been gone for the missing duration, so that it does not keep reminding about hardware that is gone. A pending alert
+// returns to ok.
+func (e *Evaluator) Evaluate(snapshot *sensors.Snapshot) []*Event {
+	now := e.clock.Now()
+	events := make([]*Event, 0)
+	seen := make(map[string]bool, len(e.alerts))
+	for _, reading := range snapshot.Readings {
+		value, ok := reading.Input()
+		if !ok {
+			continue
+		}
+
+		rule := e.RuleFor(reading)
+		if rule == nil {
+			continue
+		}
+
+		if event := update(e.alerts, rule, reading, value, checkBreach(rule, reading, value), now); event != nil {
+			events = append(events, event)
+		}
+		seen[rule.Name+":"+reading.ID()] = true
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(e.alerts)) {
+		a := e.alerts[key]
+		if seen[key] || e.missingFor <= 0 || now.Sub(a.seenAt) < e.missingFor {
+			continue
+		}
+
+		if event := e.vanish(a, now); event != nil {
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
+
+// vanish moves the alert of a sensor that is no longer reported on: a firing alert resolves, and any other alert is
+// forgotten.
+func (e *Evaluator) vanish(a *Alert, now time.Time) *Event {
+	if a.State != StateFiring {
+		delete(e.alerts, a.Key())
+		if a.State == StatePending {
+			return a.moveTo(StateOK, now)
+		}
+		return nil
+	}
+
+	a.absent = true
+	a.Reason = "not reported since " + a.seenAt.Format(time.TimeOnly)
+	a.ResolvedAt = now
+	return a.moveTo(StateResolved, now)
+}
+
+// update moves the alert of the rule on the reading to its next state given the breach of the latest value, keeping
+// it in the alerts unless it is back to ok. It returns the event if the state changed.
+func update(
+	alerts map[string]*Alert,
+	rule *config.Rule,
+	reading *sensors.Reading,
+	value float64,
+	b *breach,
+	now time.Time,
+) *Event {
+	a := &Alert{Reading: reading, Rule: rule}
+	if existing, ok := alerts[a.Key()]; ok {
+		a = existing
+	}
+	a.Reading = reading
+	a.Rule = rule
+	a.Value = value
+	a.seenAt = now
+	a.absent = false
+
+	event := transition(a, b, now)
+	if a.State == StateOK {
+		delete(alerts, a.Key())
+	} else {
+		alerts[a.Key()] = a
+	}
+
+	return event
+}
+
+// transition moves the alert to its next state given the breach of the latest reading, returning the event if the
+// state changed.
+func transition(a *Alert, b *breach, now time.Time) *Event {
+	switch a.State {
+	case StateOK, StateResolved:
+		if b == nil {
+			a.State = StateOK
+			return nil
+		}
+
+		a.apply(b)
+		a.StartedAt = now
+		a.FiredAt = time.Time{}
+		a.ResolvedAt = time.Time{}
+		if a.Rule.For <= 0 {
+			a.FiredAt = now
+			return a.moveTo(StateFiring, now)
+		}
+
+		return a.moveTo(StatePending, now)
+	case StatePending:
+		if b == nil {
+			return a.moveTo(StateOK, now)
+		}
+
+		a.apply(b)
+		if now.Sub(a.StartedAt) < a.Rule.For.Std() {
+			return nil
+		}
+
+		a.FiredAt = now
+		return a.moveTo(StateFiring, now)
+	case StateFiring:
+		if b != nil {
+			escalated := b.severity > a.Severity
+			a.apply(b)
+			if escalated {
+				return a.moveTo(StateFiring, now)
+			}
+			return nil
+		}
+
+		if !cleared(a) {
+			return nil
+		}
+
+		a.ResolvedAt = now
+		return a.moveTo(StateResolved, now)
+	default:
+		return nil
+	}
+}
+
+// cleared reports whether a firing alert whose reading no longer breaches its rule has crossed back over the clear
+// threshold of the rule.
+func cleared(a *Alert) bool {
+	if a.Rule.Clear == nil || a.direction == directionAlarm {
+		return true
+	}
+
+	limit, ok := a.Rule.Clear.Resolve(limits(a.Reading))
+	if !ok {
+		return true
+	}
+
+	if a.direction == directionBelow {
+		return a.Value > limit
+	}
+
+	return a.Value < limit
+}
+
+// moveTo moves the alert to the given state and returns the event for the transition.
+func (a *Alert) moveTo(state State, now time.Time) *Event {
+	from := a.State
+	a.State = state
+	switch {
+	case state == StateFiring && from != StateFiring:
+		a.PeakSeverity = a.Severity
+	case state == StateFiring:
+		a.PeakSeverity = max(a.PeakSeverity, a.Severity)
+	}
+
+	return &Event{Alert: *a, From: from, Time: now}
+}
+
+// apply records the details of the breach on the alert.
+func (a *Alert) apply(b *breach) {
+	a.Severity = b.severity
+	a.Threshold = b.threshold
+	a.Reason = b.reason
+	a.direction = b.direction
+}
+
+// direction is how a breach was raised.
+type direction int
+
+const (
+	// directionAbove is a reading at or above an upper threshold.
+	directionAbove direction = iota
+
+	// directionBelow is a reading at or below a lower threshold.
+	directionBelow
+
+	// directionAlarm is a hardware alarm flag.
+	directionAlarm
+)
+
+// breach is a reading breaching a rule.
+type breach struct {
+	severity  Severity
+	threshold float64
+	reason    string
+	direction direction
+}
+
+// checkBreach checks the reading against the alarm flags and thresholds of the rule, returning the most severe breach
+// or nil if there is none.
+func checkBreach(rule *config.Rule, reading *sensors.Reading, value float64) *breach {
+	feature := limits(reading)
+	above := func(t *config.Threshold, name string) (float64, string, bool) {
+		if t == nil {
+			return 0, "", false
+		}
+
+		limit, ok := t.Resolve(feature)
+		if !ok || value < limit {
+			return 0, "", false
+		}
+
+		return limit, fmt.Sprintf("at or above %s threshold %s (%s)", name, t, reading.Kind.Format(limit)), true
+	}
+
+	below := func(t *config.Threshold, name string) (float64, string, bool) {
+		if t == nil {
+			return 0, "", false
+		}
+
+		limit, ok := t.Resolve(feature)
+		if !ok || value > limit {
+			return 0, "", false
+		}
+
+		return limit, fmt.Sprintf("at or below %s threshold %s (%s)", name, t, reading.Kind.Format(limit)), true
+	}
+
+	critAlarm, alarm := alarmFlags(reading.Feature)
+	if rule.Alarms && critAlarm != "" {
+		return &breach{severity: SeverityCritical, reason: critAlarm + " is set", direction: directionAlarm}
+	}
+
+	if limit, reason, ok := above(rule.Critical, "critical"); ok {
+		return &breach{severity: SeverityCritical, threshold: limit, reason: reason, direction: directionAbove}
+	}
+
+	if limit, reason, ok := below(rule.CriticalBelow, "critical_below"); ok {
+		return &breach{severity: SeverityCritical, threshold: limit, reason: reason, direction: directionBelow}
+	}
+
+	if rule.Alarms && alarm != "" {
+		return &breach{severity: SeverityWarning, reason: alarm + " is set", direction: directionAlarm}
+	}
+
+	if limit, reason, ok := above(rule.Warn, "warn"); ok {
+		return &breach{severity: SeverityWarning, threshold: limit, reason: reason, direction: directionAbove}
+	}
+
+	if limit, reason, ok := below(rule.WarnBelow, "warn_below"); ok {
+		return &breach{severity: SeverityWarning, threshold: limit, reason: reason, direction: directionBelow}
+	}
+
+	return nil
+}
+
+// limits returns the feature the thresholds of a reading are resolved against. The critical limit reported by the
+// hardware or the kernel trip points is used when available, and temperatures without one are given
+// DefaultCrashTemperature.
+func limits(reading *sensors.Reading) *sensors.Feature {
+	if reading.Kind != sensors.KindTemperature {
+		return reading.Feature
+	}
+
+	if crit, ok := reading.Value(sensors.SubfeatureCrit); ok && crit > 0 {
+		return reading.Feature
+	}
+
+	values := maps.Clone(reading.Values)
+	if values == nil {
+		values = make(map[sensors.Subfeature]float64, 1)
+	}
+	values[sensors.SubfeatureCrit] = DefaultCrashTemperature
+
+	return &sensors.Feature{Name: reading.Name, Kind: reading.Kind, Values: values}
+}
+
+// alarmFlags returns the name of a set critical alarm flag and of any other set alarm flag of the feature.
+func alarmFlags(f *sensors.Feature) (critAlarm, alarm string) {
+	for sf, v := range f.Values {
+		name := string(sf)
+		if v == 0 || !strings.HasSuffix(name, string(sensors.SubfeatureAlarm)) {
+			continue
+		}
+
+		switch name {
+		case string(sensors.SubfeatureCritAlarm), "emergency_alarm":
+			if critAlarm == "" || name < critAlarm {
+				critAlarm = name
+			}
+		default:
+			if alarm == "" || name < alarm {
+				alarm = name
+			}
+		}
+	}
+
+	return critAlarm, alarm
+}
@@ -0,0 +1,368 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// fakeClock is a clock that only moves when told to.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestLimits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		kind   sensors.Kind
+		values map[sensors.Subfeature]float64
+		want   float64
+		wantOK bool
+	}{
+		{
+			name:   "temperature without a critical limit",
+			kind:   sensors.KindTemperature,
+			values: map[sensors.Subfeature]float64{sensors.SubfeatureInput: 60, sensors.SubfeatureMax: 98},
+			want:   DefaultCrashTemperature,
+			wantOK: true,
+		},
+		{
+			name:   "hardware critical limit",
+			kind:   sensors.KindTemperature,
+			values: map[sensors.Subfeature]float64{sensors.SubfeatureInput: 38, sensors.SubfeatureCrit: 87.85},
+			want:   87.85,
+			wantOK: true,
+		},
+		{
+			name:   "kernel critical trip point",
+			kind:   sensors.KindTemperature,
+			values: map[sensors.Subfeature]float64{sensors.SubfeatureInput: 45, sensors.SubfeaturePassive: 95, sensors.SubfeatureCrit: 105},
+			want:   105,
+			wantOK: true,
+		},
+		{
+			name:   "fan without a critical limit",
+			kind:   sensors.KindFan,
+			values: map[sensors.Subfeature]float64{sensors.SubfeatureInput: 2371, sensors.SubfeatureMax: 5000},
+			wantOK: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			reading := &sensors.Reading{
+				Feature: &sensors.Feature{Kind: test.kind, Values: test.values},
+			}
+			got, ok := limits(reading).Value(sensors.SubfeatureCrit)
+			require.Equal(t, test.wantOK, ok)
+			require.InDelta(t, test.want, got, 0.001)
+
+			// The reading itself is never modified.
+			_, ok = reading.Value(sensors.SubfeatureCrit)
+			require.Equal(t, test.wantOK && test.want != DefaultCrashTemperature, ok)
+		})
+	}
+}
+
+func TestEvaluator_Evaluate_States(t *testing.T) {
+	t.Parallel()
+
+	threshold := func(s string) *config.Threshold {
+		parsed, err := config.ParseThreshold(s)
+		require.NoError(t, err)
+		return parsed
+	}
+
+	clock := &fakeClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	m := NewEvaluator([]*config.Rule{
+		{
+			Name:     "cpu",
+			Match:    "coretemp-*",
+			Warn:     threshold("90"),
+			Critical: threshold("crit"),
+			Clear:    threshold("85"),
+			For:      config.Duration(30 * time.Second),
+		},
+	}, WithClock(clock))
+
+	snapshot := func(value float64) *sensors.Snapshot {
+		return sensors.NewSnapshot(clock.Now(), []*sensors.Chip{
+			{
+				Name: "coretemp-isa-0000",
+				Features: []*sensors.Feature{
+					{Name: "Package id 0", Kind: sensors.KindTemperature, Values: map[sensors.Subfeature]float64{
+						sensors.SubfeatureInput: value,
+						sensors.SubfeatureCrit:  100,
+					}},
+				},
+			},
+		})
+	}
+
+	type result struct {
+		from, to State
+		severity Severity
+	}
+
+	step := func(d time.Duration, value float64) []result {
+		clock.Advance(d)
+		got := make([]result, 0)
+		for _, event := range m.Evaluate(snapshot(value)) {
+			require.Equal(t, "cpu:coretemp-isa-0000/Package id 0", event.Key())
+			require.Equal(t, clock.Now(), event.Time)
+			got = append(got, result{from: event.From, to: event.State, severity: event.Severity})
+		}
+		return got
+	}
+
+	require.Empty(t, step(0, 60))
+	require.Empty(t, m.Alerts())
+
+	// A short spike returns to ok without firing.
+	require.Equal(t, []result{{StateOK, StatePending, SeverityWarning}}, step(time.Second, 91))
+	require.Empty(t, step(10*time.Second, 92))
+	require.Equal(t, []result{{StatePending, StateOK, SeverityWarning}}, step(10*time.Second, 80))
+	require.Empty(t, m.Alerts())
+
+	// A sustained breach fires once it has lasted for the whole duration.
+	require.Equal(t, []result{{StateOK, StatePending, SeverityWarning}}, step(time.Second, 91))
+	require.Empty(t, step(29*time.Second, 91))
+	require.Equal(t, []result{{StatePending, StateFiring, SeverityWarning}}, step(time.Second, 91))
+	require.Len(t, m.Alerts(), 1)
+	require.Equal(t, StateFiring, m.Alerts()[0].State)
+
+	// A firing alert fires again only when it becomes more severe.
+	require.Empty(t, step(time.Second, 93))
+	require.Equal(t, []result{{StateFiring, StateFiring, SeverityCritical}}, step(time.Second, 100))
+	require.Empty(t, step(time.Second, 95))
+
+	// Dropping below the warn threshold is not enough to resolve the alert until it crosses the clear threshold.
+	require.Empty(t, step(time.Second, 88))
+	require.Empty(t, step(time.Second, 85))
+	events := m.Evaluate(snapshot(84))
+	require.Len(t, events, 1)
+	require.Equal(t, StateResolved, events[0].State)
+	require.Equal(t, "coretemp-isa-0000/Package id 0 has recovered at 84.0°C after 5s", events[0].Message())
+
+	// A resolved alert returns to ok on the next evaluation.
+	require.Empty(t, step(time.Second, 84))
+	require.Empty(t, m.Alerts())
+}
+
+func TestEvaluator_SetRules(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	snapshot := sensors.NewSnapshot(clock.Now(), []*sensors.Chip{
+		{
+			Name: "coretemp-isa-0000",
+			Features: []*sensors.Feature{
+				{Name: "Core 0", Kind: sensors.KindTemperature, Values: map[sensors.Subfeature]float64{
+					sensors.SubfeatureInput: 95,
+				}},
+			},
+		},
+		{
+			Name: "nvme-pci-0100",
+			Features: []*sensors.Feature{
+				{Name: "Composite", Kind: sensors.KindTemperature, Values: map[sensors.Subfeature]float64{
+					sensors.SubfeatureInput: 75,
+				}},
+			},
+		},
+	})
+
+	m := NewEvaluator([]*config.Rule{
+		{Name: "cpu", Match: "coretemp-*", Warn: &config.Threshold{Offset: 90}},
+		{Name: "nvme", Match: "nvme-*", Warn: &config.Threshold{Offset: 70}},
+	}, WithClock(clock))
+	require.Len(t, m.Evaluate(snapshot), 2)
+	firedAt := clock.Now()
+
+	// The alert of a rule that is kept stays firing under the new threshold without firing again; the alert of a
+	// removed rule is dropped.
+	clock.Advance(time.Minute)
+	m.SetRules([]*config.Rule{
+		{Name: "cpu", Match: "coretemp-*", Warn: &config.Threshold{Offset: 92}},
+	})
+	require.Empty(t, m.Evaluate(snapshot))
+
+	alerts := m.Alerts()
+	require.Len(t, alerts, 1)
+	require.Equal(t, "cpu:coretemp-isa-0000/Core 0", alerts[0].Key())
+	require.Equal(t, StateFiring, alerts[0].State)
+	require.Equal(t, firedAt, alerts[0].FiredAt)
+	require.InDelta(t, 92, alerts[0].Threshold, 0.001)
+}
+
+func TestEvaluator_Evaluate_Absent(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	hot := sensors.NewSnapshot(clock.Now(), []*sensors.Chip{{
+		Name: "nvme-pci-0100",
+		Features: []*sensors.Feature{
+			{Name: "Composite", Kind: sensors.KindTemperature, Values: map[sensors.Subfeature]float64{
+				sensors.SubfeatureInput: 75,
+			}},
+		},
+	}})
+	gone := sensors.NewSnapshot(clock.Now(), nil)
+
+	m := NewEvaluator([]*config.Rule{
+		{Name: "nvme", Match: "nvme-*", Warn: &config.Threshold{Offset: 70}},
+	}, WithClock(clock), WithMissingFor(time.Minute))
+	require.Len(t, m.Evaluate(hot), 1)
+
+	// The alert stays firing while the sensor has only briefly gone unreported.
+	clock.Advance(30 * time.Second)
+	require.Empty(t, m.Evaluate(gone))
+	require.Equal(t, StateFiring, m.Alerts()[0].State)
+
+	// Once it has been gone for the missing duration, the alert resolves and is then forgotten.
+	clock.Advance(30 * time.Second)
+	events := m.Evaluate(gone)
+	require.Len(t, events, 1)
+	require.Equal(t, StateFiring, events[0].From)
+	require.Equal(t, StateResolved, events[0].State)
+	require.Equal(t, "nvme-pci-0100/Composite is no longer reported, resolving after 1m0s: "+
+		"not reported since 12:00:00", events[0].Message())
+
+	clock.Advance(30 * time.Second)
+	require.Empty(t, m.Evaluate(gone))
+	require.Empty(t, m.Alerts())
+}
+
+func TestEvaluator_Evaluate_Thresholds(t *testing.T) {
+	t.Parallel()
+
+	threshold := func(s string) *config.Threshold {
+		parsed, err := config.ParseThreshold(s)
+		require.NoError(t, err)
+		return parsed
+	}
+
+	m := NewEvaluator([]*config.Rule{
+		{Name: "cores", Match: "coretemp-*/Core *", Warn: threshold("max-5"), Critical: threshold("crit"), Alarms: true},
+		{Name: "nvme", Match: "nvme-*", Critical: threshold("80"), Alarms: true},
+		{Name: "fans", Match: "dell_smm-*", Kinds: []sensors.Kind{sensors.KindFan}, WarnBelow: threshold("min+500")},
+	})
+
+	snapshot := func(core, nvme, fan, nvmeAlarm float64) *sensors.Snapshot {
+		return sensors.NewSnapshot(time.Now(), []*sensors.Chip{
+			{
+				Name: "coretemp-isa-0000",
+				Features: []*sensors.Feature{
+					{Name: "Package id 0", Kind: sensors.KindTemperature, Values: map[sensors.Subfeature]float64{
+						sensors.SubfeatureInput: 120,
+					}},
+					{Name: "Core 0", Kind: sensors.KindTemperature, Values: map[sensors.Subfeature]float64{
+						sensors.SubfeatureInput:     core,
+						sensors.SubfeatureMax:       90,
+						sensors.SubfeatureCrit:      100,
+						sensors.SubfeatureCritAlarm: 0,
+					}},
+				},
+			},
+			{
+				Name: "nvme-pci-e100",
+				Features: []*sensors.Feature{
+					{Name: "Composite", Kind: sensors.KindTemperature, Values: map[sensors.Subfeature]float64{
+						sensors.SubfeatureInput: nvme,
+						sensors.SubfeatureAlarm: nvmeAlarm,
+					}},
+				},
+			},
+			{
+				Name: "dell_smm-virtual-0",
+				Features: []*sensors.Feature{
+					{Name: "fan1", Kind: sensors.KindFan, Values: map[sensors.Subfeature]float64{
+						sensors.SubfeatureInput: fan,
+						sensors.SubfeatureMin:   1000,
+					}},
+					{Name: "temp1", Kind: sensors.KindTemperature, Values: map[sensors.Subfeature]float64{
+						sensors.SubfeatureInput: 200,
+					}},
+				},
+			},
+		})
+	}
+
+	type result struct {
+		id       string
+		severity Severity
+		reason   string
+	}
+
+	results := func(events []*Event) []result {
+		got := make([]result, 0, len(events))
+		for _, e := range events {
+			if e.Notify() {
+				got = append(got, result{id: e.Reading.ID(), severity: e.Severity, reason: e.Reason})
+			}
+		}
+		return got
+	}
+
+	// Sensors that are not matched by a rule, or filtered out by kind, are never alerted on.
+	require.Empty(t, m.Evaluate(snapshot(60, 40, 2400, 0)))
+	require.Empty(t, m.Alerts())
+
+	require.Equal(t, []result{
+		{id: "coretemp-isa-0000/Core 0", severity: SeverityWarning, reason: "at or above warn threshold max-5 (85.0°C)"},
+		{id: "nvme-pci-e100/Composite", severity: SeverityWarning, reason: "alarm is set"},
+		{id: "dell_smm-virtual-0/fan1", severity: SeverityWarning, reason: "at or below warn_below threshold min+500 (1500 RPM)"},
+	}, results(m.Evaluate(snapshot(86, 40, 1200, 1))))
+
+	// A breach is only alerted on again when it becomes more severe.
+	require.Equal(t, []result{
+		{id: "coretemp-isa-0000/Core 0", severity: SeverityCritical, reason: "at or above critical threshold crit (100.0°C)"},
+		{id: "nvme-pci-e100/Composite", severity: SeverityCritical, reason: "at or above critical threshold 80 (80.0°C)"},
+	}, results(m.Evaluate(snapshot(100, 81, 1100, 1))))
+
+	require.Empty(t, m.Evaluate(snapshot(101, 82, 1000, 1)))
+
+	// Once a sensor recovers its alert resolves, and a new breach is alerted on again.
+	require.Len(t, results(m.Evaluate(snapshot(60, 40, 2400, 0))), 3)
+	require.Equal(t, []result{
+		{id: "coretemp-isa-0000/Core 0", severity: SeverityWarning, reason: "at or above warn threshold max-5 (85.0°C)"},
+	}, results(m.Evaluate(snapshot(88, 40, 2400, 0))))
+}
+
+func TestEvaluator_Evaluate_CritAlarm(t *testing.T) {
+	t.Parallel()
+
+	m := NewEvaluator(config.Default().Rules)
+	events := m.Evaluate(sensors.NewSnapshot(time.Now(), []*sensors.Chip{
+		{
+			Name: "coretemp-isa-0000",
+			Features: []*sensors.Feature{
+				{Name: "Core 3", Kind: sensors.KindTemperature, Values: map[sensors.Subfeature]float64{
+					sensors.SubfeatureInput:     72,
+					sensors.SubfeatureCrit:      100,
+					sensors.SubfeatureCritAlarm: 1,
+				}},
+			},
+		},
+	}))
+
+	require.Len(t, events, 1)
+	require.Equal(t, StateFiring, events[0].State)
+	require.Equal(t, SeverityCritical, events[0].Severity)
+	require.Equal(t, "coretemp-isa-0000/Core 3 is at 72.0°C: crit_alarm is set", events[0].Message())
+}
@@ -0,0 +1,289 @@
+package alert
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+const (
+	// FanSlowSuffix is added to the name of a fan rule to name the alert raised when a fan is below its minimum speed
+	// while its temperature is rising.
+	FanSlowSuffix = "-slow"
+
+	// FanStoppedSuffix is added to the name of a fan rule to name the alert raised when a fan has stopped while its
+	// temperature is high.
+	FanStoppedSuffix = "-stopped"
+
+	// FanPinnedSuffix is added to the name of a fan rule to name the alert raised when a fan has run at its maximum
+	// speed for a long time.
+	FanPinnedSuffix = "-pinned"
+)
+
+// Fans raises alerts on fans that are stalling or failing, judged against the temperature sensor each fan cools: a
+// fan below its minimum speed while the temperature is rising, a stopped fan while the temperature is high, and a fan
+// pinned at its maximum speed for a long time.
+type Fans struct {
+	// rules are the fan rules. The first rule matching a fan is used.
+	rules []*fanRule
+
+	// clock times the alert transitions.
+	clock Clock
+
+	// temperatures holds the values of every temperature sensor within the longest rise window, keyed by its ID.
+	temperatures map[string][]sample
+
+	// alerts holds the alerts that are not in StateOK, keyed by Alert.Key.
+	alerts map[string]*Alert
+}
+
+// fanRule is a fan rule with the rules its alerts are raised under.
+type fanRule struct {
+	*config.Fan
+
+	slow    *config.Rule
+	stopped *config.Rule
+	pinned  *config.Rule
+}
+
+// sample is a value of a sensor and when it was read.
+type sample struct {
+	at    time.Time
+	value float64
+}
+
+// NewFans creates a tracker for the given fan rules.
+func NewFans(rules []*config.Fan, clock Clock) *Fans {
+	f := &Fans{
+		clock:        clock,
+		temperatures: make(map[string][]sample),
+		alerts:       make(map[string]*Alert),
+	}
+	f.SetRules(rules)
+
+	return f
+}
+
+// SetRules replaces the fan rules, e.g. when the configuration is reloaded. Alerts of rules that are still configured
+// keep their state; alerts of rules that are no longer configured are dropped.
+func (f *Fans) SetRules(rules []*config.Fan) {
+	f.rules = make([]*fanRule, 0, len(rules))
+	byName := make(map[string]*config.Rule, 3*len(rules))
+	for _, rule := range rules {
+		r := &fanRule{
+			Fan:     rule,
+			slow:    &config.Rule{Name: rule.Name + FanSlowSuffix, For: rule.For},
+			stopped: &config.Rule{Name: rule.Name + FanStoppedSuffix, For: rule.For},
+			pinned:  &config.Rule{Name: rule.Name + FanPinnedSuffix, For: rule.PinnedFor},
+		}
+		f.rules = append(f.rules, r)
+		for _, named := range []*config.Rule{r.slow, r.stopped, r.pinned} {
+			byName[named.Name] = named
+		}
+	}
+
+	for key, a := range f.alerts {
+		rule, ok := byName[a.Rule.Name]
+		if !ok {
+			delete(f.alerts, key)
+			continue
+		}
+		a.Rule = rule
+	}
+}
+
+// Alerts returns the alerts that are pending, firing or have just resolved, ordered by key.
+func (f *Fans) Alerts() []*Alert {
+	alerts := make([]*Alert, 0, len(f.alerts))
+	for _, key := range slices.Sorted(maps.Keys(f.alerts)) {
+		alerts = append(alerts, f.alerts[key])
+	}
+
+	return alerts
+}
+
+// Evaluate checks every fan in the snapshot against the hottest of its paired temperature sensors and returns the
+// transitions of their alerts. A stopped fan is only alerted on as stopped, not also as slow.
+func (f *Fans) Evaluate(snapshot *sensors.Snapshot) []*Event {
+	now := f.clock.Now()
+	f.record(snapshot, now)
+
+	events := make([]*Event, 0)
+	add := func(event *Event) {
+		if event != nil {
+			events = append(events, event)
+		}
+	}
+
+	for _, reading := range snapshot.Readings {
+		value, ok := reading.Input()
+		if !ok {
+			continue
+		}
+
+		i := slices.IndexFunc(f.rules, func(r *fanRule) bool {
+			return r.Matches(reading)
+		})
+		if i < 0 {
+			continue
+		}
+
+		rule := f.rules[i]
+		paired, temperature := f.paired(rule, reading, snapshot)
+
+		stopped := rule.checkStopped(value, paired, temperature)
+		var slow *breach
+		if stopped == nil {
+			slow = f.checkSlow(rule, reading, value, paired, temperature, now)
+		}
+
+		add(update(f.alerts, rule.slow, reading, value, slow, now))
+		add(update(f.alerts, rule.stopped, reading, value, stopped, now))
+		add(update(f.alerts, rule.pinned, reading, value, rule.checkPinned(reading, value), now))
+	}
+
+	return events
+}
+
+// record keeps the values of the temperature sensors in the snapshot for as long as the longest rise window.
+func (f *Fans) record(snapshot *sensors.Snapshot, now time.Time) {
+	var window time.Duration
+	for _, rule := range f.rules {
+		window = max(window, rule.RiseWindow.Std())
+	}
+
+	seen := make(map[string]bool, len(f.temperatures))
+	for _, reading := range snapshot.Readings {
+		value, ok := reading.Input()
+		if !ok || reading.Kind != sensors.KindTemperature {
+			continue
+		}
+
+		samples := append(f.temperatures[reading.ID()], sample{at: now, value: value})
+		i := 0
+		for i < len(samples) && now.Sub(samples[i].at) > window {
+			i++
+		}
+		f.temperatures[reading.ID()] = samples[i:]
+		seen[reading.ID()] = true
+	}
+
+	for id := range f.temperatures {
+		if !seen[id] {
+			delete(f.temperatures, id)
+		}
+	}
+}
+
+// paired returns the hottest temperature sensor paired with the fan and its value, or nil if there is none.
+func (f *Fans) paired(
+	rule *fanRule,
+	fan *sensors.Reading,
+	snapshot *sensors.Snapshot,
+) (*sensors.Reading, float64) {
+	var (
+		paired  *sensors.Reading
+		hottest float64
+	)
+	for _, reading := range snapshot.Readings {
+		value, ok := reading.Input()
+		if !ok || !rule.Pairs(fan, reading) {
+			continue
+		}
+
+		if paired == nil || value > hottest {
+			paired, hottest = reading, value
+		}
+	}
+
+	return paired, hottest
+}
+
+// checkSlow returns a breach if the fan is below its minimum speed while the paired temperature has risen by the rise
+// of the rule within its window. A firing alert stays firing until the fan is back up to speed, even if the
+// temperature has stopped rising meanwhile.
+func (f *Fans) checkSlow(
+	rule *fanRule,
+	fan *sensors.Reading,
+	value float64,
+	paired *sensors.Reading,
+	temperature float64,
+	now time.Time,
+) *breach {
+	if rule.Min == nil || paired == nil {
+		return nil
+	}
+
+	limit, ok := rule.Min.Resolve(fan.Feature)
+	if !ok || value >= limit {
+		return nil
+	}
+
+	lowest := temperature
+	for _, s := range f.temperatures[paired.ID()] {
+		if now.Sub(s.at) <= rule.RiseWindow.Std() {
+			lowest = min(lowest, s.value)
+		}
+	}
+
+	b := &breach{severity: SeverityWarning, threshold: limit, direction: directionBelow}
+	if rise := temperature - lowest; rise > 0 && rise >= rule.Rise {
+		b.reason = fmt.Sprintf("below min threshold %s (%s) while %s rose %s to %s within %s",
+			rule.Min, fan.Kind.Format(limit), paired.ID(), paired.Kind.Format(rise), paired.Kind.Format(temperature),
+			rule.RiseWindow.Std())
+		return b
+	}
+
+	if a, ok := f.alerts[rule.slow.Name+":"+fan.ID()]; ok && a.State == StateFiring {
+		b.reason = a.Reason
+		return b
+	}
+
+	return nil
+}
+
+// checkStopped returns a breach if the fan has stopped while the paired temperature is at or above the stopped level
+// of the rule.
+func (r *fanRule) checkStopped(value float64, paired *sensors.Reading, temperature float64) *breach {
+	if r.StoppedAbove == nil || paired == nil || value != 0 {
+		return nil
+	}
+
+	level, ok := r.StoppedAbove.Resolve(paired.Feature)
+	if !ok || temperature < level {
+		return nil
+	}
+
+	return &breach{
+		severity:  SeverityCritical,
+		threshold: level,
+		reason: fmt.Sprintf("stopped while %s is at %s, at or above %s (%s)",
+			paired.ID(), paired.Kind.Format(temperature), r.StoppedAbove, paired.Kind.Format(level)),
+		direction: directionAlarm,
+	}
+}
+
+// checkPinned returns a breach if the fan is at or above its pinned speed. The alert fires once it has lasted for the
+// pinned duration of the rule.
+func (r *fanRule) checkPinned(fan *sensors.Reading, value float64) *breach {
+	if r.Pinned == nil || r.PinnedFor <= 0 {
+		return nil
+	}
+
+	limit, ok := r.Pinned.Resolve(fan.Feature)
+	if !ok || value < limit {
+		return nil
+	}
+
+	return &breach{
+		severity:  SeverityWarning,
+		threshold: limit,
+		reason: fmt.Sprintf("pinned at or above %s (%s) for %s",
+			r.Pinned, fan.Kind.Format(limit), r.PinnedFor.Std()),
+		direction: directionAbove,
+	}
+}
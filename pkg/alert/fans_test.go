@@ -0,0 +1,197 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// fanSnapshot returns a snapshot of a Dell SMM chip with a fan and a temperature, and a CPU package temperature.
+func fanSnapshot(rpm, smm, cpu float64) *sensors.Snapshot {
+	return sensors.NewSnapshot(time.Time{}, []*sensors.Chip{
+		{
+			Name: "dell_smm-virtual-0",
+			Features: []*sensors.Feature{
+				{
+					Name: "fan1",
+					Kind: sensors.KindFan,
+					Values: map[sensors.Subfeature]float64{
+						sensors.SubfeatureInput: rpm,
+						sensors.SubfeatureMin:   1000,
+						sensors.SubfeatureMax:   5000,
+					},
+				},
+				{
+					Name:   "temp1",
+					Kind:   sensors.KindTemperature,
+					Values: map[sensors.Subfeature]float64{sensors.SubfeatureInput: smm},
+				},
+			},
+		},
+		{
+			Name: "coretemp-isa-0000",
+			Features: []*sensors.Feature{{
+				Name: "Package id 0",
+				Kind: sensors.KindTemperature,
+				Values: map[sensors.Subfeature]float64{
+					sensors.SubfeatureInput: cpu,
+					sensors.SubfeatureCrit:  100,
+				},
+			}},
+		},
+	})
+}
+
+type fanResult struct {
+	key      string
+	from, to State
+}
+
+func TestFans_Stopped(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		temperature string
+		smm, cpu    float64
+		want        []fanResult
+		reason      string
+	}{
+		{
+			name: "hot chip",
+			smm:  65,
+			cpu:  40,
+			want: []fanResult{
+				{"fan-stopped:dell_smm-virtual-0/fan1", StateOK, StatePending},
+				{"fan-stopped:dell_smm-virtual-0/fan1", StatePending, StateFiring},
+			},
+			reason: "stopped while dell_smm-virtual-0/temp1 is at 65.0°C, at or above 60 (60.0°C)",
+		},
+		{
+			name: "cool chip",
+			smm:  40,
+			cpu:  90,
+			want: []fanResult{},
+		},
+		{
+			name:        "hot paired sensor",
+			temperature: "coretemp-*/Package id 0",
+			smm:         40,
+			cpu:         90,
+			want: []fanResult{
+				{"fan-stopped:dell_smm-virtual-0/fan1", StateOK, StatePending},
+				{"fan-stopped:dell_smm-virtual-0/fan1", StatePending, StateFiring},
+			},
+			reason: "stopped while coretemp-isa-0000/Package id 0 is at 90.0°C, at or above 60 (60.0°C)",
+		},
+		{
+			name:        "hottest paired sensor",
+			temperature: "*",
+			smm:         62,
+			cpu:         70,
+			want: []fanResult{
+				{"fan-stopped:dell_smm-virtual-0/fan1", StateOK, StatePending},
+				{"fan-stopped:dell_smm-virtual-0/fan1", StatePending, StateFiring},
+			},
+			reason: "stopped while coretemp-isa-0000/Package id 0 is at 70.0°C, at or above 60 (60.0°C)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := &fakeClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+			rule := config.DefaultFan()
+			rule.Temperature = test.temperature
+			f := NewFans([]*config.Fan{rule}, clock)
+
+			got := make([]fanResult, 0)
+			for range 2 {
+				for _, event := range f.Evaluate(fanSnapshot(0, test.smm, test.cpu)) {
+					got = append(got, fanResult{event.Key(), event.From, event.State})
+				}
+				clock.Advance(config.DefaultFanFor.Std())
+			}
+			require.Equal(t, test.want, got)
+
+			if len(test.want) > 0 {
+				alerts := f.Alerts()
+				require.Len(t, alerts, 1)
+				require.Equal(t, SeverityCritical, alerts[0].Severity)
+				require.Equal(t, test.reason, alerts[0].Reason)
+			}
+		})
+	}
+}
+
+func TestFans_Slow(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	rule := config.DefaultFan()
+	rule.Temperature = "coretemp-*/Package id 0"
+	f := NewFans([]*config.Fan{rule}, clock)
+
+	const key = "fan-slow:dell_smm-virtual-0/fan1"
+	step := func(rpm, cpu float64) []fanResult {
+		clock.Advance(10 * time.Second)
+		got := make([]fanResult, 0)
+		for _, event := range f.Evaluate(fanSnapshot(rpm, 40, cpu)) {
+			got = append(got, fanResult{event.Key(), event.From, event.State})
+		}
+		return got
+	}
+
+	// A slow fan is not alerted on while the temperature it cools is steady.
+	require.Empty(t, step(500, 50))
+	require.Empty(t, step(500, 50.5))
+
+	// Once the temperature rises by 2°C within a minute, it is.
+	require.Equal(t, []fanResult{{key, StateOK, StatePending}}, step(500, 52))
+	require.Equal(t, []fanResult{{key, StatePending, StateFiring}}, step(500, 53))
+	require.Equal(t, "below min threshold min (1000 RPM) while coretemp-isa-0000/Package id 0 rose 3.0°C to 53.0°C "+
+		"within 1m0s", f.Alerts()[0].Reason)
+
+	// It keeps firing while the fan is slow, even once the temperature is steady, and resolves when it is up to speed.
+	for range 10 {
+		require.Empty(t, step(500, 53))
+	}
+	require.Equal(t, []fanResult{{key, StateFiring, StateResolved}}, step(1200, 53))
+	require.Empty(t, step(1200, 53))
+	require.Empty(t, f.Alerts())
+}
+
+func TestFans_Pinned(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	f := NewFans([]*config.Fan{config.DefaultFan()}, clock)
+
+	const key = "fan-pinned:dell_smm-virtual-0/fan1"
+	step := func(rpm float64) []fanResult {
+		clock.Advance(5 * time.Minute)
+		got := make([]fanResult, 0)
+		for _, event := range f.Evaluate(fanSnapshot(rpm, 40, 50)) {
+			got = append(got, fanResult{event.Key(), event.From, event.State})
+		}
+		return got
+	}
+
+	require.Equal(t, []fanResult{{key, StateOK, StatePending}}, step(4800))
+	require.Empty(t, step(4900))
+	require.Equal(t, []fanResult{{key, StatePending, StateFiring}}, step(4800))
+	require.Equal(t, "pinned at or above max*0.95 (4750 RPM) for 10m0s", f.Alerts()[0].Reason)
+
+	require.Equal(t, []fanResult{{key, StateFiring, StateResolved}}, step(3000))
+
+	// Alerts of fan rules that are no longer configured are dropped.
+	require.Equal(t, []fanResult{{key, StateResolved, StatePending}}, step(4800))
+	f.SetRules(nil)
+	require.Empty(t, f.Alerts())
+	require.Empty(t, step(4800))
+}
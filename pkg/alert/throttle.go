@@ -0,0 +1,182 @@
+package alert
+
+import (
+	"fmt"
+	"time"
+)
+
+// Notification is an alert that is due to be sent to a notifier.
+type Notification struct {
+	*Event
+
+	// Reminder is true when the notification repeats an alert that is still firing.
+	Reminder bool
+
+	// Suppressed is how many notifications were suppressed since the last one sent to the notifier.
+	Suppressed int
+}
+
+// Message returns the message of the alert, noting whether it is a reminder and how many notifications were
+// suppressed before it.
+func (n *Notification) Message() string {
+	msg := n.Event.Message()
+	if n.Reminder {
+		msg += fmt.Sprintf(" (firing for %s)", n.Time.Sub(n.FiredAt).Round(time.Second))
+	}
+
+	switch n.Suppressed {
+	case 0:
+	case 1:
+		msg += " (1 similar alert suppressed)"
+	default:
+		msg += fmt.Sprintf(" (%d similar alerts suppressed)", n.Suppressed)
+	}
+
+	return msg
+}
+
+// DedupKey identifies notifications that are the same, e.g. "cores:coretemp-isa-0000/Core 0/firing/critical".
+func (n *Notification) DedupKey() string {
+	return fmt.Sprintf("%s/%s/%s", n.Key(), n.State, n.Severity)
+}
+
+// Throttle decides which notifications are sent to a notifier. It suppresses notifications repeated within the
+// cooldown of their rule and notifications over the rate limit, and sends reminders for alerts that stay firing.
+// Resolves are always sent and do not count towards the rate limit, so that an alert is never left open at the
+// notifier.
+type Throttle struct {
+	// clock times the notifications.
+	clock Clock
+
+	// limit is the number of notifications allowed in each period. Zero is unlimited.
+	limit int
+
+	// period is the length of the rate limit period.
+	period time.Duration
+
+	// cooldowns holds when notifications may be sent again, keyed by Notification.DedupKey.
+	cooldowns map[string]time.Time
+
+	// remindedAt holds when a firing alert was last notified on, keyed by Alert.Key.
+	remindedAt map[string]time.Time
+
+	// window holds when the notifications in the current rate limit period were sent.
+	window []time.Time
+
+	// suppressed is how many notifications were suppressed since the last one was sent.
+	suppressed int
+}
+
+// NewThrottle creates a throttle allowing at most limit notifications in any period. A limit of zero is unlimited.
+func NewThrottle(clock Clock, limit int, period time.Duration) *Throttle {
+	return &Throttle{
+		clock:      clock,
+		limit:      limit,
+		period:     period,
+		cooldowns:  make(map[string]time.Time),
+		remindedAt: make(map[string]time.Time),
+		window:     make([]time.Time, 0),
+	}
+}
+
+// Filter returns the notifications to send for the events of an evaluation, followed by reminders for the alerts
+// that are still firing.
+func (t *Throttle) Filter(events []*Event, alerts []*Alert) []*Notification {
+	now := t.clock.Now()
+	t.prune(now)
+
+	notifications := make([]*Notification, 0)
+	notified := make(map[string]bool, len(events))
+	for _, e := range events {
+		if !e.Notify() {
+			continue
+		}
+
+		notified[e.Key()] = true
+		if e.State == StateResolved {
+			delete(t.remindedAt, e.Key())
+		}
+
+		n := &Notification{Event: e}
+		if until, ok := t.cooldowns[n.DedupKey()]; ok && now.Before(until) && e.State != StateResolved {
+			t.suppressed++
+			continue
+		}
+
+		if t.send(n, now) {
+			notifications = append(notifications, n)
+		}
+	}
+
+	for _, a := range alerts {
+		if a.State != StateFiring || notified[a.Key()] || a.Rule.RepeatInterval <= 0 {
+			continue
+		}
+
+		last, ok := t.remindedAt[a.Key()]
+		if !ok {
+			last = a.FiredAt
+		}
+
+		if now.Sub(last) < a.Rule.RepeatInterval.Std() {
+			continue
+		}
+
+		n := &Notification{
+			Event:    &Event{Alert: *a, From: StateFiring, Time: now},
+			Reminder: true,
+		}
+		if t.send(n, now) {
+			notifications = append(notifications, n)
+		} else {
+			// Try again once the next interval has passed rather than on every evaluation.
+			t.remindedAt[a.Key()] = now
+		}
+	}
+
+	return notifications
+}
+
+// send records the notification as sent if it is within the rate limit, returning false if it was suppressed. Resolves
+// are never suppressed.
+func (t *Throttle) send(n *Notification, now time.Time) bool {
+	if n.State == StateResolved {
+		n.Suppressed = t.suppressed
+		t.suppressed = 0
+		return true
+	}
+
+	if t.limit > 0 && len(t.window) >= t.limit {
+		t.suppressed++
+		return false
+	}
+
+	n.Suppressed = t.suppressed
+	t.suppressed = 0
+	t.window = append(t.window, now)
+	if n.Rule.Cooldown > 0 {
+		t.cooldowns[n.DedupKey()] = now.Add(n.Rule.Cooldown.Std())
+	}
+	if n.State == StateFiring {
+		t.remindedAt[n.Key()] = now
+	}
+
+	return true
+}
+
+// prune forgets the notifications that no longer count towards the rate limit or a cooldown.
+func (t *Throttle) prune(now time.Time) {
+	kept := t.window[:0]
+	for _, sent := range t.window {
+		if now.Sub(sent) < t.period {
+			kept = append(kept, sent)
+		}
+	}
+	t.window = kept
+
+	for key, until := range t.cooldowns {
+		if !now.Before(until) {
+			delete(t.cooldowns, key)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+func TestThrottle_Filter(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	m := NewEvaluator([]*config.Rule{
+		{
+			Name:           "cpu",
+			Match:          "coretemp-*",
+			Warn:           &config.Threshold{Offset: 90},
+			RepeatInterval: config.Duration(10 * time.Minute),
+			Cooldown:       config.Duration(5 * time.Minute),
+		},
+	}, WithClock(clock))
+	throttle := NewThrottle(clock, 2, time.Minute)
+
+	snapshot := func(values ...float64) *sensors.Snapshot {
+		features := make([]*sensors.Feature, 0, len(values))
+		for i, v := range values {
+			features = append(features, &sensors.Feature{
+				Name:   "Core " + string(rune('0'+i)),
+				Kind:   sensors.KindTemperature,
+				Values: map[sensors.Subfeature]float64{sensors.SubfeatureInput: v},
+			})
+		}
+
+		return sensors.NewSnapshot(clock.Now(), []*sensors.Chip{{Name: "coretemp-isa-0000", Features: features}})
+	}
+
+	step := func(d time.Duration, values ...float64) []string {
+		clock.Advance(d)
+		events := m.Evaluate(snapshot(values...))
+		messages := make([]string, 0)
+		for _, n := range throttle.Filter(events, m.Alerts()) {
+			messages = append(messages, n.Message())
+		}
+		return messages
+	}
+
+	require.Empty(t, step(0, 60, 60, 60))
+
+	// Only two notifications are allowed each minute, the third is suppressed.
+	require.Equal(t, []string{
+		"coretemp-isa-0000/Core 0 is at 91.0°C: at or above warn threshold 90 (90.0°C)",
+		"coretemp-isa-0000/Core 1 is at 92.0°C: at or above warn threshold 90 (90.0°C)",
+	}, step(time.Second, 91, 92, 93))
+
+	// The next notification sent reports the suppressed one.
+	require.Equal(t, []string{
+		"coretemp-isa-0000/Core 0 has recovered at 60.0°C after 1m0s (1 similar alert suppressed)",
+	}, step(time.Minute, 60, 92, 93))
+
+	// Flapping back within the cooldown is suppressed.
+	require.Empty(t, step(time.Minute, 91, 92, 93))
+
+	// Alerts that stay firing are repeated once the repeat interval has passed since they were last notified.
+	require.Equal(t, []string{
+		"coretemp-isa-0000/Core 1 is at 92.0°C: at or above warn threshold 90 (90.0°C) (firing for 10m0s) (1 similar alert suppressed)",
+		"coretemp-isa-0000/Core 2 is at 93.0°C: at or above warn threshold 90 (90.0°C) (firing for 10m0s)",
+	}, step(8*time.Minute, 91, 92, 93))
+	require.Empty(t, step(time.Minute, 91, 92, 93))
+
+	// Core 0 fired within the cooldown so it was never notified on; it is reminded of from when it fired.
+	require.Equal(t, []string{
+		"coretemp-isa-0000/Core 0 is at 91.0°C: at or above warn threshold 90 (90.0°C) (firing for 10m0s)",
+	}, step(time.Minute, 91, 92, 93))
+}
+
+func TestThrottle_Filter_Resolved(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	m := NewEvaluator([]*config.Rule{
+		{
+			Name:     "nvme",
+			Match:    "nvme-*",
+			Warn:     &config.Threshold{Offset: 70},
+			Cooldown: config.Duration(5 * time.Minute),
+		},
+	}, WithClock(clock))
+	throttle := NewThrottle(clock, 1, time.Hour)
+
+	step := func(d time.Duration, value float64) []string {
+		clock.Advance(d)
+		events := m.Evaluate(sensors.NewSnapshot(clock.Now(), []*sensors.Chip{{
+			Name: "nvme-pci-0100",
+			Features: []*sensors.Feature{{
+				Name:   "Composite",
+				Kind:   sensors.KindTemperature,
+				Values: map[sensors.Subfeature]float64{sensors.SubfeatureInput: value},
+			}},
+		}}))
+		messages := make([]string, 0)
+		for _, n := range throttle.Filter(events, m.Alerts()) {
+			messages = append(messages, n.Message())
+		}
+		return messages
+	}
+
+	require.Equal(t, []string{
+		"nvme-pci-0100/Composite is at 75.0°C: at or above warn threshold 70 (70.0°C)",
+	}, step(0, 75))
+
+	// The resolve is sent although the rate limit has been reached, and does not count towards it.
+	require.Equal(t, []string{
+		"nvme-pci-0100/Composite has recovered at 60.0°C after 1m0s",
+	}, step(time.Minute, 60))
+
+	// Firing again is suppressed by the cooldown and the rate limit, while resolving within the cooldown is not.
+	require.Empty(t, step(time.Minute, 75))
+	require.Equal(t, []string{
+		"nvme-pci-0100/Composite has recovered at 60.0°C after 1m0s (1 similar alert suppressed)",
+	}, step(time.Minute, 60))
+}
+
+func TestNotification_DedupKey(t *testing.T) {
+	t.Parallel()
+
+	n := &Notification{Event: &Event{Alert: Alert{
+		Reading:  &sensors.Reading{Chip: "coretemp-isa-0000", Feature: &sensors.Feature{Name: "Core 0"}},
+		Rule:     &config.Rule{Name: "cores"},
+		State:    StateFiring,
+		Severity: SeverityCritical,
+	}}}
+
+	require.Equal(t, "cores:coretemp-isa-0000/Core 0/firing/critical", n.DedupKey())
+}
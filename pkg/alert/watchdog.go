@@ -0,0 +1,259 @@
+package alert
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+const (
+	// MissingRule is the name of the rule of the alert raised when an expected sensor is no longer reported.
+	MissingRule = "sensor-missing"
+
+	// StaleRule is the name of the rule of the alert raised when the value of a sensor stops changing.
+	StaleRule = "sensor-stale"
+
+	// ImplausibleRule is the name of the rule of the alert raised when a sensor reports a physically impossible value.
+	ImplausibleRule = "sensor-implausible"
+)
+
+// Watchdog raises alerts on sensors that cannot be trusted: expected sensors that are no longer reported, e.g. an
+// NVMe drive that dropped off the bus, sensors whose value has stopped changing for implausibly long, and sensors
+// reporting physically impossible values such as -273°C or a stopped fan that is being driven. Such a sensor would
+// otherwise either not be checked at all or read as perfectly fine.
+type Watchdog struct {
+	cfg config.Watchdog
+
+	// severity is the severity of the alerts raised.
+	severity Severity
+
+	// missing, stale and implausible are the rules the alerts are raised under.
+	missing     *config.Rule
+	stale       *config.Rule
+	implausible *config.Rule
+
+	// clock times the alert transitions.
+	clock Clock
+
+	// discovered is set once the sensors of the first snapshot with any readings have been expected.
+	discovered bool
+
+	// expected holds every discovered sensor, keyed by its ID.
+	expected map[string]*sighting
+
+	// changes holds the value of every sensor checked for stale values and since when it has had it, keyed by its ID.
+	changes map[string]*sighting
+
+	// alerts holds the alerts that are not in StateOK, keyed by Alert.Key.
+	alerts map[string]*Alert
+}
+
+// sighting is a reading of a sensor and when it was seen.
+type sighting struct {
+	reading *sensors.Reading
+	value   float64
+	at      time.Time
+}
+
+// NewWatchdog creates a watchdog with the given configuration.
+func NewWatchdog(cfg config.Watchdog, clock Clock) *Watchdog {
+	w := &Watchdog{
+		clock:    clock,
+		expected: make(map[string]*sighting),
+		changes:  make(map[string]*sighting),
+		alerts:   make(map[string]*Alert),
+	}
+	w.SetConfig(cfg)
+
+	return w
+}
+
+// SetConfig replaces the configuration, e.g. when the configuration is reloaded. Discovered sensors and the state of
+// the alerts are kept, unless discovery has been turned off.
+func (w *Watchdog) SetConfig(cfg config.Watchdog) {
+	severity, err := ParseSeverity(string(cfg.Severity))
+	if err != nil {
+		severity = SeverityWarning
+	}
+
+	if !cfg.Discover {
+		w.discovered = false
+		clear(w.expected)
+	}
+
+	w.cfg = cfg
+	w.severity = severity
+	w.missing = &config.Rule{Name: MissingRule, For: cfg.MissingFor}
+	w.stale = &config.Rule{Name: StaleRule}
+	w.implausible = &config.Rule{Name: ImplausibleRule, For: cfg.ImplausibleFor}
+	for _, a := range w.alerts {
+		switch a.Rule.Name {
+		case MissingRule:
+			a.Rule = w.missing
+		case StaleRule:
+			a.Rule = w.stale
+		case ImplausibleRule:
+			a.Rule = w.implausible
+		}
+	}
+}
+
+// Alerts returns the alerts that are pending, firing or have just resolved, ordered by key.
+func (w *Watchdog) Alerts() []*Alert {
+	alerts := make([]*Alert, 0, len(w.alerts))
+	for _, key := range slices.Sorted(maps.Keys(w.alerts)) {
+		alerts = append(alerts, w.alerts[key])
+	}
+
+	return alerts
+}
+
+// Evaluate checks the snapshot for missing, stale and implausible sensors and returns the transitions of their
+// alerts. When discovery is on, the sensors of the first snapshot with any readings are expected from then on.
+func (w *Watchdog) Evaluate(snapshot *sensors.Snapshot) []*Event {
+	now := w.clock.Now()
+	if w.cfg.Discover && !w.discovered && len(snapshot.Readings) > 0 {
+		for _, r := range snapshot.Readings {
+			value, _ := r.Input()
+			w.expected[r.ID()] = &sighting{reading: r, value: value, at: now}
+		}
+		w.discovered = true
+	}
+
+	events := make([]*Event, 0)
+	add := func(event *Event) {
+		if event != nil {
+			events = append(events, event)
+		}
+	}
+
+	seen := make(map[string]bool, len(snapshot.Readings))
+	for _, r := range snapshot.Readings {
+		seen[r.ID()] = true
+		value, ok := r.Input()
+		if !ok {
+			continue
+		}
+
+		if s, ok := w.expected[r.ID()]; ok {
+			s.reading, s.value, s.at = r, value, now
+		}
+
+		add(update(w.alerts, w.stale, r, value, w.checkStale(r, value, now), now))
+		add(update(w.alerts, w.implausible, r, value, w.checkImplausible(r, value), now))
+	}
+
+	// Sensors that are not reported are not checked for stale values, so that one that comes back starts afresh.
+	for id := range w.changes {
+		if !seen[id] {
+			delete(w.changes, id)
+		}
+	}
+
+	checked := make(map[string]bool, len(w.expected)+len(w.cfg.Expect))
+	for _, id := range slices.Sorted(maps.Keys(w.expected)) {
+		s := w.expected[id]
+		var b *breach
+		if !seen[id] {
+			b = w.breach(fmt.Sprintf("not reported since %s", s.at.Format(time.TimeOnly)))
+		}
+
+		checked[MissingRule+":"+id] = true
+		add(update(w.alerts, w.missing, s.reading, s.value, b, now))
+	}
+
+	for _, pattern := range w.cfg.Expect {
+		reading := patternReading(pattern)
+		var b *breach
+		if !slices.ContainsFunc(snapshot.Readings, (&config.Rule{Match: pattern}).Matches) {
+			b = w.breach("no sensor matches " + pattern)
+		}
+
+		checked[MissingRule+":"+reading.ID()] = true
+		add(update(w.alerts, w.missing, reading, 0, b, now))
+	}
+
+	// Sensors that are no longer expected, e.g. after the configuration is reloaded, are no longer missing.
+	for _, key := range slices.Sorted(maps.Keys(w.alerts)) {
+		a := w.alerts[key]
+		if a.Rule.Name == MissingRule && !checked[key] {
+			add(update(w.alerts, w.missing, a.Reading, a.Value, nil, now))
+		}
+	}
+
+	return events
+}
+
+// checkStale returns a breach if the value of the sensor has not changed for the stale duration.
+func (w *Watchdog) checkStale(r *sensors.Reading, value float64, now time.Time) *breach {
+	if w.cfg.StaleFor <= 0 || !slices.Contains(w.cfg.StaleKinds, r.Kind) || (r.Kind == sensors.KindFan && value == 0) {
+		delete(w.changes, r.ID())
+		return nil
+	}
+
+	s, ok := w.changes[r.ID()]
+	if !ok || s.value != value {
+		w.changes[r.ID()] = &sighting{reading: r, value: value, at: now}
+		return nil
+	}
+
+	if now.Sub(s.at) < w.cfg.StaleFor.Std() {
+		return nil
+	}
+
+	return w.breach(fmt.Sprintf("unchanged since %s", s.at.Format(time.TimeOnly)))
+}
+
+// checkImplausible returns a breach if the value of the sensor is physically impossible: outside of the range of its
+// kind, or a fan that is stopped while it is being driven.
+func (w *Watchdog) checkImplausible(r *sensors.Reading, value float64) *breach {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return w.breach("not a number")
+	}
+
+	if rng, ok := w.cfg.Ranges[r.Kind]; ok && rng != nil && !rng.Contains(value) {
+		return w.breach("outside of the plausible range " + formatRange(r.Kind, rng))
+	}
+
+	if pwm, ok := r.Value(sensors.SubfeaturePWM); ok && r.Kind == sensors.KindFan && value == 0 && pwm > 0 {
+		return w.breach(fmt.Sprintf("stopped while driven at PWM %.0f", pwm))
+	}
+
+	return nil
+}
+
+// breach returns a breach of a watchdog rule for the given reason.
+func (w *Watchdog) breach(reason string) *breach {
+	return &breach{severity: w.severity, reason: reason, direction: directionAlarm}
+}
+
+// patternReading returns the reading the missing alert of an expected pattern is raised on: the chip and feature
+// patterns as the chip and feature.
+func patternReading(pattern string) *sensors.Reading {
+	chip, feature, ok := strings.Cut(pattern, "/")
+	if !ok {
+		feature = "*"
+	}
+
+	return &sensors.Reading{Chip: chip, Feature: &sensors.Feature{Name: feature}}
+}
+
+// formatRange describes a range of values of the given kind, e.g. "-50.0°C to 150.0°C".
+func formatRange(kind sensors.Kind, r *config.Range) string {
+	switch {
+	case r.Min != nil && r.Max != nil:
+		return kind.Format(*r.Min) + " to " + kind.Format(*r.Max)
+	case r.Min != nil:
+		return "at least " + kind.Format(*r.Min)
+	case r.Max != nil:
+		return "at most " + kind.Format(*r.Max)
+	default:
+		return "of any value"
+	}
+}
@@ -0,0 +1,236 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// watchdogSnapshot returns a snapshot with a temperature on each of the given chips and a fan driven at the given PWM.
+func watchdogSnapshot(temp, fan, pwm float64, chips ...string) *sensors.Snapshot {
+	all := make([]*sensors.Chip, 0, len(chips)+1)
+	all = append(all, &sensors.Chip{
+		Name: "dell_smm-virtual-0",
+		Features: []*sensors.Feature{{
+			Name: "fan1",
+			Kind: sensors.KindFan,
+			Values: map[sensors.Subfeature]float64{
+				sensors.SubfeatureInput: fan,
+				sensors.SubfeaturePWM:   pwm,
+			},
+		}},
+	})
+
+	for _, chip := range chips {
+		all = append(all, &sensors.Chip{
+			Name: chip,
+			Features: []*sensors.Feature{{
+				Name:   "Composite",
+				Kind:   sensors.KindTemperature,
+				Values: map[sensors.Subfeature]float64{sensors.SubfeatureInput: temp},
+			}},
+		})
+	}
+
+	return sensors.NewSnapshot(time.Time{}, all)
+}
+
+type watchdogResult struct {
+	key      string
+	from, to State
+}
+
+// stepWatchdog advances the clock by the interval and evaluates the snapshot.
+func stepWatchdog(
+	w *Watchdog,
+	clock *fakeClock,
+	interval time.Duration,
+	snapshot *sensors.Snapshot,
+) []watchdogResult {
+	clock.Advance(interval)
+	got := make([]watchdogResult, 0)
+	for _, event := range w.Evaluate(snapshot) {
+		got = append(got, watchdogResult{key: event.Key(), from: event.From, to: event.State})
+	}
+
+	return got
+}
+
+func TestWatchdog_Missing(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	cfg := config.DefaultWatchdog()
+	cfg.Expect = []string{"acpitz-*"}
+	w := NewWatchdog(cfg, clock)
+	step := func(snapshot *sensors.Snapshot) []watchdogResult {
+		return stepWatchdog(w, clock, 10*time.Second, snapshot)
+	}
+
+	// The sensors of the first read are expected from then on; a pattern nothing matches is missing from the start.
+	const pattern = "sensor-missing:acpitz-*/*"
+	require.Equal(t, []watchdogResult{{pattern, StateOK, StatePending}},
+		step(watchdogSnapshot(40, 2000, 128, "nvme-pci-0100")))
+	require.Empty(t, step(watchdogSnapshot(41, 2000, 128, "nvme-pci-0100")))
+
+	// A sensor that vanishes is missing once it has been gone for the missing duration.
+	const nvme = "sensor-missing:nvme-pci-0100/Composite"
+	require.Equal(t, []watchdogResult{{nvme, StateOK, StatePending}}, step(watchdogSnapshot(41, 2000, 128)))
+	require.Equal(t, []watchdogResult{{pattern, StatePending, StateFiring}}, step(watchdogSnapshot(41, 2000, 128)))
+	require.Empty(t, step(watchdogSnapshot(41, 2000, 128)))
+	require.Equal(t, []watchdogResult{{nvme, StatePending, StateFiring}}, step(watchdogSnapshot(41, 2000, 128)))
+
+	alerts := w.Alerts()
+	require.Len(t, alerts, 2)
+	require.Equal(t, SeverityWarning, alerts[1].Severity)
+	require.Equal(t, "nvme-pci-0100/Composite is missing: not reported since 12:00:20", alerts[1].Message())
+	require.Equal(t, "acpitz-*/* is missing: no sensor matches acpitz-*", alerts[0].Message())
+
+	// It resolves when it is reported again, and the pattern resolves once it is no longer expected.
+	require.Equal(t, []watchdogResult{{nvme, StateFiring, StateResolved}},
+		step(watchdogSnapshot(42, 2000, 128, "nvme-pci-0100")))
+
+	cfg.Expect = nil
+	w.SetConfig(cfg)
+	require.Equal(t, []watchdogResult{{pattern, StateFiring, StateResolved}},
+		step(watchdogSnapshot(42, 2000, 128, "nvme-pci-0100")))
+	require.Empty(t, step(watchdogSnapshot(42, 2000, 128, "nvme-pci-0100")))
+	require.Empty(t, w.Alerts())
+}
+
+func TestWatchdog_Stale(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	cfg := config.DefaultWatchdog()
+	cfg.StaleFor = config.Duration(time.Hour)
+	w := NewWatchdog(cfg, clock)
+	step := func(temp, fan float64) []watchdogResult {
+		return stepWatchdog(w, clock, 30*time.Minute, watchdogSnapshot(temp, fan, 128, "nvme-pci-0100"))
+	}
+
+	require.Empty(t, step(40, 2000))
+	require.Empty(t, step(40, 2010))
+
+	// The temperature has not changed for an hour, while the fan has.
+	const stale = "sensor-stale:nvme-pci-0100/Composite"
+	require.Equal(t, []watchdogResult{{stale, StateOK, StateFiring}}, step(40, 2020))
+	require.Equal(t, "nvme-pci-0100/Composite is at 40.0°C: unchanged since 12:30:00", w.Alerts()[0].Message())
+
+	require.Equal(t, []watchdogResult{{stale, StateFiring, StateResolved}}, step(41, 2030))
+	require.Empty(t, step(41, 2040))
+	require.Empty(t, w.Alerts())
+}
+
+func TestWatchdog_Stale_Idle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		staleFor time.Duration
+	}{
+		{name: "default"},
+		{name: "stale check on", staleFor: time.Hour},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := &fakeClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+			cfg := config.DefaultWatchdog()
+			if test.staleFor > 0 {
+				cfg.StaleFor = config.Duration(test.staleFor)
+				cfg.StaleKinds = []sensors.Kind{sensors.KindFan}
+			}
+			w := NewWatchdog(cfg, clock)
+
+			// An idle fan held at 0 RPM, and not driven, is not stale however long it stays stopped. By default, nor is
+			// a steady temperature.
+			for range 10 {
+				require.Empty(t, stepWatchdog(w, clock, 30*time.Minute, watchdogSnapshot(40, 0, 0, "nvme-pci-0100")))
+			}
+			require.Empty(t, w.Alerts())
+		})
+	}
+}
+
+func TestWatchdog_Implausible(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		temp   float64
+		fan    float64
+		pwm    float64
+		want   []string
+		reason string
+	}{
+		{
+			name: "plausible",
+			temp: 40,
+			fan:  2000,
+			pwm:  128,
+		},
+		{
+			name:   "absolute zero",
+			temp:   -273,
+			fan:    2000,
+			pwm:    128,
+			want:   []string{"sensor-implausible:nvme-pci-0100/Composite"},
+			reason: "outside of the plausible range -50.0°C to 150.0°C",
+		},
+		{
+			name:   "maximum of a byte",
+			temp:   255,
+			fan:    2000,
+			pwm:    128,
+			want:   []string{"sensor-implausible:nvme-pci-0100/Composite"},
+			reason: "outside of the plausible range -50.0°C to 150.0°C",
+		},
+		{
+			name:   "stopped fan driven",
+			temp:   40,
+			fan:    0,
+			pwm:    128,
+			want:   []string{"sensor-implausible:dell_smm-virtual-0/fan1"},
+			reason: "stopped while driven at PWM 128",
+		},
+		{
+			name: "stopped fan not driven",
+			temp: 40,
+			fan:  0,
+			pwm:  0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := &fakeClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+			w := NewWatchdog(config.DefaultWatchdog(), clock)
+			snapshot := watchdogSnapshot(test.temp, test.fan, test.pwm, "nvme-pci-0100")
+
+			// The value must be impossible for the implausible duration, so that a fan spinning up is not alerted on.
+			pending := stepWatchdog(w, clock, 0, snapshot)
+			fired := stepWatchdog(w, clock, config.DefaultWatchdogImplausibleFor.Std(), snapshot)
+
+			got := make([]string, 0)
+			for i, r := range fired {
+				require.Equal(t, watchdogResult{r.key, StateOK, StatePending}, pending[i])
+				require.Equal(t, StateFiring, r.to)
+				got = append(got, r.key)
+			}
+			require.ElementsMatch(t, test.want, got)
+
+			for _, a := range w.Alerts() {
+				require.Equal(t, test.reason, a.Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultAlertmanagerResendInterval is how often firing alerts are sent to Alertmanager again, matching Prometheus.
+const DefaultAlertmanagerResendInterval = Duration(time.Minute)
+
+// labelName matches valid Prometheus label names.
+var labelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Alertmanager is the configuration of an Alertmanager notifier.
+type Alertmanager struct {
+	// URLs are the base URLs of Alertmanager, e.g. "http://alertmanager:9093". Alerts are sent to every instance of a
+	// highly available cluster.
+	URLs []string `yaml:"urls"`
+
+	// ResendInterval is how often firing alerts are sent again, so that Alertmanager does not resolve them.
+	ResendInterval Duration `yaml:"resend_interval"`
+
+	// Labels are added to every alert, and may override the alertname label.
+	Labels map[string]string `yaml:"labels,omitempty"`
+
+	// Username and Password authenticate with HTTP basic authentication when given.
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+
+	HTTPOptions `yaml:",inline"`
+}
+
+// UnmarshalYAML decodes an Alertmanager notifier, filling in defaults for anything not given.
+func (a *Alertmanager) UnmarshalYAML(node *yaml.Node) error {
+	type plain Alertmanager
+	p := plain(*DefaultAlertmanager())
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*a = Alertmanager(p)
+	return nil
+}
+
+// DefaultAlertmanager returns the defaults of an Alertmanager notifier.
+func DefaultAlertmanager() *Alertmanager {
+	return &Alertmanager{
+		ResendInterval: DefaultAlertmanagerResendInterval,
+		HTTPOptions:    DefaultHTTPOptions(),
+	}
+}
+
+// validate checks the Alertmanager settings.
+func (a *Alertmanager) validate(at func(keys ...any) []any, add problemFunc) {
+	if len(a.URLs) == 0 {
+		add(at("urls"), "at least one URL is required")
+	}
+
+	for i, u := range a.URLs {
+		validateURL(at("urls", i), u, add)
+	}
+
+	if a.ResendInterval <= 0 {
+		add(at("resend_interval"), "must be greater than zero")
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(a.Labels)) {
+		if !labelName.MatchString(name) {
+			add(at("labels", name), "invalid label name %q", name)
+		}
+	}
+
+	if a.Password != "" && a.Username == "" {
+		add(at("username"), "required when a password is given")
+	}
+
+	a.HTTPOptions.validate(at, add)
+}
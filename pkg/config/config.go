@@ -0,0 +1,546 @@
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+const (
+	// DefaultPath is the path the monitor loads its configuration from when none is given.
+	DefaultPath = "/etc/sensor-monitor.yaml"
+
+	// DefaultAppName is the name of the application used for notifications.
+	DefaultAppName = "CPU Temp Monitor"
+
+	// DefaultPollInterval is how often the sensors are read.
+	DefaultPollInterval = Duration(500 * time.Millisecond)
+
+	// DefaultReadTimeout is how long a read of the sensors may take before it is abandoned.
+	DefaultReadTimeout = Duration(5 * time.Second)
+
+	// DefaultShutdownTimeout is how long the monitor waits for pending notifications and readings to be sent when it
+	// is stopped.
+	DefaultShutdownTimeout = Duration(10 * time.Second)
+
+	// DefaultDeliveryTimeout is how long the notifications and readings of a poll may take to be sent before they are
+	// given up on.
+	DefaultDeliveryTimeout = Duration(30 * time.Second)
+
+	// DefaultMatch is the sensor pattern that matches every sensor.
+	DefaultMatch = "*"
+
+	// DefaultMetricsPath is the path Prometheus metrics are served on.
+	DefaultMetricsPath = "/metrics"
+)
+
+// SourceType is the type of a sensor source.
+type SourceType string
+
+const (
+	// SourceAuto picks the best source available on the host.
+	SourceAuto SourceType = "auto"
+
+	// SourceHwmon reads the kernel hwmon sysfs interface.
+	SourceHwmon SourceType = "hwmon"
+
+	// SourceThermal reads the kernel thermal zones and cooling devices.
+	SourceThermal SourceType = "thermal"
+
+	// SourceLMSensors runs the lm-sensors `sensors` binary.
+	SourceLMSensors SourceType = "lm-sensors"
+)
+
+// sourceTypes is every valid source type.
+var sourceTypes = []SourceType{
+	SourceAuto,
+	SourceHwmon,
+	SourceThermal,
+	SourceLMSensors,
+}
+
+// Config is the configuration of the monitor.
+type Config struct {
+	// AppName is the name of the application used for notifications.
+	AppName string `yaml:"app_name"`
+
+	// PollInterval is how often the sensors are read.
+	PollInterval Duration `yaml:"poll_interval"`
+
+	// ReadTimeout is how long a read of the sensors may take before it is abandoned, e.g. a hung `sensors` binary.
+	ReadTimeout Duration `yaml:"read_timeout"`
+
+	// ShutdownTimeout is how long the monitor waits for pending notifications and readings to be sent when it is
+	// stopped.
+	ShutdownTimeout Duration `yaml:"shutdown_timeout"`
+
+	// DeliveryTimeout is how long the notifications and readings of a poll may take to be sent before they are given
+	// up on. They are sent in the background, so a notifier or output that is down does not hold up reading sensors.
+	DeliveryTimeout Duration `yaml:"delivery_timeout"`
+
+	// Sources are the sources sensors are read from. The readings of every source are combined.
+	Sources []*Source `yaml:"sources"`
+
+	// Rules decide when a sensor reading is notified on. The first rule matching a sensor is used.
+	Rules []*Rule `yaml:"rules"`
+
+	// Fans decide when fans are alerted on as slow, stopped or pinned at their maximum speed. The first fan rule
+	// matching a fan is used.
+	Fans []*Fan `yaml:"fans"`
+
+	// Notifiers are where notifications are sent.
+	Notifiers []*Notifier `yaml:"notifiers"`
+
+	// Metrics configures the Prometheus metrics endpoint.
+	Metrics Metrics `yaml:"metrics"`
+
+	// Outputs are where every reading is pushed.
+	Outputs []*Output `yaml:"outputs"`
+
+	// History configures the local history of readings, alert transitions and notifications.
+	History History `yaml:"history"`
+
+	// Health configures how sources that fail to read are retried and alerted on.
+	Health Health `yaml:"health"`
+
+	// Watchdog configures the alerts on sensors that go missing, stop changing or report impossible values.
+	Watchdog Watchdog `yaml:"watchdog"`
+}
+
+// Metrics is the configuration of the Prometheus metrics endpoint.
+type Metrics struct {
+	// Listen is the address metrics are served on, e.g. ":9102". Metrics are not served when it is empty.
+	Listen string `yaml:"listen"`
+
+	// Path is the path metrics are served on.
+	Path string `yaml:"path"`
+}
+
+// Source is the configuration of a sensor source.
+type Source struct {
+	// Type is the type of the source.
+	Type SourceType `yaml:"type"`
+
+	// SysfsRoot is the mount point of sysfs for the hwmon and thermal sources.
+	SysfsRoot string `yaml:"sysfs_root"`
+}
+
+// UnmarshalYAML decodes a source, filling in defaults for anything not given.
+func (s *Source) UnmarshalYAML(node *yaml.Node) error {
+	type plain Source
+	p := plain(*DefaultSource())
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*s = Source(p)
+	return nil
+}
+
+// Rule decides when the sensors it matches are notified on.
+type Rule struct {
+	// Name identifies the rule in notifications.
+	Name string `yaml:"name"`
+
+	// Match is a glob matched against the sensor in the form "chip/feature", e.g. "coretemp-*/Core *". A pattern
+	// without a "/" matches every feature of the chips it matches.
+	Match string `yaml:"match"`
+
+	// Kinds limits the rule to sensors of the given kinds. An empty list matches every kind.
+	Kinds []sensors.Kind `yaml:"kinds,omitempty"`
+
+	// Warn is the value at or above which a warning is raised.
+	Warn *Threshold `yaml:"warn,omitempty"`
+
+	// Critical is the value at or above which a critical alert is raised.
+	Critical *Threshold `yaml:"critical,omitempty"`
+
+	// WarnBelow is the value at or below which a warning is raised, e.g. "min" for a fan.
+	WarnBelow *Threshold `yaml:"warn_below,omitempty"`
+
+	// CriticalBelow is the value at or below which a critical alert is raised.
+	CriticalBelow *Threshold `yaml:"critical_below,omitempty"`
+
+	// Clear is the value a firing threshold alert must cross back over before it resolves. For an alert raised by an
+	// upper threshold the reading must drop below it, and for one raised by a lower threshold the reading must rise
+	// above it. Without it an alert resolves as soon as no threshold is breached.
+	Clear *Threshold `yaml:"clear,omitempty"`
+
+	// For is how long a breach must last before the alert fires. Zero fires on the first breach.
+	For Duration `yaml:"for,omitempty"`
+
+	// RepeatInterval is how often a reminder is sent for an alert that stays firing. Zero never sends reminders.
+	RepeatInterval Duration `yaml:"repeat_interval,omitempty"`
+
+	// Cooldown is how long the same notification of an alert is suppressed for after it was sent, e.g. when an alert
+	// flaps between firing and resolved. Zero never suppresses.
+	Cooldown Duration `yaml:"cooldown,omitempty"`
+
+	// Alarms raises an alert whenever the hardware sets an alarm flag of the sensor, such as temp1_crit_alarm.
+	Alarms bool `yaml:"alarms"`
+}
+
+// UnmarshalYAML decodes a rule, filling in defaults for anything not given.
+func (r *Rule) UnmarshalYAML(node *yaml.Node) error {
+	type plain Rule
+	p := plain(*DefaultRule())
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*r = Rule(p)
+	return nil
+}
+
+// patterns splits the match glob into its chip and feature patterns.
+func (r *Rule) patterns() (chip, feature string) {
+	chip, feature, ok := strings.Cut(r.Match, "/")
+	if !ok {
+		feature = "*"
+	}
+
+	return chip, feature
+}
+
+// Matches reports whether the rule applies to the given reading.
+func (r *Rule) Matches(reading *sensors.Reading) bool {
+	if len(r.Kinds) > 0 && !slices.Contains(r.Kinds, reading.Kind) {
+		return false
+	}
+
+	chip, feature := r.patterns()
+	if ok, _ := path.Match(chip, reading.Chip); !ok {
+		return false
+	}
+
+	ok, _ := path.Match(feature, reading.Name)
+	return ok
+}
+
+// HasThresholds reports whether any threshold is set on the rule.
+func (r *Rule) HasThresholds() bool {
+	return r.Warn != nil || r.Critical != nil || r.WarnBelow != nil || r.CriticalBelow != nil
+}
+
+// Default returns the configuration used when no configuration file is given. Temperatures warn at 85% of their
+// critical limit and clear below 80% of it, and every sensor alerts on its hardware alarm flags.
+func Default() *Config {
+	return &Config{
+		AppName:         DefaultAppName,
+		PollInterval:    DefaultPollInterval,
+		ReadTimeout:     DefaultReadTimeout,
+		ShutdownTimeout: DefaultShutdownTimeout,
+		DeliveryTimeout: DefaultDeliveryTimeout,
+		Sources:         []*Source{DefaultSource()},
+		Rules: []*Rule{
+			{
+				Name:     "temperature",
+				Match:    DefaultMatch,
+				Kinds:    []sensors.Kind{sensors.KindTemperature},
+				Warn:     &Threshold{Limit: sensors.SubfeatureCrit, Factor: 0.85},
+				Critical: &Threshold{Limit: sensors.SubfeatureCrit},
+				Clear:    &Threshold{Limit: sensors.SubfeatureCrit, Factor: 0.8},
+				Alarms:   true,
+			},
+			{
+				Name:   "default",
+				Match:  DefaultMatch,
+				Alarms: true,
+			},
+		},
+		Fans: []*Fan{DefaultFan()},
+		Notifiers: []*Notifier{
+			{
+				Name:        string(NotifierDesktop),
+				Type:        NotifierDesktop,
+				MinSeverity: SeverityWarning,
+			},
+		},
+		Metrics: Metrics{
+			Path: DefaultMetricsPath,
+		},
+		History:  DefaultHistory(),
+		Health:   DefaultHealth(),
+		Watchdog: DefaultWatchdog(),
+	}
+}
+
+// DefaultSource returns the defaults of a source.
+func DefaultSource() *Source {
+	return &Source{
+		Type:      SourceAuto,
+		SysfsRoot: sensors.DefaultSysfsRoot,
+	}
+}
+
+// DefaultRule returns the defaults of a rule.
+func DefaultRule() *Rule {
+	return &Rule{
+		Name:   "default",
+		Match:  DefaultMatch,
+		Alarms: true,
+	}
+}
+
+// Error is returned when a configuration file is invalid. It holds every problem found, each prefixed with the line
+// it was found on.
+type Error struct {
+	Problems []string
+}
+
+// Error returns every problem on its own line.
+func (e *Error) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// Load reads and parses the configuration file at the given path.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // The path is given by the user.
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Parse parses a configuration document. Anything not given in the document keeps its default value.
+func Parse(data []byte) (*Config, error) {
+	cfg := Default()
+
+	root := new(yaml.Node)
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(root); errors.Is(err, io.EOF) {
+		// An empty document uses the defaults.
+		return cfg, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	problems := checkFields(root, reflect.TypeOf(cfg))
+	if err := root.Decode(cfg); err != nil {
+		typeErr := new(yaml.TypeError)
+		if !errors.As(err, &typeErr) {
+			return nil, err
+		}
+
+		problems = append(problems, typeErr.Errors...)
+	}
+
+	if len(problems) == 0 {
+		cfg.applyDefaults()
+		problems = cfg.validate(root)
+	}
+
+	if len(problems) > 0 {
+		slices.SortStableFunc(problems, func(a, b string) int {
+			return cmp.Compare(problemLine(a), problemLine(b))
+		})
+		return nil, &Error{Problems: problems}
+	}
+
+	return cfg, nil
+}
+
+// applyDefaults fills in the defaults that depend on other values of the configuration.
+func (c *Config) applyDefaults() {
+	for _, n := range c.Notifiers {
+		if n.Name == "" {
+			n.Name = string(n.Type)
+		}
+	}
+
+	for _, o := range c.Outputs {
+		if o.Name == "" {
+			o.Name = string(o.Type)
+		}
+	}
+}
+
+// validate checks the values of the configuration, returning a problem for each invalid value.
+func (c *Config) validate(root *yaml.Node) []string {
+	problems := make([]string, 0)
+	var add problemFunc = func(path []any, format string, args ...any) {
+		problems = append(problems, fmt.Sprintf("line %d: %s: %s", lineOf(root, path...), formatPath(path...), fmt.Sprintf(format, args...)))
+	}
+
+	if c.PollInterval <= 0 {
+		add([]any{"poll_interval"}, "must be greater than zero")
+	}
+
+	if c.ReadTimeout <= 0 {
+		add([]any{"read_timeout"}, "must be greater than zero")
+	}
+
+	if c.ShutdownTimeout <= 0 {
+		add([]any{"shutdown_timeout"}, "must be greater than zero")
+	}
+
+	if c.DeliveryTimeout <= 0 {
+		add([]any{"delivery_timeout"}, "must be greater than zero")
+	}
+
+	if len(c.Sources) == 0 {
+		add([]any{"sources"}, "at least one source is required")
+	}
+
+	for i, s := range c.Sources {
+		if !slices.Contains(sourceTypes, s.Type) {
+			add([]any{"sources", i, "type"}, "unknown source type %q, must be one of %s", s.Type, joinQuoted(sourceTypes))
+		}
+	}
+
+	for i, r := range c.Rules {
+		validateMatch(r.Match, []any{"rules", i, "match"}, add)
+
+		for j, k := range r.Kinds {
+			if !slices.Contains(sensors.Kinds(), k) {
+				add([]any{"rules", i, "kinds", j}, "unknown sensor kind %q, must be one of %s", k, joinQuoted(sensors.Kinds()))
+			}
+		}
+
+		if r.Warn != nil && r.Critical != nil {
+			if c, ok := r.Warn.Compare(r.Critical); ok && c > 0 {
+				add([]any{"rules", i, "warn"}, "must not be above the critical threshold")
+			}
+		}
+
+		if r.WarnBelow != nil && r.CriticalBelow != nil {
+			if c, ok := r.WarnBelow.Compare(r.CriticalBelow); ok && c < 0 {
+				add([]any{"rules", i, "warn_below"}, "must not be below the critical_below threshold")
+			}
+		}
+
+		if r.Clear != nil && !r.HasThresholds() {
+			add([]any{"rules", i, "clear"}, "requires a warn, critical, warn_below or critical_below threshold")
+		}
+
+		if r.Warn != nil && r.Clear != nil {
+			if c, ok := r.Clear.Compare(r.Warn); ok && c > 0 {
+				add([]any{"rules", i, "clear"}, "must not be above the warn threshold")
+			}
+		}
+
+		if r.WarnBelow != nil && r.Clear != nil {
+			if c, ok := r.Clear.Compare(r.WarnBelow); ok && c < 0 {
+				add([]any{"rules", i, "clear"}, "must not be below the warn_below threshold")
+			}
+		}
+
+		if r.For < 0 {
+			add([]any{"rules", i, "for"}, "must not be negative")
+		}
+
+		if r.RepeatInterval < 0 {
+			add([]any{"rules", i, "repeat_interval"}, "must not be negative")
+		}
+
+		if r.Cooldown < 0 {
+			add([]any{"rules", i, "cooldown"}, "must not be negative")
+		}
+	}
+
+	for i, f := range c.Fans {
+		f.validate(func(keys ...any) []any {
+			return append([]any{"fans", i}, keys...)
+		}, add)
+	}
+
+	names := make(map[string]bool, len(c.Notifiers))
+	for i, n := range c.Notifiers {
+		at := func(keys ...any) []any {
+			return append([]any{"notifiers", i}, keys...)
+		}
+
+		if names[n.Name] {
+			add(at("name"), "duplicate notifier name %q", n.Name)
+		}
+		names[n.Name] = true
+
+		n.validate(at, add)
+	}
+
+	outputs := make(map[string]bool, len(c.Outputs))
+	for i, o := range c.Outputs {
+		at := func(keys ...any) []any {
+			return append([]any{"outputs", i}, keys...)
+		}
+
+		if outputs[o.Name] {
+			add(at("name"), "duplicate output name %q", o.Name)
+		}
+		outputs[o.Name] = true
+
+		o.validate(at, add)
+	}
+
+	if c.Metrics.Listen != "" {
+		if _, _, err := net.SplitHostPort(c.Metrics.Listen); err != nil {
+			add([]any{"metrics", "listen"}, "invalid address %q, must be a host and port such as \":9102\"", c.Metrics.Listen)
+		}
+	}
+
+	if !strings.HasPrefix(c.Metrics.Path, "/") {
+		add([]any{"metrics", "path"}, "must start with \"/\"")
+	}
+
+	c.History.validate(func(keys ...any) []any {
+		return append([]any{"history"}, keys...)
+	}, add)
+
+	c.Health.validate(func(keys ...any) []any {
+		return append([]any{"health"}, keys...)
+	}, add)
+
+	c.Watchdog.validate(func(keys ...any) []any {
+		return append([]any{"watchdog"}, keys...)
+	}, add)
+
+	return problems
+}
+
+// validateMatch checks the chip and feature patterns of a "chip/feature" glob.
+func validateMatch(match string, at []any, add problemFunc) {
+	chip, feature := (&Rule{Match: match}).patterns()
+	if _, err := path.Match(chip, ""); err != nil {
+		add(at, "invalid chip pattern %q", chip)
+	} else if _, err := path.Match(feature, ""); err != nil {
+		add(at, "invalid feature pattern %q", feature)
+	}
+}
+
+// problemFunc records a problem with the value at the given path.
+type problemFunc func(path []any, format string, args ...any)
+
+// problemLine returns the line number a problem is prefixed with.
+func problemLine(problem string) int {
+	var line int
+	_, _ = fmt.Sscanf(problem, "line %d:", &line)
+	return line
+}
+
+// joinQuoted formats a list of values for an error message.
+func joinQuoted[T ~string](values []T) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, fmt.Sprintf("%q", v))
+	}
+
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,1031 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+func TestParse_Empty(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := Parse(nil)
+	require.NoError(t, err)
+	require.Equal(t, Default(), cfg)
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := Parse([]byte(`
+app_name: Lab Monitor
+poll_interval: 2s
+read_timeout: 3s
+sources:
+  - type: hwmon
+  - type: thermal
+    sysfs_root: /host/sys
+rules:
+  - name: nvme
+    match: nvme-*
+    critical: 80
+  - match: coretemp-*/Core *
+    kinds: [temp]
+    warn: max-5
+    critical: crit
+    alarms: false
+  - name: fans
+    match: dell_smm-*/fan*
+    warn_below: min+100
+    critical_below: 0
+  - name: sustained
+    warn: crit*0.9
+    clear: crit*0.85-2
+    for: 30s
+    repeat_interval: 10m
+    cooldown: 1m
+fans:
+  - name: cpu-fan
+    match: dell_smm-*/fan1
+    temperature: coretemp-*/Package id 0
+    stopped_above: crit*0.7
+    pinned_for: 0s
+  - match: dell_ddv-*
+notifiers:
+  - type: desktop
+    rate_limit:
+      count: 5
+      per: 1m
+  - name: critical-desktop
+    type: desktop
+    min_severity: critical
+  - type: webhook
+    webhook:
+      url: https://hooks.example.com/alerts
+      headers:
+        Authorization: Bearer token
+      secret: s3cret
+      retries: 5
+  - type: email
+    email:
+      host: smtp.example.com
+      username: monitor
+      password: hunter2
+      from: Sensor Monitor <monitor@example.com>
+      to: [ops@example.com, lab@example.com]
+      digest: 15m
+  - type: slack
+    slack:
+      token: xoxb-token
+      channel: "#alerts"
+  - type: matrix
+    matrix:
+      homeserver: https://matrix.example.com
+      room_id: "!alerts:example.com"
+      access_token: syt_token
+      timeout: 5s
+  - type: ntfy
+    ntfy:
+      url: https://ntfy.sh/build-box-alerts
+      priority:
+        warning: 3
+      tags: [thermometer]
+  - type: pushover
+    pushover:
+      token: app-token
+      user_key: user-key
+      expire: 30m
+  - type: telegram
+    telegram:
+      token: "123:secret"
+      chat_id: -1001234
+  - type: pagerduty
+    min_severity: critical
+    pagerduty:
+      routing_key: R0UT1NGK3Y
+  - type: opsgenie
+    opsgenie:
+      api_key: api-key
+      api_url: https://api.eu.opsgenie.com
+      priority:
+        warning: P4
+      auto_resolve: false
+  - type: alertmanager
+    alertmanager:
+      urls: [http://alertmanager-0:9093, http://alertmanager-1:9093]
+      labels:
+        team: render
+metrics:
+  listen: ":9102"
+outputs:
+  - type: influxdb
+    flush_interval: 30s
+    influxdb:
+      url: http://influxdb:8086
+      org: lab
+      bucket: sensors
+      token: influx-token
+      gzip: true
+  - name: legacy
+    type: influxdb
+    buffer_size: 500
+    influxdb:
+      url: http://influxdb-1:8086
+      version: 1
+      database: telegraf
+      measurement: hardware
+  - type: graphite
+    graphite:
+      address: graphite:2003
+      protocol: udp
+      prefix: lab.sensors
+  - type: otlp
+    otlp:
+      url: http://otel-collector:4318
+      encoding: json
+      headers:
+        Authorization: Bearer token
+  - type: mqtt
+    flush_interval: 5s
+    mqtt:
+      broker: mqtts://broker.lan:8883
+      version: "5"
+      username: monitor
+      password: hunter2
+      qos: 2
+      tls:
+        ca_file: /etc/ssl/lan-ca.pem
+history:
+  path: /var/lib/sensor-monitor/history.db
+  retention:
+    readings: 48h
+    events: 720h
+health:
+  max_backoff: 5m
+watchdog:
+  expect:
+    - nvme-*/Composite
+  stale_for: 2h
+  ranges:
+    fan:
+      min: 0
+      max: 6000
+`))
+	require.NoError(t, err)
+
+	require.Equal(t, &Config{
+		AppName:         "Lab Monitor",
+		PollInterval:    Duration(2 * time.Second),
+		ReadTimeout:     Duration(3 * time.Second),
+		ShutdownTimeout: DefaultShutdownTimeout,
+		DeliveryTimeout: DefaultDeliveryTimeout,
+		Sources: []*Source{
+			{Type: SourceHwmon, SysfsRoot: "/sys"},
+			{Type: SourceThermal, SysfsRoot: "/host/sys"},
+		},
+		Rules: []*Rule{
+			{
+				Name:     "nvme",
+				Match:    "nvme-*",
+				Critical: &Threshold{Offset: 80},
+				Alarms:   true,
+			},
+			{
+				Name:     "default",
+				Match:    "coretemp-*/Core *",
+				Kinds:    []sensors.Kind{sensors.KindTemperature},
+				Warn:     &Threshold{Limit: sensors.SubfeatureMax, Offset: -5},
+				Critical: &Threshold{Limit: sensors.SubfeatureCrit},
+			},
+			{
+				Name:          "fans",
+				Match:         "dell_smm-*/fan*",
+				WarnBelow:     &Threshold{Limit: sensors.SubfeatureMin, Offset: 100},
+				CriticalBelow: &Threshold{},
+				Alarms:        true,
+			},
+			{
+				Name:           "sustained",
+				Match:          DefaultMatch,
+				Warn:           &Threshold{Limit: sensors.SubfeatureCrit, Factor: 0.9},
+				Clear:          &Threshold{Limit: sensors.SubfeatureCrit, Factor: 0.85, Offset: -2},
+				For:            Duration(30 * time.Second),
+				RepeatInterval: Duration(10 * time.Minute),
+				Cooldown:       Duration(time.Minute),
+				Alarms:         true,
+			},
+		},
+		Fans: []*Fan{
+			{
+				Name:         "cpu-fan",
+				Match:        "dell_smm-*/fan1",
+				Temperature:  "coretemp-*/Package id 0",
+				Min:          &Threshold{Limit: sensors.SubfeatureMin},
+				Rise:         DefaultFanRise,
+				RiseWindow:   DefaultFanRiseWindow,
+				StoppedAbove: &Threshold{Limit: sensors.SubfeatureCrit, Factor: 0.7},
+				Pinned:       &Threshold{Limit: sensors.SubfeatureMax, Factor: 0.95},
+				For:          DefaultFanFor,
+			},
+			{
+				Name:         "fan",
+				Match:        "dell_ddv-*",
+				Min:          &Threshold{Limit: sensors.SubfeatureMin},
+				Rise:         DefaultFanRise,
+				RiseWindow:   DefaultFanRiseWindow,
+				StoppedAbove: &Threshold{Offset: DefaultFanStoppedAbove},
+				Pinned:       &Threshold{Limit: sensors.SubfeatureMax, Factor: 0.95},
+				PinnedFor:    DefaultFanPinnedFor,
+				For:          DefaultFanFor,
+			},
+		},
+		Notifiers: []*Notifier{
+			{
+				Name:        "desktop",
+				Type:        NotifierDesktop,
+				MinSeverity: SeverityWarning,
+				RateLimit:   &RateLimit{Count: 5, Per: Duration(time.Minute)},
+			},
+			{Name: "critical-desktop", Type: NotifierDesktop, MinSeverity: SeverityCritical},
+			{
+				Name:        "webhook",
+				Type:        NotifierWebhook,
+				MinSeverity: SeverityWarning,
+				Webhook: &Webhook{
+					URL:     "https://hooks.example.com/alerts",
+					Method:  "POST",
+					Headers: map[string]string{"Authorization": "Bearer token"},
+					Secret:  "s3cret",
+					HTTPOptions: HTTPOptions{
+						Timeout: DefaultHTTPTimeout,
+						Retries: 5,
+						Backoff: DefaultHTTPBackoff,
+					},
+				},
+			},
+			{
+				Name:        "email",
+				Type:        NotifierEmail,
+				MinSeverity: SeverityWarning,
+				Email: &Email{
+					Host:     "smtp.example.com",
+					TLS:      EmailTLSStartTLS,
+					Username: "monitor",
+					Password: "hunter2",
+					From:     "Sensor Monitor <monitor@example.com>",
+					To:       []string{"ops@example.com", "lab@example.com"},
+					Digest:   Duration(15 * time.Minute),
+					Timeout:  DefaultHTTPTimeout,
+				},
+			},
+			{
+				Name:        "slack",
+				Type:        NotifierSlack,
+				MinSeverity: SeverityWarning,
+				Slack: &Slack{
+					Token:       "xoxb-token",
+					Channel:     "#alerts",
+					APIURL:      "https://slack.com/api",
+					HTTPOptions: DefaultHTTPOptions(),
+				},
+			},
+			{
+				Name:        "matrix",
+				Type:        NotifierMatrix,
+				MinSeverity: SeverityWarning,
+				Matrix: &Matrix{
+					Homeserver:  "https://matrix.example.com",
+					RoomID:      "!alerts:example.com",
+					AccessToken: "syt_token",
+					HTTPOptions: HTTPOptions{
+						Timeout: Duration(5 * time.Second),
+						Retries: DefaultHTTPRetries,
+						Backoff: DefaultHTTPBackoff,
+					},
+				},
+			},
+			{
+				Name:        "ntfy",
+				Type:        NotifierNtfy,
+				MinSeverity: SeverityWarning,
+				Ntfy: &Ntfy{
+					URL:         "https://ntfy.sh/build-box-alerts",
+					Priority:    Priorities{Warning: 3, Critical: 5, Resolved: 3},
+					Tags:        []string{"thermometer"},
+					HTTPOptions: DefaultHTTPOptions(),
+				},
+			},
+			{
+				Name:        "pushover",
+				Type:        NotifierPushover,
+				MinSeverity: SeverityWarning,
+				Pushover: &Pushover{
+					Token:       "app-token",
+					UserKey:     "user-key",
+					Priority:    Priorities{Warning: 0, Critical: PushoverPriorityEmergency, Resolved: -1},
+					Retry:       Duration(time.Minute),
+					Expire:      Duration(30 * time.Minute),
+					APIURL:      "https://api.pushover.net/1",
+					HTTPOptions: DefaultHTTPOptions(),
+				},
+			},
+			{
+				Name:        "telegram",
+				Type:        NotifierTelegram,
+				MinSeverity: SeverityWarning,
+				Telegram: &Telegram{
+					Token:       "123:secret",
+					ChatID:      "-1001234",
+					Silent:      []Level{LevelResolved},
+					APIURL:      "https://api.telegram.org",
+					HTTPOptions: DefaultHTTPOptions(),
+				},
+			},
+			{
+				Name:        "pagerduty",
+				Type:        NotifierPagerDuty,
+				MinSeverity: SeverityCritical,
+				PagerDuty: &PagerDuty{
+					RoutingKey:  "R0UT1NGK3Y",
+					URL:         "https://events.pagerduty.com/v2/enqueue",
+					AutoResolve: true,
+					HTTPOptions: DefaultHTTPOptions(),
+				},
+			},
+			{
+				Name:        "opsgenie",
+				Type:        NotifierOpsgenie,
+				MinSeverity: SeverityWarning,
+				Opsgenie: &Opsgenie{
+					APIKey:      "api-key",
+					APIURL:      "https://api.eu.opsgenie.com",
+					Priority:    OpsgeniePriorities{Warning: "P4", Critical: "P1"},
+					HTTPOptions: DefaultHTTPOptions(),
+				},
+			},
+			{
+				Name:        "alertmanager",
+				Type:        NotifierAlertmanager,
+				MinSeverity: SeverityWarning,
+				Alertmanager: &Alertmanager{
+					URLs:           []string{"http://alertmanager-0:9093", "http://alertmanager-1:9093"},
+					ResendInterval: DefaultAlertmanagerResendInterval,
+					Labels:         map[string]string{"team": "render"},
+					HTTPOptions:    DefaultHTTPOptions(),
+				},
+			},
+		},
+		Metrics: Metrics{Listen: ":9102", Path: DefaultMetricsPath},
+		Outputs: []*Output{
+			{
+				Name:          "influxdb",
+				Type:          OutputInfluxDB,
+				FlushInterval: Duration(30 * time.Second),
+				BufferSize:    DefaultBufferSize,
+				InfluxDB: &InfluxDB{
+					URL:         "http://influxdb:8086",
+					Version:     2,
+					Org:         "lab",
+					Bucket:      "sensors",
+					Token:       "influx-token",
+					Measurement: DefaultInfluxDBMeasurement,
+					Gzip:        true,
+					HTTPOptions: DefaultHTTPOptions(),
+				},
+			},
+			{
+				Name:          "legacy",
+				Type:          OutputInfluxDB,
+				FlushInterval: DefaultFlushInterval,
+				BufferSize:    500,
+				InfluxDB: &InfluxDB{
+					URL:         "http://influxdb-1:8086",
+					Version:     1,
+					Database:    "telegraf",
+					Measurement: "hardware",
+					HTTPOptions: DefaultHTTPOptions(),
+				},
+			},
+			{
+				Name:          "graphite",
+				Type:          OutputGraphite,
+				FlushInterval: DefaultFlushInterval,
+				BufferSize:    DefaultBufferSize,
+				Graphite: &Graphite{
+					Address:  "graphite:2003",
+					Protocol: GraphiteUDP,
+					Prefix:   "lab.sensors",
+					Timeout:  DefaultHTTPTimeout,
+				},
+			},
+			{
+				Name:          "otlp",
+				Type:          OutputOTLP,
+				FlushInterval: DefaultFlushInterval,
+				BufferSize:    DefaultBufferSize,
+				OTLP: &OTLP{
+					URL:         "http://otel-collector:4318",
+					Encoding:    OTLPJSON,
+					Headers:     map[string]string{"Authorization": "Bearer token"},
+					HTTPOptions: DefaultHTTPOptions(),
+				},
+			},
+			{
+				Name:          "mqtt",
+				Type:          OutputMQTT,
+				FlushInterval: Duration(5 * time.Second),
+				BufferSize:    DefaultBufferSize,
+				MQTT: &MQTT{
+					Broker:          "mqtts://broker.lan:8883",
+					Version:         MQTTVersion5,
+					Username:        "monitor",
+					Password:        "hunter2",
+					QoS:             2,
+					Retain:          true,
+					TopicPrefix:     DefaultMQTTTopicPrefix,
+					Discovery:       true,
+					DiscoveryPrefix: DefaultMQTTDiscoveryPrefix,
+					KeepAlive:       DefaultMQTTKeepAlive,
+					Timeout:         DefaultHTTPTimeout,
+					TLS:             TLS{CAFile: "/etc/ssl/lan-ca.pem"},
+				},
+			},
+		},
+		History: History{
+			Path:           "/var/lib/sensor-monitor/history.db",
+			FlushInterval:  DefaultHistoryFlushInterval,
+			BatchSize:      DefaultHistoryBatchSize,
+			RollupInterval: DefaultHistoryRollupInterval,
+			Retention: HistoryRetention{
+				Readings: Duration(48 * time.Hour),
+				Minute:   DefaultHistoryMinuteRetention,
+				Hour:     DefaultHistoryHourRetention,
+				Events:   Duration(720 * time.Hour),
+			},
+		},
+		Health: Health{
+			Backoff:       DefaultHealthBackoff,
+			MaxBackoff:    Duration(5 * time.Minute),
+			DegradedAfter: DefaultHealthDegradedAfter,
+		},
+		Watchdog: Watchdog{
+			Discover:       true,
+			Expect:         []string{"nvme-*/Composite"},
+			MissingFor:     DefaultWatchdogMissingFor,
+			StaleFor:       Duration(2 * time.Hour),
+			StaleKinds:     []sensors.Kind{sensors.KindTemperature, sensors.KindFan},
+			ImplausibleFor: DefaultWatchdogImplausibleFor,
+			Ranges: map[sensors.Kind]*Range{
+				sensors.KindTemperature: {Min: ptr(-50.0), Max: ptr(150.0)},
+				sensors.KindFan:         {Min: ptr(0.0), Max: ptr(6000.0)},
+			},
+			Severity: SeverityWarning,
+		},
+	}, cfg)
+
+	reading := func(chip, feature string, kind sensors.Kind) *sensors.Reading {
+		return &sensors.Reading{Chip: chip, Feature: &sensors.Feature{Name: feature, Kind: kind}}
+	}
+
+	require.True(t, cfg.Rules[0].Matches(reading("nvme-pci-e100", "Composite", sensors.KindTemperature)))
+	require.True(t, cfg.Rules[1].Matches(reading("coretemp-isa-0000", "Core 3", sensors.KindTemperature)))
+	require.False(t, cfg.Rules[1].Matches(reading("coretemp-isa-0000", "Package id 0", sensors.KindTemperature)))
+	require.False(t, cfg.Rules[1].Matches(reading("coretemp-isa-0000", "Core 3", sensors.KindFan)))
+	require.True(t, cfg.Rules[2].Matches(reading("dell_smm-virtual-0", "fan1", sensors.KindFan)))
+	require.False(t, cfg.Rules[2].Matches(reading("dell_smm-virtual-0", "temp1", sensors.KindTemperature)))
+	require.Equal(t, "smtp.example.com:587", cfg.Notifiers[3].Email.Addr())
+
+	require.True(t, cfg.Rules[3].Matches(reading("BAT0-acpi-0", "in0", sensors.KindVoltage)))
+}
+
+func TestParseThreshold(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    *Threshold
+		wantErr string
+	}{
+		{input: "90", want: &Threshold{Offset: 90}},
+		{input: "-40.5", want: &Threshold{Offset: -40.5}},
+		{input: "crit", want: &Threshold{Limit: sensors.SubfeatureCrit}},
+		{input: "max-5", want: &Threshold{Limit: sensors.SubfeatureMax, Offset: -5}},
+		{input: "max - 2.5", want: &Threshold{Limit: sensors.SubfeatureMax, Offset: -2.5}},
+		{input: "min+100", want: &Threshold{Limit: sensors.SubfeatureMin, Offset: 100}},
+		{input: "passive", want: &Threshold{Limit: sensors.SubfeaturePassive}},
+		{input: "crit*0.85", want: &Threshold{Limit: sensors.SubfeatureCrit, Factor: 0.85}},
+		{input: "crit * 0.9 - 2", want: &Threshold{Limit: sensors.SubfeatureCrit, Factor: 0.9, Offset: -2}},
+		{input: "max*1", want: &Threshold{Limit: sensors.SubfeatureMax}},
+		{input: "crit*", wantErr: `invalid threshold "crit*"`},
+		{input: "max-", wantErr: `invalid threshold "max-"`},
+		{input: "input-5", wantErr: `unknown hardware limit "input"`},
+		{input: "hot", want: &Threshold{Limit: sensors.SubfeatureHot}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseThreshold(test.input)
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.want, got)
+
+			reparsed, err := ParseThreshold(got.String())
+			require.NoError(t, err)
+			require.Equal(t, got, reparsed)
+		})
+	}
+}
+
+func TestThreshold_Resolve(t *testing.T) {
+	t.Parallel()
+
+	feature := &sensors.Feature{
+		Kind: sensors.KindTemperature,
+		Values: map[sensors.Subfeature]float64{
+			sensors.SubfeatureInput: 61,
+			sensors.SubfeatureMax:   100,
+		},
+	}
+
+	got, ok := (&Threshold{Limit: sensors.SubfeatureMax, Offset: -5}).Resolve(feature)
+	require.True(t, ok)
+	require.InDelta(t, 95.0, got, 0.001)
+
+	got, ok = (&Threshold{Offset: 70}).Resolve(feature)
+	require.True(t, ok)
+	require.InDelta(t, 70.0, got, 0.001)
+
+	got, ok = (&Threshold{Limit: sensors.SubfeatureMax, Factor: 0.85, Offset: 1}).Resolve(feature)
+	require.True(t, ok)
+	require.InDelta(t, 86.0, got, 0.001)
+
+	_, ok = (&Threshold{Limit: sensors.SubfeatureCrit}).Resolve(feature)
+	require.False(t, ok)
+}
+
+func TestParse_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name: "unknown keys",
+			input: `
+poll_intreval: 1s
+rules:
+  - match: "*/*"
+    critical_temp: 90
+`,
+			want: []string{
+				`line 2: unknown field "poll_intreval" in config`,
+				`line 5: unknown field "critical_temp" in rule`,
+			},
+		},
+		{
+			name: "invalid duration",
+			input: `
+poll_interval: soon
+`,
+			want: []string{
+				`line 2: invalid duration "soon"`,
+			},
+		},
+		{
+			name: "wrong type",
+			input: `
+rules:
+  - alarms: often
+`,
+			want: []string{
+				"line 3: cannot unmarshal !!str `often` into bool",
+			},
+		},
+		{
+			name: "invalid values",
+			input: `
+poll_interval: 0s
+sources:
+  - type: ipmi
+rules:
+  - match: "[coretemp"
+    kinds: [temp, pressure]
+    warn: max
+    critical: max-5
+    for: -5s
+    repeat_interval: -1m
+  - warn_below: min
+    clear: min-10
+  - clear: 80
+notifiers:
+  - type: desktop
+  - type: carrier-pigeon
+    name: desktop
+    min_severity: info
+    rate_limit:
+      count: 0
+`,
+			want: []string{
+				"line 2: poll_interval: must be greater than zero",
+				`line 4: sources[0].type: unknown source type "ipmi", must be one of "auto", "hwmon", "thermal", "lm-sensors"`,
+				`line 6: rules[0].match: invalid chip pattern "[coretemp"`,
+				`line 7: rules[0].kinds[1]: unknown sensor kind "pressure", must be one of "temp", "fan", "in", "curr", "power", "energy", "humidity", "cooling"`,
+				"line 8: rules[0].warn: must not be above the critical threshold",
+				"line 10: rules[0].for: must not be negative",
+				"line 11: rules[0].repeat_interval: must not be negative",
+				"line 13: rules[1].clear: must not be below the warn_below threshold",
+				"line 14: rules[2].clear: requires a warn, critical, warn_below or critical_below threshold",
+				`line 17: notifiers[1].type: unknown notifier type "carrier-pigeon", must be one of ` + joinQuoted(notifierTypes),
+				`line 18: notifiers[1].name: duplicate notifier name "desktop"`,
+				`line 19: notifiers[1].min_severity: unknown severity "info", must be one of "warning", "critical"`,
+				"line 21: notifiers[1].rate_limit.count: must be greater than zero",
+				"line 21: notifiers[1].rate_limit.per: must be greater than zero",
+			},
+		},
+		{
+			name: "invalid timeouts",
+			input: `
+read_timeout: 0s
+shutdown_timeout: -1s
+delivery_timeout: 0s
+`,
+			want: []string{
+				"line 2: read_timeout: must be greater than zero",
+				"line 3: shutdown_timeout: must be greater than zero",
+				"line 4: delivery_timeout: must be greater than zero",
+			},
+		},
+		{
+			name: "invalid webhook",
+			input: `
+notifiers:
+  - type: webhook
+  - name: relative
+    type: webhook
+    webhook:
+      url: /alerts
+      timeout: 0s
+      retries: -1
+`,
+			want: []string{
+				"line 3: notifiers[0].webhook: required for webhook notifiers",
+				`line 7: notifiers[1].webhook.url: invalid URL "/alerts", must be an absolute http or https URL`,
+				"line 8: notifiers[1].webhook.timeout: must be greater than zero",
+				"line 9: notifiers[1].webhook.retries: must not be negative",
+			},
+		},
+		{
+			name: "invalid email",
+			input: `
+notifiers:
+  - type: email
+    email:
+      tls: ssl
+      password: hunter2
+      from: monitor
+      to: [ops@example.com, "not an address"]
+      digest: -1m
+`,
+			want: []string{
+				"line 5: notifiers[0].email.host: required",
+				`line 5: notifiers[0].email.tls: unknown TLS mode "ssl", must be one of "starttls", "implicit", "none"`,
+				"line 5: notifiers[0].email.username: required when a password is given",
+				`line 7: notifiers[0].email.from: invalid address "monitor"`,
+				`line 8: notifiers[0].email.to[1]: invalid address "not an address"`,
+				"line 9: notifiers[0].email.digest: must not be negative",
+			},
+		},
+		{
+			name: "invalid chat notifiers",
+			input: `
+notifiers:
+  - type: slack
+    slack:
+      token: xoxb-token
+  - name: both
+    type: slack
+    slack:
+      webhook_url: https://hooks.slack.com/services/T000/B000/XXX
+      token: xoxb-token
+  - type: discord
+    discord: {}
+  - type: teams
+  - type: matrix
+    matrix:
+      homeserver: matrix.example.com
+      room_id: "#alerts:example.com"
+`,
+			want: []string{
+				"line 5: notifiers[0].slack.channel: required with token",
+				"line 10: notifiers[1].slack.token: must not be given with webhook_url",
+				"line 12: notifiers[2].discord.webhook_url: required",
+				"line 13: notifiers[3].teams: required for teams notifiers",
+				`line 16: notifiers[4].matrix.homeserver: invalid URL "matrix.example.com", must be an absolute http or https URL`,
+				"line 16: notifiers[4].matrix.access_token: required",
+				`line 17: notifiers[4].matrix.room_id: invalid room ID "#alerts:example.com", must start with "!"`,
+			},
+		},
+		{
+			name: "invalid push notifiers",
+			input: `
+notifiers:
+  - type: ntfy
+    ntfy:
+      url: https://ntfy.sh/alerts
+      priority:
+        critical: 6
+  - type: gotify
+    gotify:
+      url: https://gotify.example.com
+  - type: pushover
+    pushover:
+      token: app-token
+      user_key: user-key
+      priority:
+        resolved: -3
+      retry: 10s
+      expire: 4h
+  - type: telegram
+    telegram:
+      token: "123:secret"
+      silent: [warning, info]
+`,
+			want: []string{
+				"line 7: notifiers[0].ntfy.priority.critical: must be between 1 and 5",
+				"line 10: notifiers[1].gotify.token: required",
+				"line 16: notifiers[2].pushover.priority.resolved: must be between -2 and 2",
+				"line 17: notifiers[2].pushover.retry: must be at least 30s",
+				"line 18: notifiers[2].pushover.expire: must be greater than zero and at most 3h0m0s",
+				"line 21: notifiers[3].telegram.chat_id: required",
+				`line 22: notifiers[3].telegram.silent[1]: unknown level "info", must be one of "warning", "critical", "resolved"`,
+			},
+		},
+		{
+			name: "invalid incident notifiers",
+			input: `
+notifiers:
+  - type: pagerduty
+    pagerduty:
+      url: events.pagerduty.com
+  - type: opsgenie
+    opsgenie:
+      api_key: api-key
+      priority:
+        critical: P0
+`,
+			want: []string{
+				"line 5: notifiers[0].pagerduty.routing_key: required",
+				`line 5: notifiers[0].pagerduty.url: invalid URL "events.pagerduty.com", must be an absolute http or https URL`,
+				`line 10: notifiers[1].opsgenie.priority.critical: unknown priority "P0", must be one of "P1", "P2", "P3", "P4", "P5"`,
+			},
+		},
+		{
+			name: "invalid alertmanager",
+			input: `
+notifiers:
+  - type: alertmanager
+    alertmanager:
+      urls: []
+      resend_interval: 0s
+  - name: labelled
+    type: alertmanager
+    alertmanager:
+      urls: [alertmanager:9093]
+      labels:
+        build-farm: render
+      password: hunter2
+`,
+			want: []string{
+				"line 5: notifiers[0].alertmanager.urls: at least one URL is required",
+				"line 6: notifiers[0].alertmanager.resend_interval: must be greater than zero",
+				`line 10: notifiers[1].alertmanager.urls[0]: invalid URL "alertmanager:9093", must be an absolute http or https URL`,
+				"line 10: notifiers[1].alertmanager.username: required when a password is given",
+				`line 12: notifiers[1].alertmanager.labels.build-farm: invalid label name "build-farm"`,
+			},
+		},
+		{
+			name: "invalid metrics",
+			input: `
+metrics:
+  listen: "9102"
+  path: metrics
+`,
+			want: []string{
+				`line 3: metrics.listen: invalid address "9102", must be a host and port such as ":9102"`,
+				`line 4: metrics.path: must start with "/"`,
+			},
+		},
+		{
+			name: "invalid outputs",
+			input: `
+outputs:
+  - type: influxdb
+    flush_interval: 0s
+    influxdb:
+      url: http://influxdb:8086
+      bucket: sensors
+  - type: influxdb
+    buffer_size: 0
+    influxdb:
+      url: influxdb:8086
+      version: 3
+  - name: legacy
+    type: influxdb
+    influxdb:
+      url: http://influxdb:8086
+      version: 1
+      password: hunter2
+  - type: graphite
+    graphite:
+      address: graphite
+      protocol: sctp
+  - type: opentsdb
+  - type: otlp
+    otlp:
+      url: otel-collector:4318
+      encoding: grpc
+  - name: collector
+    type: otlp
+`,
+			want: []string{
+				"line 4: outputs[0].flush_interval: must be greater than zero",
+				"line 6: outputs[0].influxdb.org: required for version 2",
+				"line 6: outputs[0].influxdb.token: required for version 2",
+				`line 8: outputs[1].name: duplicate output name "influxdb"`,
+				"line 9: outputs[1].buffer_size: must be greater than zero",
+				`line 11: outputs[1].influxdb.url: invalid URL "influxdb:8086", must be an absolute http or https URL`,
+				"line 12: outputs[1].influxdb.version: unknown version 3, must be 1 or 2",
+				"line 16: outputs[2].influxdb.database: required for version 1",
+				"line 16: outputs[2].influxdb.username: required when a password is given",
+				`line 21: outputs[3].graphite.address: invalid address "graphite", must be a host and port such as "graphite:2003"`,
+				`line 22: outputs[3].graphite.protocol: unknown protocol "sctp", must be one of "tcp", "udp"`,
+				`line 23: outputs[4].type: unknown output type "opentsdb", must be one of "influxdb", "graphite", "otlp", "mqtt"`,
+				`line 26: outputs[5].otlp.url: invalid URL "otel-collector:4318", must be an absolute http or https URL`,
+				`line 27: outputs[5].otlp.encoding: unknown encoding "grpc", must be one of "protobuf", "json"`,
+				`line 28: outputs[6].otlp: required for otlp outputs`,
+			},
+		},
+		{
+			name: "invalid mqtt",
+			input: `
+outputs:
+  - type: mqtt
+    mqtt:
+      broker: http://broker:1883
+      version: "3"
+      password: hunter2
+      qos: 3
+      topic_prefix: sensors/+
+      keep_alive: 0s
+      tls:
+        cert_file: client.pem
+  - name: local
+    type: mqtt
+    mqtt:
+      broker: mqtt://broker
+`,
+			want: []string{
+				`line 5: outputs[0].mqtt.broker: unknown scheme "http", must be one of "mqtt", "mqtts", "ssl", "tcp", "tls"`,
+				`line 5: outputs[0].mqtt.username: required when a password is given`,
+				`line 6: outputs[0].mqtt.version: unknown version "3", must be one of "3.1.1", "5"`,
+				"line 8: outputs[0].mqtt.qos: must be 0, 1 or 2",
+				"line 9: outputs[0].mqtt.topic_prefix: must be a topic without wildcards",
+				"line 10: outputs[0].mqtt.keep_alive: must be between 1s and 18h12m15s",
+				"line 12: outputs[0].mqtt.tls.cert_file: cert_file and key_file must be given together",
+				`line 16: outputs[1].mqtt.broker: invalid URL "mqtt://broker", must be a URL with a host and port such as "mqtt://broker:1883"`,
+			},
+		},
+		{
+			name: "invalid history",
+			input: `
+history:
+  path: history.db
+  flush_interval: 0s
+  batch_size: -1
+  retention:
+    readings: 30s
+    minute: 30m
+    hour: 10m
+    events: 0s
+`,
+			want: []string{
+				"line 4: history.flush_interval: must be greater than zero",
+				"line 5: history.batch_size: must be greater than zero",
+				"line 7: history.retention.readings: must be at least 1m",
+				"line 8: history.retention.minute: must be at least 1h and at least the retention of readings",
+				"line 9: history.retention.hour: must be at least the retention of minute rollups",
+				"line 10: history.retention.events: must be greater than zero",
+			},
+		},
+		{
+			name: "invalid health",
+			input: `
+health:
+  backoff: 10s
+  max_backoff: 5s
+  degraded_after: 0
+`,
+			want: []string{
+				"line 4: health.max_backoff: must not be less than the backoff",
+				"line 5: health.degraded_after: must be greater than zero",
+			},
+		},
+		{
+			name: "invalid fan",
+			input: `
+fans:
+  - match: "dell_smm-*/fan["
+    temperature: "coretemp-["
+    rise: -1
+    rise_window: 0s
+    pinned_for: -1m
+`,
+			want: []string{
+				`line 3: fans[0].match: invalid feature pattern "fan["`,
+				`line 4: fans[0].temperature: invalid chip pattern "coretemp-["`,
+				"line 5: fans[0].rise: must not be negative",
+				"line 6: fans[0].rise_window: must be greater than zero",
+				"line 7: fans[0].pinned_for: must not be negative",
+			},
+		},
+		{
+			name: "invalid watchdog",
+			input: `
+watchdog:
+  expect:
+    - "nvme-[/Composite"
+  missing_for: -1s
+  stale_kinds: [temperature]
+  ranges:
+    temp:
+      min: 100
+      max: 0
+    rpm:
+      max: 10
+  severity: page
+`,
+			want: []string{
+				`line 4: watchdog.expect[0]: invalid chip pattern "nvme-["`,
+				"line 5: watchdog.missing_for: must not be negative",
+				`line 6: watchdog.stale_kinds[0]: unknown sensor kind "temperature", must be one of "temp", "fan", "in", "curr", "power", "energy", "humidity", "cooling"`,
+				"line 9: watchdog.ranges.temp.min: must not be above the max",
+				`line 12: watchdog.ranges.rpm: unknown sensor kind "rpm", must be one of "temp", "fan", "in", "curr", "power", "energy", "humidity", "cooling"`,
+				`line 13: watchdog.severity: unknown severity "page", must be one of "warning", "critical"`,
+			},
+		},
+		{
+			name: "invalid threshold",
+			input: `
+rules:
+  - warn: maximum-5
+`,
+			want: []string{
+				`line 3: unknown hardware limit "maximum" in threshold "maximum-5", must be one of "min", "max", "crit", "lcrit", "emergency", "passive", "hot"`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Parse([]byte(test.input))
+
+			var cfgErr *Error
+			require.ErrorAs(t, err, &cfgErr)
+			require.Equal(t, test.want, cfgErr.Problems)
+		})
+	}
+}
+
+func TestParse_Syntax(t *testing.T) {
+	t.Parallel()
+
+	_, err := Parse([]byte("rules: [\n"))
+	require.ErrorContains(t, err, "line")
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "sensor-monitor.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("poll_interval: 1s\n"), 0o600))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, time.Second, cfg.PollInterval.Std())
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
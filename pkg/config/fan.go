@@ -0,0 +1,136 @@
+package config
+
+import (
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+const (
+	// DefaultFanRise is how much the paired temperature must rise within the rise window for a slow fan to be alerted
+	// on.
+	DefaultFanRise = 2.0
+
+	// DefaultFanRiseWindow is how far back the rise of the paired temperature is measured.
+	DefaultFanRiseWindow = Duration(time.Minute)
+
+	// DefaultFanStoppedAbove is the paired temperature in Celsius at or above which a stopped fan is alerted on.
+	DefaultFanStoppedAbove = 60.0
+
+	// DefaultFanPinnedFor is how long a fan must run at its maximum speed before it is alerted on.
+	DefaultFanPinnedFor = Duration(10 * time.Minute)
+
+	// DefaultFanFor is how long a fan must be slow or stopped before it is alerted on.
+	DefaultFanFor = Duration(10 * time.Second)
+)
+
+// Fan decides when the fans it matches are alerted on as stalled or failing. Each fan is paired with the temperature
+// sensor it cools, so that a fan is only alerted on as slow or stopped when it matters.
+type Fan struct {
+	// Name identifies the fan rule. The alerts it raises are named after it, e.g. "fan-stopped".
+	Name string `yaml:"name"`
+
+	// Match is a glob matched against the fan in the form "chip/feature", e.g. "dell_smm-*/fan1". Only fans are
+	// matched.
+	Match string `yaml:"match"`
+
+	// Temperature is a glob in the same form as Match of the temperature sensors paired with the fan, e.g.
+	// "coretemp-*/Package id 0". When it is empty the temperatures on the chip of the fan are paired. The hottest of
+	// the paired sensors is used.
+	Temperature string `yaml:"temperature"`
+
+	// Min is the speed below which a fan is slow. A slow fan is alerted on while the paired temperature is rising, and
+	// until it is back up to speed.
+	Min *Threshold `yaml:"min,omitempty"`
+
+	// Rise is how much the paired temperature must rise within the RiseWindow to count as rising.
+	Rise float64 `yaml:"rise"`
+
+	// RiseWindow is how far back the rise of the paired temperature is measured.
+	RiseWindow Duration `yaml:"rise_window"`
+
+	// StoppedAbove is the paired temperature at or above which a fan at 0 RPM is alerted on as stopped. It is resolved
+	// against the paired sensor, e.g. "crit*0.7".
+	StoppedAbove *Threshold `yaml:"stopped_above,omitempty"`
+
+	// Pinned is the speed at or above which a fan is running flat out.
+	Pinned *Threshold `yaml:"pinned,omitempty"`
+
+	// PinnedFor is how long a fan must run flat out before it is alerted on. Zero never alerts on pinned fans.
+	PinnedFor Duration `yaml:"pinned_for"`
+
+	// For is how long a fan must be slow or stopped before it is alerted on, so that a fan spinning up is not.
+	For Duration `yaml:"for"`
+}
+
+// UnmarshalYAML decodes a fan rule, filling in defaults for anything not given.
+func (f *Fan) UnmarshalYAML(node *yaml.Node) error {
+	type plain Fan
+	p := plain(*DefaultFan())
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*f = Fan(p)
+	return nil
+}
+
+// DefaultFan returns the defaults of a fan rule. Fans are slow below the minimum speed reported by the hardware,
+// stopped at 0 RPM with their temperature at or above 60°C, and pinned at 95% of their maximum speed.
+func DefaultFan() *Fan {
+	return &Fan{
+		Name:         "fan",
+		Match:        DefaultMatch,
+		Min:          &Threshold{Limit: sensors.SubfeatureMin},
+		Rise:         DefaultFanRise,
+		RiseWindow:   DefaultFanRiseWindow,
+		StoppedAbove: &Threshold{Offset: DefaultFanStoppedAbove},
+		Pinned:       &Threshold{Limit: sensors.SubfeatureMax, Factor: 0.95},
+		PinnedFor:    DefaultFanPinnedFor,
+		For:          DefaultFanFor,
+	}
+}
+
+// Matches reports whether the fan rule applies to the given reading.
+func (f *Fan) Matches(reading *sensors.Reading) bool {
+	return reading.Kind == sensors.KindFan && (&Rule{Match: f.Match}).Matches(reading)
+}
+
+// Pairs reports whether the temperature reading is paired with the fan reading.
+func (f *Fan) Pairs(fan, temperature *sensors.Reading) bool {
+	if temperature.Kind != sensors.KindTemperature {
+		return false
+	}
+
+	if f.Temperature == "" {
+		return temperature.Chip == fan.Chip
+	}
+
+	return (&Rule{Match: f.Temperature}).Matches(temperature)
+}
+
+// validate checks the fan rule.
+func (f *Fan) validate(at func(keys ...any) []any, add problemFunc) {
+	validateMatch(f.Match, at("match"), add)
+	if f.Temperature != "" {
+		validateMatch(f.Temperature, at("temperature"), add)
+	}
+
+	if f.Rise < 0 {
+		add(at("rise"), "must not be negative")
+	}
+
+	if f.RiseWindow <= 0 {
+		add(at("rise_window"), "must be greater than zero")
+	}
+
+	if f.PinnedFor < 0 {
+		add(at("pinned_for"), "must not be negative")
+	}
+
+	if f.For < 0 {
+		add(at("for"), "must not be negative")
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"time"
+)
+
+const (
+	// DefaultHealthBackoff is how long a source that failed to read is left before it is read again.
+	DefaultHealthBackoff = Duration(time.Second)
+
+	// DefaultHealthMaxBackoff is the longest a failing source is left before it is read again.
+	DefaultHealthMaxBackoff = Duration(time.Minute)
+
+	// DefaultHealthDegradedAfter is how many reads of a source in a row must fail to raise the monitor degraded alert.
+	DefaultHealthDegradedAfter = 3
+)
+
+// Health is the configuration of how the monitor copes with sources that fail to read.
+type Health struct {
+	// Backoff is how long a source that failed to read is left before it is read again. It doubles with every failure
+	// in a row, up to MaxBackoff.
+	Backoff Duration `yaml:"backoff"`
+
+	// MaxBackoff is the longest a failing source is left before it is read again.
+	MaxBackoff Duration `yaml:"max_backoff"`
+
+	// DegradedAfter is how many reads of a source in a row must fail to raise a critical alert that the monitor is
+	// degraded. The alert resolves once the source is read again.
+	DegradedAfter int `yaml:"degraded_after"`
+}
+
+// DefaultHealth returns the defaults of the health settings.
+func DefaultHealth() Health {
+	return Health{
+		Backoff:       DefaultHealthBackoff,
+		MaxBackoff:    DefaultHealthMaxBackoff,
+		DegradedAfter: DefaultHealthDegradedAfter,
+	}
+}
+
+// validate checks the health settings.
+func (h *Health) validate(at func(keys ...any) []any, add problemFunc) {
+	if h.Backoff <= 0 {
+		add(at("backoff"), "must be greater than zero")
+	}
+
+	if h.MaxBackoff < h.Backoff {
+		add(at("max_backoff"), "must not be less than the backoff")
+	}
+
+	if h.DegradedAfter <= 0 {
+		add(at("degraded_after"), "must be greater than zero")
+	}
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"time"
+)
+
+const (
+	// DefaultHistoryFlushInterval is how often buffered readings are written to the history store.
+	DefaultHistoryFlushInterval = Duration(10 * time.Second)
+
+	// DefaultHistoryBatchSize is how many rows are buffered before they are written regardless of the flush interval.
+	DefaultHistoryBatchSize = 5000
+
+	// DefaultHistoryRollupInterval is how often old readings are rolled up and expired data is deleted.
+	DefaultHistoryRollupInterval = Duration(time.Minute)
+
+	// DefaultHistoryReadingsRetention is how long every reading is kept.
+	DefaultHistoryReadingsRetention = Duration(24 * time.Hour)
+
+	// DefaultHistoryMinuteRetention is how long 1 minute rollups are kept.
+	DefaultHistoryMinuteRetention = Duration(7 * 24 * time.Hour)
+
+	// DefaultHistoryHourRetention is how long 1 hour rollups are kept.
+	DefaultHistoryHourRetention = Duration(365 * 24 * time.Hour)
+
+	// DefaultHistoryEventsRetention is how long alert transitions and notifications are kept.
+	DefaultHistoryEventsRetention = Duration(90 * 24 * time.Hour)
+)
+
+// History is the configuration of the local history store.
+type History struct {
+	// Path is the SQLite database the history is kept in, e.g. "/var/lib/sensor-monitor/history.db". No history is
+	// kept when it is empty.
+	Path string `yaml:"path"`
+
+	// FlushInterval is how often buffered readings, alert transitions and notifications are written.
+	FlushInterval Duration `yaml:"flush_interval"`
+
+	// BatchSize is how many rows are buffered before they are written regardless of the flush interval.
+	BatchSize int `yaml:"batch_size"`
+
+	// RollupInterval is how often readings are rolled up into 1 minute and 1 hour rollups, and expired data deleted.
+	RollupInterval Duration `yaml:"rollup_interval"`
+
+	// Retention is how long each kind of data is kept.
+	Retention HistoryRetention `yaml:"retention"`
+}
+
+// HistoryRetention is how long each kind of data in the history store is kept.
+type HistoryRetention struct {
+	// Readings is how long every reading is kept. Older readings only survive in the rollups.
+	Readings Duration `yaml:"readings"`
+
+	// Minute is how long the minimum, average and maximum of every sensor for each minute are kept.
+	Minute Duration `yaml:"minute"`
+
+	// Hour is how long the minimum, average and maximum of every sensor for each hour are kept.
+	Hour Duration `yaml:"hour"`
+
+	// Events is how long alert transitions and notifications are kept.
+	Events Duration `yaml:"events"`
+}
+
+// DefaultHistory returns the defaults of the history store.
+func DefaultHistory() History {
+	return History{
+		FlushInterval:  DefaultHistoryFlushInterval,
+		BatchSize:      DefaultHistoryBatchSize,
+		RollupInterval: DefaultHistoryRollupInterval,
+		Retention: HistoryRetention{
+			Readings: DefaultHistoryReadingsRetention,
+			Minute:   DefaultHistoryMinuteRetention,
+			Hour:     DefaultHistoryHourRetention,
+			Events:   DefaultHistoryEventsRetention,
+		},
+	}
+}
+
+// validate checks the history settings.
+func (h *History) validate(at func(keys ...any) []any, add problemFunc) {
+	if h.FlushInterval <= 0 {
+		add(at("flush_interval"), "must be greater than zero")
+	}
+
+	if h.BatchSize <= 0 {
+		add(at("batch_size"), "must be greater than zero")
+	}
+
+	if h.RollupInterval <= 0 {
+		add(at("rollup_interval"), "must be greater than zero")
+	}
+
+	// Readings are rolled up once their minute has passed, and minute rollups once their hour has, so nothing is
+	// deleted before it has been rolled up.
+	if h.Retention.Readings < Duration(time.Minute) {
+		add(at("retention", "readings"), "must be at least 1m")
+	}
+
+	if h.Retention.Minute < max(h.Retention.Readings, Duration(time.Hour)) {
+		add(at("retention", "minute"), "must be at least 1h and at least the retention of readings")
+	}
+
+	if h.Retention.Hour < h.Retention.Minute {
+		add(at("retention", "hour"), "must be at least the retention of minute rollups")
+	}
+
+	if h.Retention.Events <= 0 {
+		add(at("retention", "events"), "must be greater than zero")
+	}
+}
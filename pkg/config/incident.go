@@ -0,0 +1,128 @@
+package config
+
+import (
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// opsgeniePriorities is every valid Opsgenie priority, from highest to lowest.
+var opsgeniePriorities = []string{"P1", "P2", "P3", "P4", "P5"}
+
+// PagerDuty is the configuration of a PagerDuty notifier, sending events to the Events API v2.
+type PagerDuty struct {
+	// RoutingKey is the integration key of the service events are sent to.
+	RoutingKey string `yaml:"routing_key"`
+
+	// URL is the endpoint of the Events API.
+	URL string `yaml:"url"`
+
+	// AutoResolve resolves the incident of an alert once it recovers. Otherwise the incident is acknowledged, leaving
+	// on-call to resolve it.
+	AutoResolve bool `yaml:"auto_resolve"`
+
+	HTTPOptions `yaml:",inline"`
+}
+
+// UnmarshalYAML decodes a PagerDuty notifier, filling in defaults for anything not given.
+func (p *PagerDuty) UnmarshalYAML(node *yaml.Node) error {
+	type plain PagerDuty
+	pl := plain(*DefaultPagerDuty())
+	if err := node.Decode(&pl); err != nil {
+		return err
+	}
+
+	*p = PagerDuty(pl)
+	return nil
+}
+
+// DefaultPagerDuty returns the defaults of a PagerDuty notifier.
+func DefaultPagerDuty() *PagerDuty {
+	return &PagerDuty{
+		URL:         "https://events.pagerduty.com/v2/enqueue",
+		AutoResolve: true,
+		HTTPOptions: DefaultHTTPOptions(),
+	}
+}
+
+// validate checks the PagerDuty settings.
+func (p *PagerDuty) validate(at func(keys ...any) []any, add problemFunc) {
+	if p.RoutingKey == "" {
+		add(at("routing_key"), "required")
+	}
+
+	validateURL(at("url"), p.URL, add)
+	p.HTTPOptions.validate(at, add)
+}
+
+// OpsgeniePriorities maps the severities of alerts to Opsgenie priorities.
+type OpsgeniePriorities struct {
+	// Warning is the priority of warnings.
+	Warning string `yaml:"warning"`
+
+	// Critical is the priority of critical alerts.
+	Critical string `yaml:"critical"`
+}
+
+// Opsgenie is the configuration of an Opsgenie notifier.
+type Opsgenie struct {
+	// APIKey is the key of the API integration alerts are created with.
+	APIKey string `yaml:"api_key"`
+
+	// APIURL is the base URL of the Opsgenie API, "https://api.eu.opsgenie.com" for accounts in the EU.
+	APIURL string `yaml:"api_url"`
+
+	// Priority maps alerts to Opsgenie priorities, from P1 (highest) to P5.
+	Priority OpsgeniePriorities `yaml:"priority"`
+
+	// Tags are added to every alert.
+	Tags []string `yaml:"tags,omitempty"`
+
+	// AutoResolve closes the Opsgenie alert of an alert once it recovers. Otherwise the Opsgenie alert is acknowledged,
+	// leaving on-call to close it.
+	AutoResolve bool `yaml:"auto_resolve"`
+
+	HTTPOptions `yaml:",inline"`
+}
+
+// UnmarshalYAML decodes an Opsgenie notifier, filling in defaults for anything not given.
+func (o *Opsgenie) UnmarshalYAML(node *yaml.Node) error {
+	type plain Opsgenie
+	p := plain(*DefaultOpsgenie())
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*o = Opsgenie(p)
+	return nil
+}
+
+// DefaultOpsgenie returns the defaults of an Opsgenie notifier.
+func DefaultOpsgenie() *Opsgenie {
+	return &Opsgenie{
+		APIURL:      "https://api.opsgenie.com",
+		Priority:    OpsgeniePriorities{Warning: "P3", Critical: "P1"},
+		AutoResolve: true,
+		HTTPOptions: DefaultHTTPOptions(),
+	}
+}
+
+// validate checks the Opsgenie settings.
+func (o *Opsgenie) validate(at func(keys ...any) []any, add problemFunc) {
+	if o.APIKey == "" {
+		add(at("api_key"), "required")
+	}
+
+	validateURL(at("api_url"), o.APIURL, add)
+
+	validateOpsgeniePriority(at("priority", "warning"), o.Priority.Warning, add)
+	validateOpsgeniePriority(at("priority", "critical"), o.Priority.Critical, add)
+	o.HTTPOptions.validate(at, add)
+}
+
+// validateOpsgeniePriority checks that the value at path is an Opsgenie priority.
+func validateOpsgeniePriority(path []any, value string, add problemFunc) {
+	if !slices.Contains(opsgeniePriorities, value) {
+		add(path, "unknown priority %q, must be one of %s", value, joinQuoted(opsgeniePriorities))
+	}
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MQTTVersion is the version of the MQTT protocol spoken to the broker.
+type MQTTVersion string
+
+const (
+	// MQTTVersion311 is MQTT 3.1.1.
+	MQTTVersion311 MQTTVersion = "3.1.1"
+
+	// MQTTVersion5 is MQTT 5.
+	MQTTVersion5 MQTTVersion = "5"
+)
+
+// mqttVersions is every valid MQTT version.
+var mqttVersions = []MQTTVersion{
+	MQTTVersion311,
+	MQTTVersion5,
+}
+
+// mqttSchemes are the schemes of broker URLs, and whether they connect over TLS.
+var mqttSchemes = map[string]bool{
+	"mqtt":  false,
+	"tcp":   false,
+	"mqtts": true,
+	"ssl":   true,
+	"tls":   true,
+}
+
+const (
+	// DefaultMQTTTopicPrefix is the first level of the topics readings are published to.
+	DefaultMQTTTopicPrefix = "sensor-monitor"
+
+	// DefaultMQTTDiscoveryPrefix is the topic prefix Home Assistant discovers entities under.
+	DefaultMQTTDiscoveryPrefix = "homeassistant"
+
+	// DefaultMQTTKeepAlive is the longest the connection to the broker is left idle.
+	DefaultMQTTKeepAlive = Duration(30 * time.Second)
+)
+
+// MQTT is the configuration of an MQTT output.
+type MQTT struct {
+	// Broker is the URL of the broker, e.g. "mqtt://broker:1883", or "mqtts://broker:8883" to connect over TLS.
+	Broker string `yaml:"broker"`
+
+	// Version is the version of the MQTT protocol.
+	Version MQTTVersion `yaml:"version"`
+
+	// ClientID identifies the monitor to the broker. It defaults to "sensor-monitor-<host>".
+	ClientID string `yaml:"client_id,omitempty"`
+
+	// Username and Password authenticate with the broker when given.
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+
+	// QoS is the quality of service readings are published with: 0, 1 or 2.
+	QoS int `yaml:"qos"`
+
+	// Retain publishes readings as retained messages, so that subscribers get the latest reading straight away.
+	Retain bool `yaml:"retain"`
+
+	// TopicPrefix is the first level of the topics readings are published to, <prefix>/<host>/<chip>/<feature>. The
+	// availability of the monitor is published to <prefix>/<host>/status.
+	TopicPrefix string `yaml:"topic_prefix"`
+
+	// Discovery publishes Home Assistant discovery configs, so that every sensor is an entity of a device for the host.
+	Discovery bool `yaml:"discovery"`
+
+	// DiscoveryPrefix is the topic prefix Home Assistant discovers entities under.
+	DiscoveryPrefix string `yaml:"discovery_prefix"`
+
+	// KeepAlive is the longest the connection is left idle. The broker publishes the monitor as offline once it has
+	// heard nothing for one and a half times this long.
+	KeepAlive Duration `yaml:"keep_alive"`
+
+	// Timeout is how long connecting and publishing may take.
+	Timeout Duration `yaml:"timeout"`
+
+	// TLS configures the TLS connection to a broker with an mqtts URL.
+	TLS TLS `yaml:"tls"`
+}
+
+// TLS is the configuration of a TLS connection.
+type TLS struct {
+	// CAFile is a PEM file of the certificate authorities trusted to sign the certificate of the server. The system
+	// roots are trusted when it is empty.
+	CAFile string `yaml:"ca_file,omitempty"`
+
+	// CertFile and KeyFile are the PEM files of a client certificate, for servers that require one.
+	CertFile string `yaml:"cert_file,omitempty"`
+	KeyFile  string `yaml:"key_file,omitempty"`
+
+	// InsecureSkipVerify accepts any certificate of the server. Only use it for testing.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify,omitempty"`
+}
+
+// UnmarshalYAML decodes an MQTT output, filling in defaults for anything not given.
+func (m *MQTT) UnmarshalYAML(node *yaml.Node) error {
+	type plain MQTT
+	p := plain(*DefaultMQTT())
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*m = MQTT(p)
+	return nil
+}
+
+// DefaultMQTT returns the defaults of an MQTT output.
+func DefaultMQTT() *MQTT {
+	return &MQTT{
+		Version:         MQTTVersion311,
+		QoS:             1,
+		Retain:          true,
+		TopicPrefix:     DefaultMQTTTopicPrefix,
+		Discovery:       true,
+		DiscoveryPrefix: DefaultMQTTDiscoveryPrefix,
+		KeepAlive:       DefaultMQTTKeepAlive,
+		Timeout:         DefaultHTTPTimeout,
+	}
+}
+
+// UseTLS reports whether the broker is connected to over TLS.
+func (m *MQTT) UseTLS() bool {
+	u, err := url.Parse(m.Broker)
+	return err == nil && mqttSchemes[u.Scheme]
+}
+
+// validate checks the MQTT settings.
+func (m *MQTT) validate(at func(keys ...any) []any, add problemFunc) {
+	if m.Broker == "" {
+		add(at("broker"), "required")
+	} else if u, err := url.Parse(m.Broker); err != nil || u.Host == "" || u.Port() == "" {
+		add(at("broker"), "invalid URL %q, must be a URL with a host and port such as \"mqtt://broker:1883\"", m.Broker)
+	} else if _, ok := mqttSchemes[u.Scheme]; !ok {
+		schemes := slices.Sorted(maps.Keys(mqttSchemes))
+		add(at("broker"), "unknown scheme %q, must be one of %s", u.Scheme, joinQuoted(schemes))
+	}
+
+	if !slices.Contains(mqttVersions, m.Version) {
+		add(at("version"), "unknown version %q, must be one of %s", m.Version, joinQuoted(mqttVersions))
+	}
+
+	if m.Password != "" && m.Username == "" {
+		add(at("username"), "required when a password is given")
+	}
+
+	if m.QoS < 0 || m.QoS > 2 {
+		add(at("qos"), "must be 0, 1 or 2")
+	}
+
+	if m.TopicPrefix == "" || strings.ContainsAny(m.TopicPrefix, "+#") {
+		add(at("topic_prefix"), "must be a topic without wildcards")
+	}
+
+	if m.Discovery && (m.DiscoveryPrefix == "" || strings.ContainsAny(m.DiscoveryPrefix, "+#")) {
+		add(at("discovery_prefix"), "must be a topic without wildcards")
+	}
+
+	if m.KeepAlive < Duration(time.Second) || m.KeepAlive > Duration(65535*time.Second) {
+		add(at("keep_alive"), "must be between 1s and 18h12m15s")
+	}
+
+	if m.Timeout <= 0 {
+		add(at("timeout"), "must be greater than zero")
+	}
+
+	if (m.TLS.CertFile == "") != (m.TLS.KeyFile == "") {
+		add(at("tls", "cert_file"), "cert_file and key_file must be given together")
+	}
+}
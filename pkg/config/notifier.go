@@ -0,0 +1,676 @@
+package config
+
+import (
+	"net"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NotifierType is the type of a notifier.
+type NotifierType string
+
+const (
+	// NotifierDesktop sends desktop notifications.
+	NotifierDesktop NotifierType = "desktop"
+
+	// NotifierWebhook sends an HTTP request for every alert.
+	NotifierWebhook NotifierType = "webhook"
+
+	// NotifierEmail sends email over SMTP.
+	NotifierEmail NotifierType = "email"
+
+	// NotifierSlack posts to Slack.
+	NotifierSlack NotifierType = "slack"
+
+	// NotifierDiscord posts to a Discord webhook.
+	NotifierDiscord NotifierType = "discord"
+
+	// NotifierTeams posts to a Microsoft Teams webhook.
+	NotifierTeams NotifierType = "teams"
+
+	// NotifierMatrix posts to a Matrix room.
+	NotifierMatrix NotifierType = "matrix"
+
+	// NotifierNtfy publishes to an ntfy topic.
+	NotifierNtfy NotifierType = "ntfy"
+
+	// NotifierGotify sends messages to a Gotify server.
+	NotifierGotify NotifierType = "gotify"
+
+	// NotifierPushover sends Pushover notifications.
+	NotifierPushover NotifierType = "pushover"
+
+	// NotifierTelegram sends messages with a Telegram bot.
+	NotifierTelegram NotifierType = "telegram"
+
+	// NotifierPagerDuty sends events to PagerDuty.
+	NotifierPagerDuty NotifierType = "pagerduty"
+
+	// NotifierOpsgenie creates Opsgenie alerts.
+	NotifierOpsgenie NotifierType = "opsgenie"
+
+	// NotifierAlertmanager sends alerts to Prometheus Alertmanager.
+	NotifierAlertmanager NotifierType = "alertmanager"
+)
+
+// notifierTypes is every valid notifier type.
+var notifierTypes = []NotifierType{
+	NotifierDesktop,
+	NotifierWebhook,
+	NotifierEmail,
+	NotifierSlack,
+	NotifierDiscord,
+	NotifierTeams,
+	NotifierMatrix,
+	NotifierNtfy,
+	NotifierGotify,
+	NotifierPushover,
+	NotifierTelegram,
+	NotifierPagerDuty,
+	NotifierOpsgenie,
+	NotifierAlertmanager,
+}
+
+// EmailTLS is how an email notifier secures its connection to the SMTP server.
+type EmailTLS string
+
+const (
+	// EmailTLSStartTLS upgrades a plain connection with the STARTTLS command.
+	EmailTLSStartTLS EmailTLS = "starttls"
+
+	// EmailTLSImplicit connects over TLS from the start, usually on port 465.
+	EmailTLSImplicit EmailTLS = "implicit"
+
+	// EmailTLSNone sends email unencrypted. Only use it for a relay on the local machine.
+	EmailTLSNone EmailTLS = "none"
+)
+
+// emailTLSModes is every valid email TLS mode.
+var emailTLSModes = []EmailTLS{
+	EmailTLSStartTLS,
+	EmailTLSImplicit,
+	EmailTLSNone,
+}
+
+const (
+	// DefaultHTTPTimeout is how long a notifier waits for each HTTP request.
+	DefaultHTTPTimeout = Duration(10 * time.Second)
+
+	// DefaultHTTPRetries is how many times a notifier retries a failed HTTP request.
+	DefaultHTTPRetries = 3
+
+	// DefaultHTTPBackoff is how long a notifier waits before retrying a failed HTTP request the first time.
+	DefaultHTTPBackoff = Duration(time.Second)
+)
+
+// Severity is the name of an alert severity.
+type Severity string
+
+const (
+	// SeverityWarning is the severity of alerts that need attention.
+	SeverityWarning Severity = "warning"
+
+	// SeverityCritical is the severity of alerts that put the system at risk.
+	SeverityCritical Severity = "critical"
+)
+
+// severities is every valid severity, from least to most severe.
+var severities = []Severity{
+	SeverityWarning,
+	SeverityCritical,
+}
+
+// Notifier is the configuration of a notifier.
+type Notifier struct {
+	// Name identifies the notifier in logs.
+	Name string `yaml:"name"`
+
+	// Type is the type of the notifier.
+	Type NotifierType `yaml:"type"`
+
+	// MinSeverity is the least severe alert sent to the notifier.
+	MinSeverity Severity `yaml:"min_severity"`
+
+	// RateLimit limits how many notifications are sent. Notifications over the limit are suppressed and counted in the
+	// next one sent.
+	RateLimit *RateLimit `yaml:"rate_limit,omitempty"`
+
+	// Webhook configures a webhook notifier.
+	Webhook *Webhook `yaml:"webhook,omitempty"`
+
+	// Email configures an email notifier.
+	Email *Email `yaml:"email,omitempty"`
+
+	// Slack configures a Slack notifier.
+	Slack *Slack `yaml:"slack,omitempty"`
+
+	// Discord configures a Discord notifier.
+	Discord *Discord `yaml:"discord,omitempty"`
+
+	// Teams configures a Microsoft Teams notifier.
+	Teams *Teams `yaml:"teams,omitempty"`
+
+	// Matrix configures a Matrix notifier.
+	Matrix *Matrix `yaml:"matrix,omitempty"`
+
+	// Ntfy configures an ntfy notifier.
+	Ntfy *Ntfy `yaml:"ntfy,omitempty"`
+
+	// Gotify configures a Gotify notifier.
+	Gotify *Gotify `yaml:"gotify,omitempty"`
+
+	// Pushover configures a Pushover notifier.
+	Pushover *Pushover `yaml:"pushover,omitempty"`
+
+	// Telegram configures a Telegram notifier.
+	Telegram *Telegram `yaml:"telegram,omitempty"`
+
+	// PagerDuty configures a PagerDuty notifier.
+	PagerDuty *PagerDuty `yaml:"pagerduty,omitempty"`
+
+	// Opsgenie configures an Opsgenie notifier.
+	Opsgenie *Opsgenie `yaml:"opsgenie,omitempty"`
+
+	// Alertmanager configures an Alertmanager notifier.
+	Alertmanager *Alertmanager `yaml:"alertmanager,omitempty"`
+}
+
+// UnmarshalYAML decodes a notifier, filling in defaults for anything not given.
+func (n *Notifier) UnmarshalYAML(node *yaml.Node) error {
+	type plain Notifier
+	p := plain(*DefaultNotifier())
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*n = Notifier(p)
+	return nil
+}
+
+// RateLimit allows at most Count notifications in any period of Per.
+type RateLimit struct {
+	// Count is the number of notifications allowed in the period.
+	Count int `yaml:"count"`
+
+	// Per is the length of the period.
+	Per Duration `yaml:"per"`
+}
+
+// DefaultNotifier returns the defaults of a notifier.
+func DefaultNotifier() *Notifier {
+	return &Notifier{
+		MinSeverity: SeverityWarning,
+	}
+}
+
+// validate checks the values of the notifier. The at function returns the path of a key of the notifier.
+func (n *Notifier) validate(at func(keys ...any) []any, add problemFunc) {
+	if !slices.Contains(notifierTypes, n.Type) {
+		add(at("type"), "unknown notifier type %q, must be one of %s", n.Type, joinQuoted(notifierTypes))
+	}
+
+	if !slices.Contains(severities, n.MinSeverity) {
+		add(at("min_severity"), "unknown severity %q, must be one of %s", n.MinSeverity, joinQuoted(severities))
+	}
+
+	if n.RateLimit != nil {
+		if n.RateLimit.Count <= 0 {
+			add(at("rate_limit", "count"), "must be greater than zero")
+		}
+
+		if n.RateLimit.Per <= 0 {
+			add(at("rate_limit", "per"), "must be greater than zero")
+		}
+	}
+
+	switch n.Type {
+	case NotifierWebhook:
+		if n.Webhook == nil {
+			add(at("webhook"), "required for webhook notifiers")
+			return
+		}
+
+		validateURL(at("webhook", "url"), n.Webhook.URL, add)
+		n.Webhook.HTTPOptions.validate(sub(at, "webhook"), add)
+	case NotifierEmail:
+		if n.Email == nil {
+			add(at("email"), "required for email notifiers")
+			return
+		}
+
+		n.Email.validate(sub(at, "email"), add)
+	case NotifierSlack:
+		if n.Slack == nil {
+			add(at("slack"), "required for slack notifiers")
+			return
+		}
+
+		n.Slack.validate(sub(at, "slack"), add)
+	case NotifierDiscord:
+		if n.Discord == nil {
+			add(at("discord"), "required for discord notifiers")
+			return
+		}
+
+		validateURL(at("discord", "webhook_url"), n.Discord.WebhookURL, add)
+		n.Discord.HTTPOptions.validate(sub(at, "discord"), add)
+	case NotifierTeams:
+		if n.Teams == nil {
+			add(at("teams"), "required for teams notifiers")
+			return
+		}
+
+		validateURL(at("teams", "webhook_url"), n.Teams.WebhookURL, add)
+		n.Teams.HTTPOptions.validate(sub(at, "teams"), add)
+	case NotifierMatrix:
+		if n.Matrix == nil {
+			add(at("matrix"), "required for matrix notifiers")
+			return
+		}
+
+		n.Matrix.validate(sub(at, "matrix"), add)
+	case NotifierNtfy:
+		if n.Ntfy == nil {
+			add(at("ntfy"), "required for ntfy notifiers")
+			return
+		}
+
+		n.Ntfy.validate(sub(at, "ntfy"), add)
+	case NotifierGotify:
+		if n.Gotify == nil {
+			add(at("gotify"), "required for gotify notifiers")
+			return
+		}
+
+		n.Gotify.validate(sub(at, "gotify"), add)
+	case NotifierPushover:
+		if n.Pushover == nil {
+			add(at("pushover"), "required for pushover notifiers")
+			return
+		}
+
+		n.Pushover.validate(sub(at, "pushover"), add)
+	case NotifierTelegram:
+		if n.Telegram == nil {
+			add(at("telegram"), "required for telegram notifiers")
+			return
+		}
+
+		n.Telegram.validate(sub(at, "telegram"), add)
+	case NotifierPagerDuty:
+		if n.PagerDuty == nil {
+			add(at("pagerduty"), "required for pagerduty notifiers")
+			return
+		}
+
+		n.PagerDuty.validate(sub(at, "pagerduty"), add)
+	case NotifierOpsgenie:
+		if n.Opsgenie == nil {
+			add(at("opsgenie"), "required for opsgenie notifiers")
+			return
+		}
+
+		n.Opsgenie.validate(sub(at, "opsgenie"), add)
+	case NotifierAlertmanager:
+		if n.Alertmanager == nil {
+			add(at("alertmanager"), "required for alertmanager notifiers")
+			return
+		}
+
+		n.Alertmanager.validate(sub(at, "alertmanager"), add)
+	default:
+	}
+}
+
+// sub returns the path function of a section of the notifier.
+func sub(at func(keys ...any) []any, section string) func(keys ...any) []any {
+	return func(keys ...any) []any {
+		return at(append([]any{section}, keys...)...)
+	}
+}
+
+// HTTPOptions are the settings shared by notifiers that send HTTP requests.
+type HTTPOptions struct {
+	// Timeout is how long each request may take.
+	Timeout Duration `yaml:"timeout"`
+
+	// Retries is how many times a failed request is retried.
+	Retries int `yaml:"retries"`
+
+	// Backoff is how long to wait before the first retry. It doubles with every retry.
+	Backoff Duration `yaml:"backoff"`
+}
+
+// DefaultHTTPOptions returns the defaults of the HTTP settings of a notifier.
+func DefaultHTTPOptions() HTTPOptions {
+	return HTTPOptions{
+		Timeout: DefaultHTTPTimeout,
+		Retries: DefaultHTTPRetries,
+		Backoff: DefaultHTTPBackoff,
+	}
+}
+
+// validate checks the HTTP settings.
+func (o *HTTPOptions) validate(at func(keys ...any) []any, add problemFunc) {
+	if o.Timeout <= 0 {
+		add(at("timeout"), "must be greater than zero")
+	}
+
+	if o.Retries < 0 {
+		add(at("retries"), "must not be negative")
+	}
+
+	if o.Backoff < 0 {
+		add(at("backoff"), "must not be negative")
+	}
+}
+
+// Webhook is the configuration of a webhook notifier.
+type Webhook struct {
+	// URL is where alerts are sent.
+	URL string `yaml:"url"`
+
+	// Method is the HTTP method of the request.
+	Method string `yaml:"method"`
+
+	// Headers are added to every request.
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// Template is a Go text/template producing the request body. The default body is a JSON object describing the
+	// alert.
+	Template string `yaml:"template,omitempty"`
+
+	// Secret signs the request body with HMAC-SHA256 when set.
+	Secret string `yaml:"secret,omitempty"`
+
+	HTTPOptions `yaml:",inline"`
+}
+
+// UnmarshalYAML decodes a webhook, filling in defaults for anything not given.
+func (w *Webhook) UnmarshalYAML(node *yaml.Node) error {
+	type plain Webhook
+	p := plain(*DefaultWebhook())
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*w = Webhook(p)
+	return nil
+}
+
+// DefaultWebhook returns the defaults of a webhook.
+func DefaultWebhook() *Webhook {
+	return &Webhook{
+		Method:      "POST",
+		HTTPOptions: DefaultHTTPOptions(),
+	}
+}
+
+// validateURL checks that the value is an absolute HTTP or HTTPS URL.
+func validateURL(path []any, value string, add problemFunc) {
+	if value == "" {
+		add(path, "required")
+		return
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add(path, "invalid URL %q, must be an absolute http or https URL", value)
+	}
+}
+
+// Email is the configuration of an email notifier.
+type Email struct {
+	// Host is the SMTP server.
+	Host string `yaml:"host"`
+
+	// Port is the port of the SMTP server. It defaults to 465 for implicit TLS, 25 without TLS and 587 otherwise.
+	Port int `yaml:"port,omitempty"`
+
+	// TLS is how the connection to the SMTP server is secured.
+	TLS EmailTLS `yaml:"tls"`
+
+	// Username authenticates with the SMTP server using PLAIN authentication. No authentication is done when empty.
+	Username string `yaml:"username,omitempty"`
+
+	// Password is the password of the user.
+	Password string `yaml:"password,omitempty"`
+
+	// From is the sender address.
+	From string `yaml:"from"`
+
+	// To are the recipient addresses.
+	To []string `yaml:"to"`
+
+	// Digest batches alerts that are not critical into one email sent at most this often. Critical alerts are always
+	// sent straight away. Zero sends every alert straight away.
+	Digest Duration `yaml:"digest,omitempty"`
+
+	// Timeout is how long sending an email may take.
+	Timeout Duration `yaml:"timeout"`
+}
+
+// UnmarshalYAML decodes an email notifier, filling in defaults for anything not given.
+func (e *Email) UnmarshalYAML(node *yaml.Node) error {
+	type plain Email
+	p := plain(*DefaultEmail())
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*e = Email(p)
+	return nil
+}
+
+// DefaultEmail returns the defaults of an email notifier.
+func DefaultEmail() *Email {
+	return &Email{
+		TLS:     EmailTLSStartTLS,
+		Timeout: DefaultHTTPTimeout,
+	}
+}
+
+// Addr returns the address of the SMTP server, filling in the default port for the TLS mode.
+func (e *Email) Addr() string {
+	port := e.Port
+	if port == 0 {
+		switch e.TLS {
+		case EmailTLSImplicit:
+			port = 465
+		case EmailTLSNone:
+			port = 25
+		default:
+			port = 587
+		}
+	}
+
+	return net.JoinHostPort(e.Host, strconv.Itoa(port))
+}
+
+// validate checks the email settings.
+func (e *Email) validate(at func(keys ...any) []any, add problemFunc) {
+	if e.Host == "" {
+		add(at("host"), "required")
+	}
+
+	if e.Port < 0 || e.Port > 65535 {
+		add(at("port"), "must be between 1 and 65535")
+	}
+
+	if !slices.Contains(emailTLSModes, e.TLS) {
+		add(at("tls"), "unknown TLS mode %q, must be one of %s", e.TLS, joinQuoted(emailTLSModes))
+	}
+
+	if e.Password != "" && e.Username == "" {
+		add(at("username"), "required when a password is given")
+	}
+
+	if _, err := mail.ParseAddress(e.From); err != nil {
+		add(at("from"), "invalid address %q", e.From)
+	}
+
+	if len(e.To) == 0 {
+		add(at("to"), "at least one recipient is required")
+	}
+
+	for i, to := range e.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			add(at("to", i), "invalid address %q", to)
+		}
+	}
+
+	if e.Digest < 0 {
+		add(at("digest"), "must not be negative")
+	}
+
+	if e.Timeout <= 0 {
+		add(at("timeout"), "must be greater than zero")
+	}
+}
+
+// Slack is the configuration of a Slack notifier. Alerts are posted either to an incoming webhook, or with a bot token
+// to a channel. Only the bot token can post resolved alerts as replies in the thread of the alert.
+type Slack struct {
+	// WebhookURL is the incoming webhook alerts are posted to.
+	WebhookURL string `yaml:"webhook_url,omitempty"`
+
+	// Token is the bot token used to post to the channel with the Web API.
+	Token string `yaml:"token,omitempty"`
+
+	// Channel is the channel posted to with the bot token.
+	Channel string `yaml:"channel,omitempty"`
+
+	// APIURL is the base URL of the Slack Web API.
+	APIURL string `yaml:"api_url"`
+
+	HTTPOptions `yaml:",inline"`
+}
+
+// UnmarshalYAML decodes a Slack notifier, filling in defaults for anything not given.
+func (s *Slack) UnmarshalYAML(node *yaml.Node) error {
+	type plain Slack
+	p := plain(*DefaultSlack())
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*s = Slack(p)
+	return nil
+}
+
+// DefaultSlack returns the defaults of a Slack notifier.
+func DefaultSlack() *Slack {
+	return &Slack{
+		APIURL:      "https://slack.com/api",
+		HTTPOptions: DefaultHTTPOptions(),
+	}
+}
+
+// validate checks the Slack settings.
+func (s *Slack) validate(at func(keys ...any) []any, add problemFunc) {
+	switch {
+	case s.WebhookURL != "" && s.Token != "":
+		add(at("token"), "must not be given with webhook_url")
+	case s.WebhookURL != "":
+		validateURL(at("webhook_url"), s.WebhookURL, add)
+	case s.Token != "":
+		if s.Channel == "" {
+			add(at("channel"), "required with token")
+		}
+		validateURL(at("api_url"), s.APIURL, add)
+	default:
+		add(at(), "either webhook_url or token and channel are required")
+	}
+
+	s.HTTPOptions.validate(at, add)
+}
+
+// Discord is the configuration of a Discord notifier.
+type Discord struct {
+	// WebhookURL is the webhook alerts are posted to.
+	WebhookURL string `yaml:"webhook_url"`
+
+	// Username overrides the name the webhook posts as.
+	Username string `yaml:"username,omitempty"`
+
+	HTTPOptions `yaml:",inline"`
+}
+
+// UnmarshalYAML decodes a Discord notifier, filling in defaults for anything not given.
+func (d *Discord) UnmarshalYAML(node *yaml.Node) error {
+	type plain Discord
+	p := plain(Discord{HTTPOptions: DefaultHTTPOptions()})
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*d = Discord(p)
+	return nil
+}
+
+// Teams is the configuration of a Microsoft Teams notifier.
+type Teams struct {
+	// WebhookURL is the incoming webhook or workflow URL alerts are posted to.
+	WebhookURL string `yaml:"webhook_url"`
+
+	HTTPOptions `yaml:",inline"`
+}
+
+// UnmarshalYAML decodes a Teams notifier, filling in defaults for anything not given.
+func (t *Teams) UnmarshalYAML(node *yaml.Node) error {
+	type plain Teams
+	p := plain(Teams{HTTPOptions: DefaultHTTPOptions()})
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*t = Teams(p)
+	return nil
+}
+
+// Matrix is the configuration of a Matrix notifier.
+type Matrix struct {
+	// Homeserver is the base URL of the homeserver, e.g. "https://matrix.example.com".
+	Homeserver string `yaml:"homeserver"`
+
+	// RoomID is the room alerts are posted to, e.g. "!abcdef:example.com".
+	RoomID string `yaml:"room_id"`
+
+	// AccessToken authenticates the user posting the alerts.
+	AccessToken string `yaml:"access_token"`
+
+	HTTPOptions `yaml:",inline"`
+}
+
+// UnmarshalYAML decodes a Matrix notifier, filling in defaults for anything not given.
+func (m *Matrix) UnmarshalYAML(node *yaml.Node) error {
+	type plain Matrix
+	p := plain(Matrix{HTTPOptions: DefaultHTTPOptions()})
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*m = Matrix(p)
+	return nil
+}
+
+// validate checks the Matrix settings.
+func (m *Matrix) validate(at func(keys ...any) []any, add problemFunc) {
+	validateURL(at("homeserver"), m.Homeserver, add)
+
+	if !strings.HasPrefix(m.RoomID, "!") {
+		add(at("room_id"), "invalid room ID %q, must start with \"!\"", m.RoomID)
+	}
+
+	if m.AccessToken == "" {
+		add(at("access_token"), "required")
+	}
+
+	m.HTTPOptions.validate(at, add)
+}
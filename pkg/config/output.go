@@ -0,0 +1,365 @@
+package config
+
+import (
+	"net"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OutputType is the type of an output.
+type OutputType string
+
+const (
+	// OutputInfluxDB writes readings to InfluxDB in the line protocol.
+	OutputInfluxDB OutputType = "influxdb"
+
+	// OutputGraphite writes readings to Graphite in the plaintext protocol.
+	OutputGraphite OutputType = "graphite"
+
+	// OutputOTLP exports readings as OpenTelemetry metrics over OTLP/HTTP.
+	OutputOTLP OutputType = "otlp"
+
+	// OutputMQTT publishes readings to an MQTT broker.
+	OutputMQTT OutputType = "mqtt"
+)
+
+// outputTypes is every valid output type.
+var outputTypes = []OutputType{
+	OutputInfluxDB,
+	OutputGraphite,
+	OutputOTLP,
+	OutputMQTT,
+}
+
+// OTLPEncoding is how OTLP requests are encoded.
+type OTLPEncoding string
+
+const (
+	// OTLPProtobuf encodes requests as binary protobuf.
+	OTLPProtobuf OTLPEncoding = "protobuf"
+
+	// OTLPJSON encodes requests as JSON.
+	OTLPJSON OTLPEncoding = "json"
+)
+
+// otlpEncodings is every valid OTLP encoding.
+var otlpEncodings = []OTLPEncoding{
+	OTLPProtobuf,
+	OTLPJSON,
+}
+
+// GraphiteProtocol is the transport readings are sent to Graphite over.
+type GraphiteProtocol string
+
+const (
+	// GraphiteTCP sends readings over a TCP connection.
+	GraphiteTCP GraphiteProtocol = "tcp"
+
+	// GraphiteUDP sends readings in UDP datagrams. Datagrams that are lost are not noticed.
+	GraphiteUDP GraphiteProtocol = "udp"
+)
+
+// graphiteProtocols is every valid Graphite protocol.
+var graphiteProtocols = []GraphiteProtocol{
+	GraphiteTCP,
+	GraphiteUDP,
+}
+
+const (
+	// DefaultFlushInterval is how often an output sends the readings it has buffered.
+	DefaultFlushInterval = Duration(10 * time.Second)
+
+	// DefaultBufferSize is how many lines an output buffers while its endpoint is down before dropping the oldest.
+	DefaultBufferSize = 10000
+
+	// DefaultInfluxDBVersion is the version of the InfluxDB write API used.
+	DefaultInfluxDBVersion = 2
+
+	// DefaultInfluxDBMeasurement is the measurement readings are written to.
+	DefaultInfluxDBMeasurement = "sensors"
+
+	// DefaultGraphitePrefix is the prefix of the metric paths sent to Graphite.
+	DefaultGraphitePrefix = "sensors"
+)
+
+// Output is the configuration of an output, which pushes every reading to a time series database.
+type Output struct {
+	// Name identifies the output in logs.
+	Name string `yaml:"name"`
+
+	// Type is the type of the output.
+	Type OutputType `yaml:"type"`
+
+	// FlushInterval is how often buffered readings are sent.
+	FlushInterval Duration `yaml:"flush_interval"`
+
+	// BufferSize is the most lines buffered while the endpoint is down. The oldest lines are dropped once it is full.
+	BufferSize int `yaml:"buffer_size"`
+
+	// InfluxDB configures an InfluxDB output.
+	InfluxDB *InfluxDB `yaml:"influxdb,omitempty"`
+
+	// Graphite configures a Graphite output.
+	Graphite *Graphite `yaml:"graphite,omitempty"`
+
+	// OTLP configures an OTLP output.
+	OTLP *OTLP `yaml:"otlp,omitempty"`
+
+	// MQTT configures an MQTT output.
+	MQTT *MQTT `yaml:"mqtt,omitempty"`
+}
+
+// UnmarshalYAML decodes an output, filling in defaults for anything not given.
+func (o *Output) UnmarshalYAML(node *yaml.Node) error {
+	type plain Output
+	p := plain(*DefaultOutput())
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*o = Output(p)
+	return nil
+}
+
+// DefaultOutput returns the defaults of an output.
+func DefaultOutput() *Output {
+	return &Output{
+		FlushInterval: DefaultFlushInterval,
+		BufferSize:    DefaultBufferSize,
+	}
+}
+
+// validate checks the values of the output. The at function returns the path of a key of the output.
+func (o *Output) validate(at func(keys ...any) []any, add problemFunc) {
+	if !slices.Contains(outputTypes, o.Type) {
+		add(at("type"), "unknown output type %q, must be one of %s", o.Type, joinQuoted(outputTypes))
+	}
+
+	if o.FlushInterval <= 0 {
+		add(at("flush_interval"), "must be greater than zero")
+	}
+
+	if o.BufferSize <= 0 {
+		add(at("buffer_size"), "must be greater than zero")
+	}
+
+	switch o.Type {
+	case OutputInfluxDB:
+		if o.InfluxDB == nil {
+			add(at("influxdb"), "required for influxdb outputs")
+			return
+		}
+
+		o.InfluxDB.validate(sub(at, "influxdb"), add)
+	case OutputGraphite:
+		if o.Graphite == nil {
+			add(at("graphite"), "required for graphite outputs")
+			return
+		}
+
+		o.Graphite.validate(sub(at, "graphite"), add)
+	case OutputOTLP:
+		if o.OTLP == nil {
+			add(at("otlp"), "required for otlp outputs")
+			return
+		}
+
+		o.OTLP.validate(sub(at, "otlp"), add)
+	case OutputMQTT:
+		if o.MQTT == nil {
+			add(at("mqtt"), "required for mqtt outputs")
+			return
+		}
+
+		o.MQTT.validate(sub(at, "mqtt"), add)
+	default:
+	}
+}
+
+// InfluxDB is the configuration of an InfluxDB output.
+type InfluxDB struct {
+	// URL is the base URL of InfluxDB, e.g. "http://influxdb:8086".
+	URL string `yaml:"url"`
+
+	// Version is the version of the write API: 2 for /api/v2/write, or 1 for /write.
+	Version int `yaml:"version"`
+
+	// Org, Bucket and Token are where readings are written with version 2 of the API.
+	Org    string `yaml:"org,omitempty"`
+	Bucket string `yaml:"bucket,omitempty"`
+	Token  string `yaml:"token,omitempty"`
+
+	// Database and RetentionPolicy are where readings are written with version 1 of the API.
+	Database        string `yaml:"database,omitempty"`
+	RetentionPolicy string `yaml:"retention_policy,omitempty"`
+
+	// Username and Password authenticate with version 1 of the API when given.
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+
+	// Measurement is the measurement readings are written to.
+	Measurement string `yaml:"measurement"`
+
+	// Gzip compresses the body of every write.
+	Gzip bool `yaml:"gzip"`
+
+	HTTPOptions `yaml:",inline"`
+}
+
+// UnmarshalYAML decodes an InfluxDB output, filling in defaults for anything not given.
+func (i *InfluxDB) UnmarshalYAML(node *yaml.Node) error {
+	type plain InfluxDB
+	p := plain(*DefaultInfluxDB())
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*i = InfluxDB(p)
+	return nil
+}
+
+// DefaultInfluxDB returns the defaults of an InfluxDB output.
+func DefaultInfluxDB() *InfluxDB {
+	return &InfluxDB{
+		Version:     DefaultInfluxDBVersion,
+		Measurement: DefaultInfluxDBMeasurement,
+		HTTPOptions: DefaultHTTPOptions(),
+	}
+}
+
+// validate checks the InfluxDB settings.
+func (i *InfluxDB) validate(at func(keys ...any) []any, add problemFunc) {
+	validateURL(at("url"), i.URL, add)
+
+	switch i.Version {
+	case 1:
+		if i.Database == "" {
+			add(at("database"), "required for version 1")
+		}
+
+		if i.Password != "" && i.Username == "" {
+			add(at("username"), "required when a password is given")
+		}
+	case 2:
+		if i.Org == "" {
+			add(at("org"), "required for version 2")
+		}
+
+		if i.Bucket == "" {
+			add(at("bucket"), "required for version 2")
+		}
+
+		if i.Token == "" {
+			add(at("token"), "required for version 2")
+		}
+	default:
+		add(at("version"), "unknown version %d, must be 1 or 2", i.Version)
+	}
+
+	if i.Measurement == "" {
+		add(at("measurement"), "required")
+	}
+
+	i.HTTPOptions.validate(at, add)
+}
+
+// Graphite is the configuration of a Graphite output.
+type Graphite struct {
+	// Address is the host and port of the plaintext listener of Graphite, e.g. "graphite:2003".
+	Address string `yaml:"address"`
+
+	// Protocol is the transport readings are sent over.
+	Protocol GraphiteProtocol `yaml:"protocol"`
+
+	// Prefix is prepended to the path of every metric.
+	Prefix string `yaml:"prefix"`
+
+	// Timeout is how long connecting and sending may take.
+	Timeout Duration `yaml:"timeout"`
+}
+
+// UnmarshalYAML decodes a Graphite output, filling in defaults for anything not given.
+func (g *Graphite) UnmarshalYAML(node *yaml.Node) error {
+	type plain Graphite
+	p := plain(*DefaultGraphite())
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*g = Graphite(p)
+	return nil
+}
+
+// DefaultGraphite returns the defaults of a Graphite output.
+func DefaultGraphite() *Graphite {
+	return &Graphite{
+		Protocol: GraphiteTCP,
+		Prefix:   DefaultGraphitePrefix,
+		Timeout:  DefaultHTTPTimeout,
+	}
+}
+
+// validate checks the Graphite settings.
+func (g *Graphite) validate(at func(keys ...any) []any, add problemFunc) {
+	if g.Address == "" {
+		add(at("address"), "required")
+	} else if _, _, err := net.SplitHostPort(g.Address); err != nil {
+		add(at("address"), "invalid address %q, must be a host and port such as \"graphite:2003\"", g.Address)
+	}
+
+	if !slices.Contains(graphiteProtocols, g.Protocol) {
+		add(at("protocol"), "unknown protocol %q, must be one of %s", g.Protocol, joinQuoted(graphiteProtocols))
+	}
+
+	if g.Timeout <= 0 {
+		add(at("timeout"), "must be greater than zero")
+	}
+}
+
+// OTLP is the configuration of an OTLP output.
+type OTLP struct {
+	// URL is the base URL of the OTLP/HTTP receiver, e.g. "http://otel-collector:4318". Metrics are sent to its
+	// /v1/metrics path.
+	URL string `yaml:"url"`
+
+	// Encoding is how requests are encoded.
+	Encoding OTLPEncoding `yaml:"encoding"`
+
+	// Headers are added to every request, e.g. for authentication.
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	HTTPOptions `yaml:",inline"`
+}
+
+// UnmarshalYAML decodes an OTLP output, filling in defaults for anything not given.
+func (o *OTLP) UnmarshalYAML(node *yaml.Node) error {
+	type plain OTLP
+	p := plain(*DefaultOTLP())
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*o = OTLP(p)
+	return nil
+}
+
+// DefaultOTLP returns the defaults of an OTLP output.
+func DefaultOTLP() *OTLP {
+	return &OTLP{
+		Encoding:    OTLPProtobuf,
+		HTTPOptions: DefaultHTTPOptions(),
+	}
+}
+
+// validate checks the OTLP settings.
+func (o *OTLP) validate(at func(keys ...any) []any, add problemFunc) {
+	validateURL(at("url"), o.URL, add)
+
+	if !slices.Contains(otlpEncodings, o.Encoding) {
+		add(at("encoding"), "unknown encoding %q, must be one of %s", o.Encoding, joinQuoted(otlpEncodings))
+	}
+
+	o.HTTPOptions.validate(at, add)
+}
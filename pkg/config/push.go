@@ -0,0 +1,295 @@
+package config
+
+import (
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Level is the level of a push notification: the severity of a firing alert, or resolved once it has recovered.
+type Level string
+
+const (
+	// LevelWarning is the level of warnings.
+	LevelWarning Level = "warning"
+
+	// LevelCritical is the level of critical alerts.
+	LevelCritical Level = "critical"
+
+	// LevelResolved is the level of recoveries.
+	LevelResolved Level = "resolved"
+)
+
+// levels is every valid level.
+var levels = []Level{
+	LevelWarning,
+	LevelCritical,
+	LevelResolved,
+}
+
+const (
+	// PushoverPriorityEmergency is the Pushover priority that repeats a notification until it is acknowledged.
+	PushoverPriorityEmergency = 2
+
+	// pushoverMinRetry is the shortest interval Pushover repeats emergency notifications at.
+	pushoverMinRetry = Duration(30 * time.Second)
+
+	// pushoverMaxExpire is the longest Pushover repeats emergency notifications for.
+	pushoverMaxExpire = Duration(3 * time.Hour)
+)
+
+// Priorities maps the levels of notifications to the priorities of a push notification service.
+type Priorities struct {
+	// Warning is the priority of warnings.
+	Warning int `yaml:"warning"`
+
+	// Critical is the priority of critical alerts.
+	Critical int `yaml:"critical"`
+
+	// Resolved is the priority of recoveries.
+	Resolved int `yaml:"resolved"`
+}
+
+// For returns the priority of notifications of the given level.
+func (p Priorities) For(level Level) int {
+	switch level {
+	case LevelCritical:
+		return p.Critical
+	case LevelResolved:
+		return p.Resolved
+	default:
+		return p.Warning
+	}
+}
+
+// validate checks that every priority is between lowest and highest.
+func (p *Priorities) validate(at func(keys ...any) []any, add problemFunc, lowest, highest int) {
+	for _, level := range levels {
+		if v := p.For(level); v < lowest || v > highest {
+			add(at("priority", string(level)), "must be between %d and %d", lowest, highest)
+		}
+	}
+}
+
+// Ntfy is the configuration of an ntfy notifier.
+type Ntfy struct {
+	// URL is the URL of the topic, e.g. "https://ntfy.sh/build-box-alerts".
+	URL string `yaml:"url"`
+
+	// Token is the access token of a protected topic.
+	Token string `yaml:"token,omitempty"`
+
+	// Priority maps notifications to ntfy priorities, from 1 (min) to 5 (urgent).
+	Priority Priorities `yaml:"priority"`
+
+	// Tags are added to every notification. Tags matching an emoji short code are shown as the emoji.
+	Tags []string `yaml:"tags,omitempty"`
+
+	HTTPOptions `yaml:",inline"`
+}
+
+// UnmarshalYAML decodes an ntfy notifier, filling in defaults for anything not given.
+func (n *Ntfy) UnmarshalYAML(node *yaml.Node) error {
+	type plain Ntfy
+	p := plain(*DefaultNtfy())
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*n = Ntfy(p)
+	return nil
+}
+
+// DefaultNtfy returns the defaults of an ntfy notifier.
+func DefaultNtfy() *Ntfy {
+	return &Ntfy{
+		Priority:    Priorities{Warning: 4, Critical: 5, Resolved: 3},
+		HTTPOptions: DefaultHTTPOptions(),
+	}
+}
+
+// validate checks the ntfy settings.
+func (n *Ntfy) validate(at func(keys ...any) []any, add problemFunc) {
+	validateURL(at("url"), n.URL, add)
+	n.Priority.validate(at, add, 1, 5)
+	n.HTTPOptions.validate(at, add)
+}
+
+// Gotify is the configuration of a Gotify notifier.
+type Gotify struct {
+	// URL is the base URL of the Gotify server.
+	URL string `yaml:"url"`
+
+	// Token is the token of the application the notifications are sent as.
+	Token string `yaml:"token"`
+
+	// Priority maps notifications to Gotify priorities, from 0 to 10.
+	Priority Priorities `yaml:"priority"`
+
+	HTTPOptions `yaml:",inline"`
+}
+
+// UnmarshalYAML decodes a Gotify notifier, filling in defaults for anything not given.
+func (g *Gotify) UnmarshalYAML(node *yaml.Node) error {
+	type plain Gotify
+	p := plain(*DefaultGotify())
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*g = Gotify(p)
+	return nil
+}
+
+// DefaultGotify returns the defaults of a Gotify notifier.
+func DefaultGotify() *Gotify {
+	return &Gotify{
+		Priority:    Priorities{Warning: 5, Critical: 8, Resolved: 2},
+		HTTPOptions: DefaultHTTPOptions(),
+	}
+}
+
+// validate checks the Gotify settings.
+func (g *Gotify) validate(at func(keys ...any) []any, add problemFunc) {
+	validateURL(at("url"), g.URL, add)
+
+	if g.Token == "" {
+		add(at("token"), "required")
+	}
+
+	g.Priority.validate(at, add, 0, 10)
+	g.HTTPOptions.validate(at, add)
+}
+
+// Pushover is the configuration of a Pushover notifier.
+type Pushover struct {
+	// Token is the API token of the application the notifications are sent as.
+	Token string `yaml:"token"`
+
+	// UserKey is the user or group key notifications are sent to.
+	UserKey string `yaml:"user_key"`
+
+	// Device limits notifications to one of the user's devices.
+	Device string `yaml:"device,omitempty"`
+
+	// Priority maps notifications to Pushover priorities, from -2 (lowest) to 2 (emergency).
+	Priority Priorities `yaml:"priority"`
+
+	// Retry is how often an emergency notification is repeated until it is acknowledged. It is at least 30s.
+	Retry Duration `yaml:"retry"`
+
+	// Expire is how long an emergency notification is repeated for. It is at most 3h.
+	Expire Duration `yaml:"expire"`
+
+	// APIURL is the base URL of the Pushover API.
+	APIURL string `yaml:"api_url"`
+
+	HTTPOptions `yaml:",inline"`
+}
+
+// UnmarshalYAML decodes a Pushover notifier, filling in defaults for anything not given.
+func (p *Pushover) UnmarshalYAML(node *yaml.Node) error {
+	type plain Pushover
+	pl := plain(*DefaultPushover())
+	if err := node.Decode(&pl); err != nil {
+		return err
+	}
+
+	*p = Pushover(pl)
+	return nil
+}
+
+// DefaultPushover returns the defaults of a Pushover notifier. Critical alerts are emergencies, repeated every minute
+// for an hour until they are acknowledged.
+func DefaultPushover() *Pushover {
+	return &Pushover{
+		Priority:    Priorities{Warning: 0, Critical: PushoverPriorityEmergency, Resolved: -1},
+		Retry:       Duration(time.Minute),
+		Expire:      Duration(time.Hour),
+		APIURL:      "https://api.pushover.net/1",
+		HTTPOptions: DefaultHTTPOptions(),
+	}
+}
+
+// validate checks the Pushover settings.
+func (p *Pushover) validate(at func(keys ...any) []any, add problemFunc) {
+	if p.Token == "" {
+		add(at("token"), "required")
+	}
+
+	if p.UserKey == "" {
+		add(at("user_key"), "required")
+	}
+
+	p.Priority.validate(at, add, -2, PushoverPriorityEmergency)
+
+	if p.Retry < pushoverMinRetry {
+		add(at("retry"), "must be at least %s", pushoverMinRetry.Std())
+	}
+
+	if p.Expire <= 0 || p.Expire > pushoverMaxExpire {
+		add(at("expire"), "must be greater than zero and at most %s", pushoverMaxExpire.Std())
+	}
+
+	validateURL(at("api_url"), p.APIURL, add)
+	p.HTTPOptions.validate(at, add)
+}
+
+// Telegram is the configuration of a Telegram notifier, sending messages with the Bot API.
+type Telegram struct {
+	// Token is the token of the bot the messages are sent as.
+	Token string `yaml:"token"`
+
+	// ChatID is the chat messages are sent to: a numeric ID, or the username of a channel such as "@alerts".
+	ChatID string `yaml:"chat_id"`
+
+	// Silent lists the levels of notifications sent without a sound.
+	Silent []Level `yaml:"silent"`
+
+	// APIURL is the base URL of the Bot API.
+	APIURL string `yaml:"api_url"`
+
+	HTTPOptions `yaml:",inline"`
+}
+
+// UnmarshalYAML decodes a Telegram notifier, filling in defaults for anything not given.
+func (t *Telegram) UnmarshalYAML(node *yaml.Node) error {
+	type plain Telegram
+	p := plain(*DefaultTelegram())
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+
+	*t = Telegram(p)
+	return nil
+}
+
+// DefaultTelegram returns the defaults of a Telegram notifier. Recoveries are sent without a sound.
+func DefaultTelegram() *Telegram {
+	return &Telegram{
+		Silent:      []Level{LevelResolved},
+		APIURL:      "https://api.telegram.org",
+		HTTPOptions: DefaultHTTPOptions(),
+	}
+}
+
+// validate checks the Telegram settings.
+func (t *Telegram) validate(at func(keys ...any) []any, add problemFunc) {
+	if t.Token == "" {
+		add(at("token"), "required")
+	}
+
+	if t.ChatID == "" {
+		add(at("chat_id"), "required")
+	}
+
+	for i, level := range t.Silent {
+		if !slices.Contains(levels, level) {
+			add(at("silent", i), "unknown level %q, must be one of %s", level, joinQuoted(levels))
+		}
+	}
+
+	validateURL(at("api_url"), t.APIURL, add)
+	t.HTTPOptions.validate(at, add)
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// thresholdLimits are the hardware limits a threshold can be given relative to.
+var thresholdLimits = []sensors.Subfeature{
+	sensors.SubfeatureMin,
+	sensors.SubfeatureMax,
+	sensors.SubfeatureCrit,
+	"lcrit",
+	"emergency",
+	sensors.SubfeaturePassive,
+	sensors.SubfeatureHot,
+}
+
+// thresholdPattern matches a threshold relative to a hardware limit, e.g. "max-5", "crit*0.85" or "crit".
+var thresholdPattern = regexp.MustCompile(
+	`^([a-z_]+)\s*(?:\*\s*([0-9]+(?:\.[0-9]+)?))?\s*(?:([+-])\s*([0-9]+(?:\.[0-9]+)?))?$`,
+)
+
+// Threshold is a limit given either as an absolute value, e.g. "90", or relative to a limit reported by the hardware,
+// e.g. "max-5", "crit*0.85", "crit" or "min+100".
+type Threshold struct {
+	// Limit is the hardware limit the threshold is relative to. It is empty for absolute thresholds.
+	Limit sensors.Subfeature
+
+	// Factor scales the hardware limit. Zero means the limit is not scaled.
+	Factor float64
+
+	// Offset is the absolute value, or the offset from the scaled hardware limit.
+	Offset float64
+}
+
+// ParseThreshold parses a threshold expression.
+func ParseThreshold(s string) (*Threshold, error) {
+	s = strings.TrimSpace(s)
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return &Threshold{Offset: v}, nil
+	}
+
+	m := thresholdPattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid threshold %q, expected a number or a hardware limit such as \"max-5\"", s)
+	}
+
+	limit := sensors.Subfeature(m[1])
+	if !slices.Contains(thresholdLimits, limit) {
+		return nil, fmt.Errorf("unknown hardware limit %q in threshold %q, must be one of %s", limit, s, joinQuoted(thresholdLimits))
+	}
+
+	t := &Threshold{Limit: limit}
+	if m[2] != "" {
+		factor, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid factor in threshold %q: %w", s, err)
+		}
+
+		if factor != 1 {
+			t.Factor = factor
+		}
+	}
+
+	if m[4] != "" {
+		offset, err := strconv.ParseFloat(m[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset in threshold %q: %w", s, err)
+		}
+
+		if m[3] == "-" {
+			offset = -offset
+		}
+		t.Offset = offset
+	}
+
+	return t, nil
+}
+
+// UnmarshalYAML parses a threshold expression.
+func (t *Threshold) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return typeError(node, "expected a threshold such as 90 or \"max-5\"")
+	}
+
+	parsed, err := ParseThreshold(node.Value)
+	if err != nil {
+		return typeError(node, err.Error())
+	}
+
+	*t = *parsed
+	return nil
+}
+
+// MarshalYAML writes the threshold expression.
+func (t Threshold) MarshalYAML() (any, error) {
+	return t.String(), nil
+}
+
+// String returns the threshold expression.
+func (t *Threshold) String() string {
+	offset := strconv.FormatFloat(t.Offset, 'f', -1, 64)
+	if t.Limit == "" {
+		return offset
+	}
+
+	s := string(t.Limit)
+	if t.Factor != 0 {
+		s += "*" + strconv.FormatFloat(t.Factor, 'f', -1, 64)
+	}
+
+	switch {
+	case t.Offset > 0:
+		return s + "+" + offset
+	case t.Offset < 0:
+		return s + offset
+	default:
+		return s
+	}
+}
+
+// Compare compares two thresholds relative to the same limit, returning false if they cannot be compared without
+// knowing the hardware limit.
+func (t *Threshold) Compare(other *Threshold) (int, bool) {
+	if t.Limit != other.Limit || t.Factor != other.Factor {
+		return 0, false
+	}
+
+	return cmp.Compare(t.Offset, other.Offset), true
+}
+
+// Resolve returns the value of the threshold for the given feature. The returned bool is false if the threshold is
+// relative to a limit the feature does not report.
+func (t *Threshold) Resolve(f *sensors.Feature) (float64, bool) {
+	if t.Limit == "" {
+		return t.Offset, true
+	}
+
+	limit, ok := f.Value(t.Limit)
+	if !ok {
+		return 0, false
+	}
+
+	if t.Factor != 0 {
+		limit *= t.Factor
+	}
+
+	return limit + t.Offset, true
+}
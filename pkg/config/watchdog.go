@@ -0,0 +1,129 @@
+package config
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+const (
+	// DefaultWatchdogMissingFor is how long an expected sensor may go unreported before it is alerted on.
+	DefaultWatchdogMissingFor = Duration(30 * time.Second)
+
+	// DefaultWatchdogImplausibleFor is how long a sensor must report impossible values before it is alerted on, so
+	// that a fan spinning up is not mistaken for a stalled one.
+	DefaultWatchdogImplausibleFor = Duration(30 * time.Second)
+)
+
+// Watchdog is the configuration of the checks that every sensor is still reported and that its readings make sense.
+// A sensor that drops off the bus would otherwise just stop being checked, and one that is stuck or broken could read
+// as perfectly cool.
+type Watchdog struct {
+	// Discover expects every sensor found by the first read of the sensors to be reported for as long as the monitor
+	// runs.
+	Discover bool `yaml:"discover"`
+
+	// Expect are patterns in the same form as the match of a rule, e.g. "nvme-*/Composite", of sensors that must be
+	// reported. A pattern is missing while no sensor matches it.
+	Expect []string `yaml:"expect"`
+
+	// MissingFor is how long an expected sensor may go unreported before it is alerted on.
+	MissingFor Duration `yaml:"missing_for"`
+
+	// StaleFor is how long the value of a sensor of one of the StaleKinds may stay exactly the same before it is
+	// alerted on. Zero, the default, never alerts on stale sensors: sensors reporting whole degrees, or an idle fan,
+	// can hold their value for hours. Fans at 0 RPM are never stale.
+	StaleFor Duration `yaml:"stale_for"`
+
+	// StaleKinds are the kinds of sensors checked for stale values. Sensors such as voltages are too steady to tell.
+	StaleKinds []sensors.Kind `yaml:"stale_kinds"`
+
+	// ImplausibleFor is how long a sensor must report impossible values, outside of its range or a fan that is
+	// stopped while it is driven, before it is alerted on.
+	ImplausibleFor Duration `yaml:"implausible_for"`
+
+	// Ranges are the values each kind of sensor can physically report. Ranges given here replace the defaults of
+	// their kind.
+	Ranges map[sensors.Kind]*Range `yaml:"ranges"`
+
+	// Severity is the severity of the alerts raised.
+	Severity Severity `yaml:"severity"`
+}
+
+// Range is a range of values. A missing bound leaves that side of the range open.
+type Range struct {
+	// Min is the lowest value in the range.
+	Min *float64 `yaml:"min,omitempty"`
+
+	// Max is the highest value in the range.
+	Max *float64 `yaml:"max,omitempty"`
+}
+
+// Contains reports whether the value is within the range.
+func (r *Range) Contains(v float64) bool {
+	return (r.Min == nil || v >= *r.Min) && (r.Max == nil || v <= *r.Max)
+}
+
+// DefaultWatchdog returns the defaults of the watchdog settings. Temperatures are plausible from -50°C to 150°C,
+// which rules out values such as -273°C and 255°C reported by broken sensors, and fans cannot spin backwards.
+func DefaultWatchdog() Watchdog {
+	return Watchdog{
+		Discover:       true,
+		MissingFor:     DefaultWatchdogMissingFor,
+		StaleKinds:     []sensors.Kind{sensors.KindTemperature, sensors.KindFan},
+		ImplausibleFor: DefaultWatchdogImplausibleFor,
+		Ranges: map[sensors.Kind]*Range{
+			sensors.KindTemperature: {Min: ptr(-50.0), Max: ptr(150.0)},
+			sensors.KindFan:         {Min: ptr(0.0)},
+		},
+		Severity: SeverityWarning,
+	}
+}
+
+// validate checks the watchdog settings.
+func (w *Watchdog) validate(at func(keys ...any) []any, add problemFunc) {
+	for i, pattern := range w.Expect {
+		validateMatch(pattern, at("expect", i), add)
+	}
+
+	if w.MissingFor < 0 {
+		add(at("missing_for"), "must not be negative")
+	}
+
+	if w.StaleFor < 0 {
+		add(at("stale_for"), "must not be negative")
+	}
+
+	for i, k := range w.StaleKinds {
+		if !slices.Contains(sensors.Kinds(), k) {
+			add(at("stale_kinds", i), "unknown sensor kind %q, must be one of %s", k, joinQuoted(sensors.Kinds()))
+		}
+	}
+
+	if w.ImplausibleFor < 0 {
+		add(at("implausible_for"), "must not be negative")
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(w.Ranges)) {
+		r := w.Ranges[k]
+		switch {
+		case !slices.Contains(sensors.Kinds(), k):
+			add(at("ranges", string(k)), "unknown sensor kind %q, must be one of %s", k, joinQuoted(sensors.Kinds()))
+		case r == nil:
+			add(at("ranges", string(k)), "must have a min or a max")
+		case r.Min != nil && r.Max != nil && *r.Min > *r.Max:
+			add(at("ranges", string(k), "min"), "must not be above the max")
+		}
+	}
+
+	if !slices.Contains(severities, w.Severity) {
+		add(at("severity"), "unknown severity %q, must be one of %s", w.Severity, joinQuoted(severities))
+	}
+}
+
+// ptr returns a pointer to the value.
+func ptr[T any](v T) *T {
+	return &v
+}
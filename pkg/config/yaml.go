@@ -0,0 +1,199 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Duration is a time.Duration that is written in configuration files as a Go duration string, e.g. "500ms".
+type Duration time.Duration
+
+// UnmarshalYAML parses a duration string.
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return typeError(node, "expected a duration such as \"30s\"")
+	}
+
+	parsed, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return typeError(node, fmt.Sprintf("invalid duration %q", node.Value))
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalYAML writes the duration as a Go duration string.
+func (d Duration) MarshalYAML() (any, error) {
+	return time.Duration(d).String(), nil
+}
+
+// Std returns the duration as a time.Duration.
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// typeError creates a decoding error for the given node, prefixed with its line number like the errors produced by
+// the yaml package itself.
+func typeError(node *yaml.Node, msg string) error {
+	return &yaml.TypeError{
+		Errors: []string{fmt.Sprintf("line %d: %s", node.Line, msg)},
+	}
+}
+
+// checkFields walks the document and reports every mapping key that does not correspond to a field of the type it is
+// decoded into. This is done up front, rather than with yaml.Decoder.KnownFields, because types that fill in their
+// own defaults decode through yaml.Node.Decode, which is never strict.
+func checkFields(node *yaml.Node, t reflect.Type) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if node.Kind == yaml.DocumentNode {
+		problems := make([]string, 0)
+		for _, child := range node.Content {
+			problems = append(problems, checkFields(child, t)...)
+		}
+		return problems
+	}
+
+	problems := make([]string, 0)
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			// Scalar types such as Duration are structs or named types decoded by their own unmarshaler.
+			return problems
+		}
+
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("line %d: unknown field %q in %s", key.Line, key.Value, typeName(t)))
+				continue
+			}
+
+			problems = append(problems, checkFields(value, field)...)
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return problems
+		}
+
+		for _, child := range node.Content {
+			problems = append(problems, checkFields(child, t.Elem())...)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return problems
+		}
+
+		for i := 1; i < len(node.Content); i += 2 {
+			problems = append(problems, checkFields(node.Content[i], t.Elem())...)
+		}
+	default:
+	}
+
+	return problems
+}
+
+// yamlFields returns the yaml key of every field of the struct type, following inlined structs.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tag := f.Tag.Get("yaml")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+
+		if strings.Contains(opts, "inline") {
+			for k, v := range yamlFields(f.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+
+		fields[name] = f.Type
+	}
+
+	return fields
+}
+
+// typeName returns the name used for a configuration section in error messages.
+func typeName(t reflect.Type) string {
+	return strings.ToLower(t.Name())
+}
+
+// lineOf returns the line of the node at the given path of mapping keys and sequence indexes. If the path does not
+// exist, because the value was defaulted, the line of the closest parent is returned.
+func lineOf(node *yaml.Node, path ...any) int {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	line := node.Line
+	for _, p := range path {
+		var next *yaml.Node
+		switch p := p.(type) {
+		case string:
+			if node.Kind != yaml.MappingNode {
+				return line
+			}
+
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == p {
+					next = node.Content[i+1]
+					break
+				}
+			}
+		case int:
+			if node.Kind != yaml.SequenceNode || p < 0 || p >= len(node.Content) {
+				return line
+			}
+
+			next = node.Content[p]
+		}
+
+		if next == nil {
+			return line
+		}
+
+		node = next
+		line = node.Line
+	}
+
+	return line
+}
+
+// formatPath formats a path of mapping keys and sequence indexes, e.g. "rules[2].match".
+func formatPath(path ...any) string {
+	var b strings.Builder
+	for _, p := range path {
+		switch p := p.(type) {
+		case string:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(p)
+		case int:
+			fmt.Fprintf(&b, "[%d]", p)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,353 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite" // Registers the sqlite driver.
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// sensor identifies a sensor together with what it describes.
+type sensor struct {
+	chip    string
+	adapter string
+	feature string
+	kind    sensors.Kind
+}
+
+// reading is the value of a sensor at a poll.
+type reading struct {
+	sensor sensor
+	time   time.Time
+	value  float64
+}
+
+// transition is an alert transition.
+type transition struct {
+	sensor sensor
+	event  *alert.Event
+}
+
+// notification is a notification sent to a notifier.
+type notification struct {
+	sensor   sensor
+	time     time.Time
+	notifier string
+	n        *alert.Notification
+	err      error
+}
+
+// Store keeps the history of the monitor in a SQLite database: the value of every sensor at every poll, every alert
+// transition and every notification sent. Rows are buffered and written in batches. Readings are rolled up into the
+// minimum, average and maximum of every minute and every hour, so that they can be kept for longer at a coarser
+// resolution, and deleted once they are older than their retention. It is safe for concurrent use, e.g. notifications
+// recorded while readings are written.
+type Store struct {
+	db    *sql.DB
+	cfg   config.History
+	clock alert.Clock
+
+	// ids holds the id of every sensor written, keyed by chip and feature.
+	ids map[[2]string]int64
+
+	// mu guards the rows buffered and the state of flushing them.
+	mu sync.Mutex
+
+	readings      []*reading
+	transitions   []*transition
+	notifications []*notification
+
+	// dropped counts the readings dropped because writing them failed since they were last written.
+	dropped int
+
+	flushedAt  time.Time
+	rolledUpAt time.Time
+}
+
+// Open opens the history store at the path of the configuration, creating it and migrating its schema as needed.
+func Open(ctx context.Context, cfg config.History, clock alert.Clock) (*Store, error) {
+	// Writers wait for each other rather than failing, and the write ahead log lets readers such as the sqlite3 shell
+	// look at the history while the monitor writes to it.
+	query := url.Values{"_pragma": {"busy_timeout(5000)", "journal_mode(WAL)", "foreign_keys(1)"}}
+	db, err := sql.Open("sqlite", cfg.Path+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+
+	// SQLite only allows one writer at a time.
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(ctx, db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to migrate history: %w", err)
+	}
+
+	now := clock.Now()
+	return &Store{
+		db:         db,
+		cfg:        cfg,
+		clock:      clock,
+		ids:        make(map[[2]string]int64),
+		flushedAt:  now,
+		rolledUpAt: now,
+	}, nil
+}
+
+// Close writes everything buffered and closes the database.
+func (s *Store) Close(ctx context.Context) error {
+	return errors.Join(s.Flush(ctx, true), s.db.Close())
+}
+
+// Record buffers the value of every reading of the snapshot and the alert transitions of its evaluation, then writes
+// them if the flush interval has passed or a batch is full.
+func (s *Store) Record(ctx context.Context, snapshot *sensors.Snapshot, events []*alert.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range snapshot.Readings {
+		v, ok := r.Input()
+		if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+
+		s.readings = append(s.readings, &reading{sensor: sensorOf(r), time: snapshot.Time, value: v})
+	}
+
+	for _, e := range events {
+		s.transitions = append(s.transitions, &transition{sensor: sensorOf(e.Reading), event: e})
+	}
+
+	return s.flush(ctx, false)
+}
+
+// RecordNotification buffers a notification sent to a notifier, with the error sending it failed with. It is written
+// by the next flush.
+func (s *Store) RecordNotification(notifier string, n *alert.Notification, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.notifications = append(s.notifications, &notification{
+		sensor:   sensorOf(n.Reading),
+		time:     s.clock.Now(),
+		notifier: notifier,
+		n:        n,
+		err:      err,
+	})
+}
+
+// Flush writes everything buffered if the flush interval has passed or a batch is full, then rolls up readings and
+// deletes expired data if the rollup interval has passed. When force is true everything is done regardless, e.g. when
+// the monitor is shutting down.
+func (s *Store) Flush(ctx context.Context, force bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.flush(ctx, force)
+}
+
+// flush flushes like Flush, with the lock held.
+func (s *Store) flush(ctx context.Context, force bool) error {
+	now := s.clock.Now()
+	rollup := force || now.Sub(s.rolledUpAt) >= s.cfg.RollupInterval.Std()
+
+	// Everything buffered is written before rolling up, so that no reading is left out of its rollup.
+	buffered := len(s.readings) + len(s.transitions) + len(s.notifications)
+	if rollup || buffered >= s.cfg.BatchSize || now.Sub(s.flushedAt) >= s.cfg.FlushInterval.Std() {
+		s.flushedAt = now
+		if err := s.write(ctx); err != nil {
+			return s.keep(err)
+		}
+	}
+
+	if rollup {
+		s.rolledUpAt = now
+		if err := s.rollup(ctx, now); err != nil {
+			return fmt.Errorf("failed to roll up history: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// keep keeps the rows that could not be written to be written by the next flush, dropping the oldest readings once
+// there are more than a batch of them.
+func (s *Store) keep(err error) error {
+	if over := len(s.readings) - s.cfg.BatchSize; over > 0 {
+		s.dropped += over
+		s.readings = slices.Delete(s.readings, 0, over)
+	}
+
+	buffered := len(s.readings) + len(s.transitions) + len(s.notifications)
+	if s.dropped > 0 {
+		return fmt.Errorf("failed to write history: %w (%d rows buffered, %d readings dropped)", err, buffered, s.dropped)
+	}
+	return fmt.Errorf("failed to write history: %w (%d rows buffered)", err, buffered)
+}
+
+// write writes everything buffered in one transaction.
+func (s *Store) write(ctx context.Context) error {
+	if len(s.readings)+len(s.transitions)+len(s.notifications) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // Rolling back a committed transaction is a no-op.
+
+	// The ids of sensors first written by the transaction are only kept once it commits.
+	ids := make(map[[2]string]int64)
+	id := func(sen sensor) (int64, error) {
+		key := [2]string{sen.chip, sen.feature}
+		if id, ok := s.ids[key]; ok {
+			return id, nil
+		} else if id, ok := ids[key]; ok {
+			return id, nil
+		}
+
+		var id int64
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO sensors (chip, adapter, feature, kind) VALUES (?, ?, ?, ?)
+			ON CONFLICT (chip, feature) DO UPDATE SET adapter = excluded.adapter, kind = excluded.kind
+			RETURNING id`,
+			sen.chip, sen.adapter, sen.feature, string(sen.kind),
+		).Scan(&id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to write sensor: %w", err)
+		}
+
+		ids[key] = id
+		return id, nil
+	}
+
+	if err := writeReadings(ctx, tx, id, s.readings); err != nil {
+		return err
+	}
+	if err := writeTransitions(ctx, tx, id, s.transitions); err != nil {
+		return err
+	}
+	if err := writeNotifications(ctx, tx, id, s.notifications); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for key, id := range ids {
+		s.ids[key] = id
+	}
+
+	s.readings = nil
+	s.transitions = nil
+	s.notifications = nil
+	s.dropped = 0
+	return nil
+}
+
+// writeReadings inserts readings. A sensor read twice at the same time, e.g. by two sources, keeps its first value.
+func writeReadings(ctx context.Context, tx *sql.Tx, id func(sensor) (int64, error), readings []*reading) error {
+	stmt, err := tx.PrepareContext(ctx, "INSERT OR IGNORE INTO readings (sensor_id, time, value) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range readings {
+		sensorID, err := id(r.sensor)
+		if err != nil {
+			return err
+		}
+
+		if _, err := stmt.ExecContext(ctx, sensorID, r.time.UnixMilli(), r.value); err != nil {
+			return fmt.Errorf("failed to write reading: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// writeTransitions inserts alert transitions.
+func writeTransitions(
+	ctx context.Context, tx *sql.Tx, id func(sensor) (int64, error), transitions []*transition,
+) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO alert_transitions
+			(time, sensor_id, rule, from_state, to_state, severity, value, threshold, reason)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, t := range transitions {
+		sensorID, err := id(t.sensor)
+		if err != nil {
+			return err
+		}
+
+		e := t.event
+		_, err = stmt.ExecContext(ctx,
+			e.Time.UnixMilli(), sensorID, e.Rule.Name, e.From.String(), e.State.String(), e.Severity.String(),
+			e.Value, e.Threshold, e.Reason,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write alert transition: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// writeNotifications inserts notifications.
+func writeNotifications(
+	ctx context.Context, tx *sql.Tx, id func(sensor) (int64, error), notifications []*notification,
+) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO notifications
+			(time, notifier, sensor_id, rule, state, severity, reminder, suppressed, message, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, n := range notifications {
+		sensorID, err := id(n.sensor)
+		if err != nil {
+			return err
+		}
+
+		var sendErr string
+		if n.err != nil {
+			sendErr = n.err.Error()
+		}
+
+		_, err = stmt.ExecContext(ctx,
+			n.time.UnixMilli(), n.notifier, sensorID, n.n.Rule.Name, n.n.State.String(), n.n.Severity.String(),
+			n.n.Reminder, n.n.Suppressed, n.n.Message(), sendErr,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write notification: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// sensorOf returns the sensor of a reading.
+func sensorOf(r *sensors.Reading) sensor {
+	return sensor{chip: r.Chip, adapter: r.Adapter, feature: r.Name, kind: r.Kind}
+}
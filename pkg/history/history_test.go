@@ -0,0 +1,283 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// stepClock is a clock that only moves when told to.
+type stepClock struct {
+	now time.Time
+}
+
+func (c *stepClock) Now() time.Time {
+	return c.now
+}
+
+// start is when the tests start, on the hour.
+var start = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// openStore opens a store in a temporary directory with the default settings, timed by the returned clock.
+func openStore(t *testing.T, modify func(cfg *config.History)) (*Store, *stepClock) {
+	t.Helper()
+
+	cfg := config.DefaultHistory()
+	cfg.Path = filepath.Join(t.TempDir(), "history.db")
+	if modify != nil {
+		modify(&cfg)
+	}
+
+	clock := &stepClock{now: start}
+	s, err := Open(context.Background(), cfg, clock)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = s.db.Close()
+	})
+
+	return s, clock
+}
+
+// snapshot returns a snapshot of a core temperature at the time.
+func snapshot(at time.Time, temp float64) *sensors.Snapshot {
+	return sensors.NewSnapshot(at, []*sensors.Chip{
+		{
+			Name:    "coretemp-isa-0000",
+			Adapter: "ISA adapter",
+			Features: []*sensors.Feature{
+				{Name: "Core 0", Kind: sensors.KindTemperature, Values: map[sensors.Subfeature]float64{
+					sensors.SubfeatureInput: temp,
+					sensors.SubfeatureCrit:  100,
+				}},
+			},
+		},
+	})
+}
+
+// count returns the number of rows of a table.
+func count(t *testing.T, db *sql.DB, table string) int {
+	t.Helper()
+
+	var n int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM "+table).Scan(&n))
+	return n
+}
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+
+	s, _ := openStore(t, nil)
+
+	var version int
+	require.NoError(t, s.db.QueryRow("PRAGMA user_version").Scan(&version))
+	require.Equal(t, 1, version)
+
+	// Opening the store again leaves the migrated schema as it is.
+	cfg := s.cfg
+	require.NoError(t, s.db.Close())
+	s, err := Open(context.Background(), cfg, &stepClock{now: start})
+	require.NoError(t, err)
+
+	// A schema written by a newer monitor is not touched.
+	_, err = s.db.Exec("PRAGMA user_version = 99")
+	require.NoError(t, err)
+	require.NoError(t, s.db.Close())
+
+	_, err = Open(context.Background(), cfg, &stepClock{now: start})
+	require.EqualError(t, err, "failed to migrate history: schema version 99 is newer than the latest known version 1")
+}
+
+func TestStore_Record(t *testing.T) {
+	t.Parallel()
+
+	s, clock := openStore(t, func(cfg *config.History) {
+		cfg.BatchSize = 4
+	})
+
+	evaluator := alert.NewEvaluator([]*config.Rule{
+		{Name: "cpu", Match: "*", Critical: &config.Threshold{Offset: 95}},
+	}, alert.WithClock(clock))
+	snap := snapshot(clock.now, 97)
+	events := evaluator.Evaluate(snap)
+	require.Len(t, events, 1)
+
+	// Rows are buffered until the flush interval has passed.
+	require.NoError(t, s.Record(context.Background(), snap, events))
+	s.RecordNotification("desktop", &alert.Notification{Event: events[0]}, nil)
+	s.RecordNotification("slack", &alert.Notification{Event: events[0]}, errors.New("connection refused"))
+	require.Zero(t, count(t, s.db, "readings"))
+
+	clock.now = clock.now.Add(10 * time.Second)
+	require.NoError(t, s.Record(context.Background(), snapshot(clock.now, 90), nil))
+	require.Equal(t, 2, count(t, s.db, "readings"))
+	require.Equal(t, 1, count(t, s.db, "sensors"))
+
+	var transition struct {
+		rule, from, to, severity, reason string
+		value, threshold                 float64
+	}
+	require.NoError(t, s.db.QueryRow(`
+		SELECT rule, from_state, to_state, severity, value, threshold, reason FROM alert_transitions`,
+	).Scan(
+		&transition.rule, &transition.from, &transition.to, &transition.severity,
+		&transition.value, &transition.threshold, &transition.reason,
+	))
+	require.Equal(t, "cpu", transition.rule)
+	require.Equal(t, "ok", transition.from)
+	require.Equal(t, "firing", transition.to)
+	require.Equal(t, "critical", transition.severity)
+	require.InDelta(t, 97.0, transition.value, 0.001)
+	require.InDelta(t, 95.0, transition.threshold, 0.001)
+
+	rows, err := s.db.Query("SELECT notifier, state, error FROM notifications ORDER BY id")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	notifications := make([]string, 0)
+	for rows.Next() {
+		var notifier, state, sendErr string
+		require.NoError(t, rows.Scan(&notifier, &state, &sendErr))
+		notifications = append(notifications, notifier+" "+state+" "+sendErr)
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, []string{"desktop firing ", "slack firing connection refused"}, notifications)
+
+	// A full batch is written straight away.
+	for range 3 {
+		clock.now = clock.now.Add(time.Second)
+		require.NoError(t, s.Record(context.Background(), snapshot(clock.now, 90), nil))
+	}
+	require.Equal(t, 2, count(t, s.db, "readings"))
+
+	clock.now = clock.now.Add(time.Second)
+	require.NoError(t, s.Record(context.Background(), snapshot(clock.now, 90), nil))
+	require.Equal(t, 6, count(t, s.db, "readings"))
+}
+
+func TestStore_Record_Failure(t *testing.T) {
+	t.Parallel()
+
+	s, clock := openStore(t, func(cfg *config.History) {
+		cfg.BatchSize = 2
+	})
+	require.NoError(t, s.db.Close())
+
+	// Rows that cannot be written stay buffered, dropping the oldest readings beyond a batch.
+	for range 3 {
+		clock.now = clock.now.Add(10 * time.Second)
+		err := s.Record(context.Background(), snapshot(clock.now, 50), nil)
+		require.ErrorContains(t, err, "failed to write history: sql: database is closed")
+	}
+	require.Len(t, s.readings, 2)
+	require.Equal(t, 1, s.dropped)
+	require.Equal(t, clock.now, s.readings[1].time)
+}
+
+func TestStore_Record_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	s, clock := openStore(t, func(cfg *config.History) {
+		cfg.BatchSize = 1
+	})
+
+	evaluator := alert.NewEvaluator([]*config.Rule{
+		{Name: "cpu", Match: "*", Critical: &config.Threshold{Offset: 95}},
+	}, alert.WithClock(clock))
+	events := evaluator.Evaluate(snapshot(clock.now, 97))
+	require.Len(t, events, 1)
+
+	// Notifications are recorded by the notifiers while the readings of the polls are written.
+	const n = 50
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range n {
+			s.RecordNotification("desktop", &alert.Notification{Event: events[0]}, nil)
+		}
+	}()
+
+	for i := range n {
+		require.NoError(t, s.Record(context.Background(), snapshot(start.Add(time.Duration(i)*time.Second), 50), nil))
+	}
+	<-done
+
+	require.NoError(t, s.Flush(context.Background(), true))
+	require.Equal(t, n, count(t, s.db, "readings"))
+	require.Equal(t, n, count(t, s.db, "notifications"))
+}
+
+func TestStore_Rollup(t *testing.T) {
+	t.Parallel()
+
+	s, clock := openStore(t, func(cfg *config.History) {
+		cfg.Retention.Readings = config.Duration(2 * time.Hour)
+		cfg.Retention.Minute = config.Duration(2 * time.Hour)
+		cfg.Retention.Hour = config.Duration(24 * time.Hour)
+	})
+
+	// Readings every 20s for three hours, rising by one degree a minute.
+	for i := range 3 * 60 * 3 {
+		clock.now = start.Add(time.Duration(i) * 20 * time.Second)
+		temp := 40 + float64(i/3) + float64(i%3)/10
+		require.NoError(t, s.Record(context.Background(), snapshot(clock.now, temp), nil))
+	}
+
+	clock.now = start.Add(3 * time.Hour)
+	require.NoError(t, s.Flush(context.Background(), true))
+
+	type rollup struct {
+		time          int64
+		min, avg, max float64
+		count         int
+	}
+	query := func(table string) []rollup {
+		rows, err := s.db.Query("SELECT time, min, avg, max, count FROM " + table + " ORDER BY time")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		rollups := make([]rollup, 0)
+		for rows.Next() {
+			var r rollup
+			require.NoError(t, rows.Scan(&r.time, &r.min, &r.avg, &r.max, &r.count))
+			rollups = append(rollups, r)
+		}
+		require.NoError(t, rows.Err())
+		return rollups
+	}
+
+	// Every minute of the last two hours is kept, and older readings only survive in the hourly rollups.
+	minutes := query("rollups_1m")
+	require.Len(t, minutes, 120)
+	require.Equal(t, start.Add(time.Hour).UnixMilli(), minutes[0].time)
+	require.InDelta(t, 100.0, minutes[0].min, 0.001)
+	require.InDelta(t, 100.1, minutes[0].avg, 0.001)
+	require.InDelta(t, 100.2, minutes[0].max, 0.001)
+	require.Equal(t, 3, minutes[0].count)
+
+	hours := query("rollups_1h")
+	require.Len(t, hours, 3)
+	for i, h := range hours {
+		require.Equal(t, start.Add(time.Duration(i)*time.Hour).UnixMilli(), h.time)
+		require.InDelta(t, float64(40+60*i), h.min, 0.001)
+		require.InDelta(t, float64(40+60*i)+29.6, h.avg, 0.001)
+		require.InDelta(t, float64(40+60*i)+59.2, h.max, 0.001)
+		require.Equal(t, 180, h.count)
+	}
+
+	require.Equal(t, 3*60*2, count(t, s.db, "readings"))
+
+	// Rolling up again only rolls up the intervals that have ended since.
+	clock.now = clock.now.Add(time.Hour)
+	require.NoError(t, s.Flush(context.Background(), true))
+	require.Len(t, query("rollups_1h"), 3)
+	require.Len(t, query("rollups_1m"), 60)
+}
@@ -0,0 +1,97 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// migrations are the changes to the schema of the store, applied in order. Each file is named after the version of the
+// schema it creates, e.g. 0001_create_history.sql, and must never change once released.
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// migration is a change to the schema.
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// loadMigrations returns every migration, oldest first.
+func loadMigrations() ([]*migration, error) {
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := make([]*migration, 0, len(names))
+	for i, name := range names {
+		prefix, _, _ := strings.Cut(path.Base(name), "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version != i+1 {
+			return nil, fmt.Errorf("migration %s is not numbered %04d", name, i+1)
+		}
+
+		data, err := migrations.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		loaded = append(loaded, &migration{version: version, name: path.Base(name), sql: string(data)})
+	}
+
+	return loaded, nil
+}
+
+// migrate applies the migrations newer than the schema of the database, each in its own transaction. The version of
+// the schema is kept in the user_version of the database.
+func migrate(ctx context.Context, db *sql.DB) error {
+	loaded, err := loadMigrations()
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	if version > len(loaded) {
+		return fmt.Errorf("schema version %d is newer than the latest known version %d", version, len(loaded))
+	}
+
+	for _, m := range loaded[version:] {
+		if err := apply(ctx, db, m); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", m.name, err)
+		}
+	}
+
+	return nil
+}
+
+// apply applies a migration and records its version in the same transaction.
+func apply(ctx context.Context, db *sql.DB, m *migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // Rolling back a committed transaction is a no-op.
+
+	if _, err := tx.ExecContext(ctx, m.sql); err != nil {
+		return err
+	}
+
+	// PRAGMA statements do not take parameters.
+	if _, err := tx.ExecContext(ctx, "PRAGMA user_version = "+strconv.Itoa(m.version)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
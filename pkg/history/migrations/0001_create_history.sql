@@ -0,0 +1,83 @@
+-- Sensors are every sensor that has been read. Readings, rollups and alerts refer to them by id.
+CREATE TABLE sensors (
+    id      INTEGER PRIMARY KEY,
+    chip    TEXT NOT NULL,
+    adapter TEXT NOT NULL,
+    feature TEXT NOT NULL,
+    kind    TEXT NOT NULL,
+    UNIQUE (chip, feature)
+);
+
+-- Readings are the value of every sensor at every poll. Times are in milliseconds since the epoch.
+CREATE TABLE readings (
+    sensor_id INTEGER NOT NULL REFERENCES sensors (id),
+    time      INTEGER NOT NULL,
+    value     REAL    NOT NULL,
+    PRIMARY KEY (sensor_id, time)
+) WITHOUT ROWID;
+
+CREATE INDEX readings_time ON readings (time);
+
+-- Rollups are the minimum, average and maximum of every sensor for each minute, and each hour, starting at time.
+CREATE TABLE rollups_1m (
+    sensor_id INTEGER NOT NULL REFERENCES sensors (id),
+    time      INTEGER NOT NULL,
+    min       REAL    NOT NULL,
+    avg       REAL    NOT NULL,
+    max       REAL    NOT NULL,
+    count     INTEGER NOT NULL,
+    PRIMARY KEY (sensor_id, time)
+) WITHOUT ROWID;
+
+CREATE INDEX rollups_1m_time ON rollups_1m (time);
+
+CREATE TABLE rollups_1h (
+    sensor_id INTEGER NOT NULL REFERENCES sensors (id),
+    time      INTEGER NOT NULL,
+    min       REAL    NOT NULL,
+    avg       REAL    NOT NULL,
+    max       REAL    NOT NULL,
+    count     INTEGER NOT NULL,
+    PRIMARY KEY (sensor_id, time)
+) WITHOUT ROWID;
+
+CREATE INDEX rollups_1h_time ON rollups_1h (time);
+
+-- Watermarks are the time up to which each rollup table has been filled in.
+CREATE TABLE watermarks (
+    rollup TEXT    PRIMARY KEY,
+    time   INTEGER NOT NULL
+);
+
+-- Alert transitions are every move of an alert from one state to another.
+CREATE TABLE alert_transitions (
+    id         INTEGER PRIMARY KEY,
+    time       INTEGER NOT NULL,
+    sensor_id  INTEGER NOT NULL REFERENCES sensors (id),
+    rule       TEXT    NOT NULL,
+    from_state TEXT    NOT NULL,
+    to_state   TEXT    NOT NULL,
+    severity   TEXT    NOT NULL,
+    value      REAL    NOT NULL,
+    threshold  REAL    NOT NULL,
+    reason     TEXT    NOT NULL
+);
+
+CREATE INDEX alert_transitions_time ON alert_transitions (time);
+
+-- Notifications are every notification sent to a notifier. Error is empty unless sending it failed.
+CREATE TABLE notifications (
+    id         INTEGER PRIMARY KEY,
+    time       INTEGER NOT NULL,
+    notifier   TEXT    NOT NULL,
+    sensor_id  INTEGER NOT NULL REFERENCES sensors (id),
+    rule       TEXT    NOT NULL,
+    state      TEXT    NOT NULL,
+    severity   TEXT    NOT NULL,
+    reminder   INTEGER NOT NULL,
+    suppressed INTEGER NOT NULL,
+    message    TEXT    NOT NULL,
+    error      TEXT    NOT NULL
+);
+
+CREATE INDEX notifications_time ON notifications (time);
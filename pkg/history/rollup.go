@@ -0,0 +1,101 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// rollup is a table of the minimum, average and maximum of every sensor over intervals of a width, computed from the
+// readings or a finer rollup.
+type rollup struct {
+	table string
+	width time.Duration
+
+	// query selects the rollup of the rows of the source between two times.
+	query string
+}
+
+// rollups are rolled up in order, each from the rows of the one before it.
+var rollups = []*rollup{
+	{
+		table: "rollups_1m",
+		width: time.Minute,
+		query: `
+			SELECT sensor_id, time - time % ?1, MIN(value), AVG(value), MAX(value), COUNT(*)
+			FROM readings
+			WHERE time >= ?2 AND time < ?3
+			GROUP BY 1, 2`,
+	},
+	{
+		table: "rollups_1h",
+		width: time.Hour,
+		query: `
+			SELECT sensor_id, time - time % ?1, MIN(min), SUM(avg * count) / SUM(count), MAX(max), SUM(count)
+			FROM rollups_1m
+			WHERE time >= ?2 AND time < ?3
+			GROUP BY 1, 2`,
+	},
+}
+
+// rollup rolls up the readings of every interval that has ended since the last rollup, then deletes everything older
+// than its retention, in one transaction.
+func (s *Store) rollup(ctx context.Context, now time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // Rolling back a committed transaction is a no-op.
+
+	for _, r := range rollups {
+		if err := r.fill(ctx, tx, now); err != nil {
+			return fmt.Errorf("failed to fill %s: %w", r.table, err)
+		}
+	}
+
+	retention := s.cfg.Retention
+	for table, keep := range map[string]time.Duration{
+		"readings":          retention.Readings.Std(),
+		"rollups_1m":        retention.Minute.Std(),
+		"rollups_1h":        retention.Hour.Std(),
+		"alert_transitions": retention.Events.Std(),
+		"notifications":     retention.Events.Std(),
+	} {
+		// The table names are constants.
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE time < ?", now.Add(-keep).UnixMilli()); err != nil {
+			return fmt.Errorf("failed to delete expired %s: %w", table, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// fill rolls up the intervals that ended between the watermark of the rollup and now, moving the watermark to the end
+// of the last of them. Intervals are only rolled up once they have ended, so each is rolled up once.
+func (r *rollup) fill(ctx context.Context, tx *sql.Tx, now time.Time) error {
+	var from int64
+	err := tx.QueryRowContext(ctx, "SELECT time FROM watermarks WHERE rollup = ?", r.table).Scan(&from)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	to := now.Truncate(r.width).UnixMilli()
+	if to <= from {
+		return nil
+	}
+
+	// The table names are constants.
+	insert := "INSERT OR REPLACE INTO " + r.table + " (sensor_id, time, min, avg, max, count)" + r.query
+	if _, err := tx.ExecContext(ctx, insert, r.width.Milliseconds(), from, to); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO watermarks (rollup, time) VALUES (?, ?)
+		ON CONFLICT (rollup) DO UPDATE SET time = excluded.time`,
+		r.table, to,
+	)
+	return err
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ContentTypeText is the content type of the Prometheus text format.
+	ContentTypeText = "text/plain; version=0.0.4; charset=utf-8"
+
+	// ContentTypeOpenMetrics is the content type of the OpenMetrics text format.
+	ContentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// metricType is the type of a metric family.
+type metricType string
+
+const (
+	typeGauge   metricType = "gauge"
+	typeCounter metricType = "counter"
+	typeSummary metricType = "summary"
+)
+
+// label is a label of a sample.
+type label struct {
+	name  string
+	value string
+}
+
+// sample is a single value of a metric family.
+type sample struct {
+	// suffix is appended to the name of the family, e.g. "_sum" for the sum of a summary.
+	suffix string
+	labels []label
+	value  float64
+}
+
+// family is a metric family and its samples.
+type family struct {
+	name    string
+	help    string
+	typ     metricType
+	samples []sample
+}
+
+// add adds a sample with the given labels and value to the family.
+func (f *family) add(value float64, labels ...label) {
+	f.samples = append(f.samples, sample{labels: labels, value: value})
+}
+
+// write writes the families in the Prometheus text format, or in the OpenMetrics text format when openMetrics is
+// true. The formats only differ in how counters are named and in the end of the exposition.
+func write(w io.Writer, families []*family, openMetrics bool) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		name := f.name
+		if openMetrics && f.typ == typeCounter {
+			// OpenMetrics names counter families without the _total suffix of their samples.
+			name = strings.TrimSuffix(name, "_total")
+		}
+
+		bw.WriteString("# HELP " + name + " " + helpEscaper.Replace(f.help) + "\n")
+		bw.WriteString("# TYPE " + name + " " + string(f.typ) + "\n")
+		for _, s := range f.samples {
+			bw.WriteString(f.name + s.suffix)
+			if len(s.labels) > 0 {
+				bw.WriteByte('{')
+				for i, l := range s.labels {
+					if i > 0 {
+						bw.WriteByte(',')
+					}
+					bw.WriteString(l.name + `="` + labelEscaper.Replace(l.value) + `"`)
+				}
+				bw.WriteByte('}')
+			}
+			bw.WriteString(" " + formatValue(s.value) + "\n")
+		}
+	}
+
+	if openMetrics {
+		bw.WriteString("# EOF\n")
+	}
+
+	return bw.Flush()
+}
+
+// helpEscaper escapes the help text of a family.
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// labelEscaper escapes the value of a label.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// formatValue formats the value of a sample.
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
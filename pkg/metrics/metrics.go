@@ -0,0 +1,363 @@
+// Package metrics exposes the sensor readings, alerts and health of the monitor to Prometheus.
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// units are the Prometheus base units of the kinds of sensors, used as the suffix of their metric names.
+var units = map[sensors.Kind]string{
+	sensors.KindTemperature: "celsius",
+	sensors.KindFan:         "rpm",
+	sensors.KindVoltage:     "volts",
+	sensors.KindCurrent:     "amps",
+	sensors.KindPower:       "watts",
+	sensors.KindEnergy:      "joules",
+	sensors.KindHumidity:    "percent",
+	sensors.KindCooling:     "state",
+}
+
+// names are the names of the kinds of sensors used in metric names.
+var names = map[sensors.Kind]string{
+	sensors.KindTemperature: "temperature",
+	sensors.KindFan:         "fan",
+	sensors.KindVoltage:     "voltage",
+	sensors.KindCurrent:     "current",
+	sensors.KindPower:       "power",
+	sensors.KindEnergy:      "energy",
+	sensors.KindHumidity:    "humidity",
+	sensors.KindCooling:     "cooling",
+}
+
+// limits are the hardware limits exposed as their own series, in the order they are written.
+var limits = []sensors.Subfeature{
+	sensors.SubfeatureMin,
+	sensors.SubfeatureMax,
+	sensors.SubfeatureCrit,
+	"lcrit",
+	"emergency",
+	sensors.SubfeaturePassive,
+	sensors.SubfeatureHot,
+}
+
+// alarms are the alarm flags exposed by the hardware.
+var alarms = []sensors.Subfeature{
+	sensors.SubfeatureAlarm,
+	sensors.SubfeatureCritAlarm,
+}
+
+// alertStates are the states of alerts counted by the exporter. Alerts that are ok are not tracked.
+var alertStates = []alert.State{
+	alert.StatePending,
+	alert.StateFiring,
+	alert.StateResolved,
+}
+
+// alertSample is the state of an alert when it was observed. Alerts are changed by every evaluation, so their state
+// is copied rather than kept.
+type alertSample struct {
+	rule     string
+	chip     string
+	feature  string
+	state    alert.State
+	severity alert.Severity
+}
+
+// healthSample is the health of a source when it was observed.
+type healthSample struct {
+	source      string
+	up          bool
+	failures    int
+	lastSuccess time.Time
+}
+
+// Exporter collects the latest readings, alerts and health of the monitor and serves them to Prometheus.
+type Exporter struct {
+	mu sync.Mutex
+
+	// snapshot is the latest snapshot of the sensors.
+	snapshot *sensors.Snapshot
+
+	// alerts are the alerts that were pending, firing or resolved at the latest evaluation.
+	alerts []alertSample
+
+	// transitions counts the alerts that moved into each state.
+	transitions map[alert.State]int
+
+	// reads, readSeconds and readErrors count the reads of the sensors, how long they took and how many failed.
+	reads       int
+	readSeconds float64
+	readErrors  int
+
+	// lastRead is when the sensors were last read successfully.
+	lastRead time.Time
+
+	// health is the health of every source at the latest read.
+	health []healthSample
+}
+
+// NewExporter creates an exporter with nothing observed yet.
+func NewExporter() *Exporter {
+	return &Exporter{
+		transitions: make(map[alert.State]int),
+	}
+}
+
+// ObserveRead records a read of the sensors that took the given time, and failed if err is not nil.
+func (e *Exporter) ObserveRead(at time.Time, took time.Duration, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.reads++
+	e.readSeconds += took.Seconds()
+	if err != nil {
+		e.readErrors++
+		return
+	}
+
+	e.lastRead = at
+}
+
+// ObserveHealth records the health of every source after a read.
+func (e *Exporter) ObserveHealth(health []*sensors.Health) {
+	samples := make([]healthSample, 0, len(health))
+	for _, h := range health {
+		samples = append(samples, healthSample{
+			source:      h.Source,
+			up:          h.Status() != sensors.StatusFailing,
+			failures:    h.ConsecutiveFailures,
+			lastSuccess: h.LastSuccess,
+		})
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.health = samples
+}
+
+// ObserveSnapshot records the latest readings of the sensors.
+func (e *Exporter) ObserveSnapshot(snapshot *sensors.Snapshot) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.snapshot = snapshot
+}
+
+// ObserveAlerts records the transitions of an evaluation and the alerts it left.
+func (e *Exporter) ObserveAlerts(events []*alert.Event, alerts []*alert.Alert) {
+	samples := make([]alertSample, 0, len(alerts))
+	for _, a := range alerts {
+		samples = append(samples, alertSample{
+			rule:     a.Rule.Name,
+			chip:     a.Reading.Chip,
+			feature:  a.Reading.Name,
+			state:    a.State,
+			severity: a.Severity,
+		})
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.alerts = samples
+	for _, event := range events {
+		e.transitions[event.State]++
+	}
+}
+
+// ServeHTTP writes the metrics in the OpenMetrics format if the scraper accepts it, or the Prometheus text format
+// otherwise.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+
+	contentType := ContentTypeText
+	if openMetrics {
+		contentType = ContentTypeOpenMetrics
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	_ = e.Write(w, openMetrics) //nolint:errcheck // The scraper has gone away, there is nobody to tell.
+}
+
+// Write writes the metrics in the Prometheus text format, or in the OpenMetrics format when openMetrics is true.
+func (e *Exporter) Write(w io.Writer, openMetrics bool) error {
+	e.mu.Lock()
+	families := e.families()
+	e.mu.Unlock()
+
+	return write(w, families, openMetrics)
+}
+
+// families builds the metric families of everything observed.
+func (e *Exporter) families() []*family {
+	families := e.sensorFamilies()
+
+	alertFamily := &family{
+		name: "sensor_alert",
+		help: "Alerts that are pending, firing or have just resolved, by rule and sensor.",
+		typ:  typeGauge,
+	}
+	counts := make(map[alert.State]int, len(alertStates))
+	for _, a := range e.alerts {
+		counts[a.state]++
+		alertFamily.add(1,
+			label{"rule", a.rule},
+			label{"chip", a.chip},
+			label{"feature", a.feature},
+			label{"state", a.state.String()},
+			label{"severity", a.severity.String()},
+		)
+	}
+
+	alertsFamily := &family{name: "sensor_alerts", help: "Number of alerts in each state.", typ: typeGauge}
+	transitionsFamily := &family{
+		name: "sensor_alert_transitions_total",
+		help: "Number of times alerts moved into each state.",
+		typ:  typeCounter,
+	}
+	for _, state := range alertStates {
+		alertsFamily.add(float64(counts[state]), label{"state", state.String()})
+		transitionsFamily.add(float64(e.transitions[state]), label{"state", state.String()})
+	}
+
+	readingsFamily := &family{
+		name: "sensor_monitor_readings",
+		help: "Number of sensors in the latest read.",
+		typ:  typeGauge,
+	}
+	if e.snapshot != nil {
+		readingsFamily.add(float64(len(e.snapshot.Readings)))
+	}
+
+	readDuration := &family{
+		name: "sensor_monitor_read_duration_seconds",
+		help: "Time taken to read the sensors.",
+		typ:  typeSummary,
+		samples: []sample{
+			{suffix: "_sum", value: e.readSeconds},
+			{suffix: "_count", value: float64(e.reads)},
+		},
+	}
+
+	readErrors := &family{
+		name: "sensor_monitor_read_errors_total",
+		help: "Number of failed reads of the sensors.",
+		typ:  typeCounter,
+	}
+	readErrors.add(float64(e.readErrors))
+
+	lastRead := &family{
+		name: "sensor_monitor_last_read_timestamp_seconds",
+		help: "When the sensors were last read successfully, in seconds since the epoch.",
+		typ:  typeGauge,
+	}
+	if !e.lastRead.IsZero() {
+		lastRead.add(float64(e.lastRead.UnixMilli()) / 1000)
+	}
+
+	families = append(families,
+		alertFamily,
+		alertsFamily,
+		transitionsFamily,
+		readingsFamily,
+		readDuration,
+		readErrors,
+		lastRead,
+	)
+	return append(families, e.healthFamilies()...)
+}
+
+// healthFamilies builds the families of the health of every source.
+func (e *Exporter) healthFamilies() []*family {
+	up := &family{
+		name: "sensor_monitor_source_up",
+		help: "Whether any chips of a source could be read at the latest read.",
+		typ:  typeGauge,
+	}
+	failures := &family{
+		name: "sensor_monitor_source_consecutive_failures",
+		help: "Number of reads of a source in a row that returned no chips.",
+		typ:  typeGauge,
+	}
+	lastSuccess := &family{
+		name: "sensor_monitor_source_last_success_timestamp_seconds",
+		help: "When any chips of a source were last read, in seconds since the epoch.",
+		typ:  typeGauge,
+	}
+
+	for _, h := range e.health {
+		value := 0.0
+		if h.up {
+			value = 1
+		}
+		up.add(value, label{"source", h.source})
+		failures.add(float64(h.failures), label{"source", h.source})
+		if !h.lastSuccess.IsZero() {
+			lastSuccess.add(float64(h.lastSuccess.UnixMilli())/1000, label{"source", h.source})
+		}
+	}
+
+	return []*family{up, failures, lastSuccess}
+}
+
+// sensorFamilies builds the families of the readings of the latest snapshot: one family for the values of each kind
+// of sensor, one for each hardware limit of each kind, and one for the alarm flags.
+func (e *Exporter) sensorFamilies() []*family {
+	if e.snapshot == nil {
+		return nil
+	}
+
+	families := make([]*family, 0)
+	byName := make(map[string]*family)
+	get := func(name, help string) *family {
+		f, ok := byName[name]
+		if !ok {
+			f = &family{name: name, help: help, typ: typeGauge}
+			byName[name] = f
+			families = append(families, f)
+		}
+		return f
+	}
+
+	// The same sensor may be reported by more than one source. Only the first is kept, as a series may only appear
+	// once.
+	seen := make(map[string]bool)
+	for _, r := range e.snapshot.Readings {
+		kind, ok := names[r.Kind]
+		if !ok || seen[r.ID()] {
+			continue
+		}
+		seen[r.ID()] = true
+
+		labels := []label{{"chip", r.Chip}, {"adapter", r.Adapter}, {"feature", r.Name}}
+		unit := units[r.Kind]
+		if v, ok := r.Input(); ok {
+			get("sensor_"+kind+"_"+unit, "Current value of "+kind+" sensors.").add(v, labels...)
+		}
+
+		for _, limit := range limits {
+			if v, ok := r.Values[limit]; ok {
+				name := "sensor_" + kind + "_" + string(limit) + "_" + unit
+				get(name, "The "+string(limit)+" limit of "+kind+" sensors reported by the hardware.").add(v, labels...)
+			}
+		}
+
+		for _, alarm := range alarms {
+			if v, ok := r.Values[alarm]; ok {
+				f := get("sensor_alarm", "Whether the hardware has raised an alarm for a sensor.")
+				f.add(v, slices.Concat(labels, []label{{"kind", r.Kind.String()}, {"alarm", string(alarm)}})...)
+			}
+		}
+	}
+
+	return families
+}
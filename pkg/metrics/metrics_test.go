@@ -0,0 +1,220 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// newExporter returns an exporter that has observed two reads, one failed, the health of a working and a failing
+// source, and an evaluation with a firing alert.
+func newExporter() *Exporter {
+	core := &sensors.Reading{
+		Chip:    "coretemp-isa-0000",
+		Adapter: "ISA adapter",
+		Feature: &sensors.Feature{
+			Name: "Core 0",
+			Kind: sensors.KindTemperature,
+			Values: map[sensors.Subfeature]float64{
+				sensors.SubfeatureInput:     91,
+				sensors.SubfeatureMax:       80,
+				sensors.SubfeatureCrit:      100,
+				sensors.SubfeatureCritAlarm: 0,
+			},
+		},
+	}
+	fan := &sensors.Reading{
+		Chip:    "dell_smm-virtual-0",
+		Adapter: "Virtual device",
+		Feature: &sensors.Feature{
+			Name:   `fan "left"`,
+			Kind:   sensors.KindFan,
+			Values: map[sensors.Subfeature]float64{sensors.SubfeatureInput: 2400, sensors.SubfeatureMin: 500},
+		},
+	}
+	unknown := &sensors.Reading{
+		Chip:    "acpitz-acpi-0",
+		Feature: &sensors.Feature{Name: "beep_enable", Values: map[sensors.Subfeature]float64{sensors.SubfeatureInput: 1}},
+	}
+
+	firing := &alert.Alert{
+		Reading:  core,
+		Rule:     &config.Rule{Name: "cores"},
+		State:    alert.StateFiring,
+		Severity: alert.SeverityWarning,
+	}
+
+	e := NewExporter()
+	e.ObserveRead(time.Unix(1748779200, 0), 250*time.Millisecond, nil)
+	e.ObserveRead(time.Unix(1748779201, 0), 500*time.Millisecond, errors.New("failed to read hwmon"))
+	e.ObserveHealth([]*sensors.Health{
+		{Source: "hwmon", LastSuccess: time.Unix(1748779200, 0)},
+		{Source: "lm-sensors", ConsecutiveFailures: 2, Err: errors.New("exit status 1")},
+	})
+	e.ObserveSnapshot(&sensors.Snapshot{Readings: []*sensors.Reading{core, fan, unknown, core}})
+	e.ObserveAlerts([]*alert.Event{{Alert: *firing, From: alert.StatePending}}, []*alert.Alert{firing})
+	return e
+}
+
+func TestExporter_Write(t *testing.T) {
+	t.Parallel()
+
+	const sensorsText = `# HELP sensor_temperature_celsius Current value of temperature sensors.
+# TYPE sensor_temperature_celsius gauge
+sensor_temperature_celsius{chip="coretemp-isa-0000",adapter="ISA adapter",feature="Core 0"} 91
+# HELP sensor_temperature_max_celsius The max limit of temperature sensors reported by the hardware.
+# TYPE sensor_temperature_max_celsius gauge
+sensor_temperature_max_celsius{chip="coretemp-isa-0000",adapter="ISA adapter",feature="Core 0"} 80
+# HELP sensor_temperature_crit_celsius The crit limit of temperature sensors reported by the hardware.
+# TYPE sensor_temperature_crit_celsius gauge
+sensor_temperature_crit_celsius{chip="coretemp-isa-0000",adapter="ISA adapter",feature="Core 0"} 100
+# HELP sensor_alarm Whether the hardware has raised an alarm for a sensor.
+# TYPE sensor_alarm gauge
+sensor_alarm{chip="coretemp-isa-0000",adapter="ISA adapter",feature="Core 0",kind="temp",alarm="crit_alarm"} 0
+# HELP sensor_fan_rpm Current value of fan sensors.
+# TYPE sensor_fan_rpm gauge
+sensor_fan_rpm{chip="dell_smm-virtual-0",adapter="Virtual device",feature="fan \"left\""} 2400
+# HELP sensor_fan_min_rpm The min limit of fan sensors reported by the hardware.
+# TYPE sensor_fan_min_rpm gauge
+sensor_fan_min_rpm{chip="dell_smm-virtual-0",adapter="Virtual device",feature="fan \"left\""} 500
+# HELP sensor_alert Alerts that are pending, firing or have just resolved, by rule and sensor.
+# TYPE sensor_alert gauge
+sensor_alert{rule="cores",chip="coretemp-isa-0000",feature="Core 0",state="firing",severity="warning"} 1
+# HELP sensor_alerts Number of alerts in each state.
+# TYPE sensor_alerts gauge
+sensor_alerts{state="pending"} 0
+sensor_alerts{state="firing"} 1
+sensor_alerts{state="resolved"} 0
+`
+
+	tests := []struct {
+		name        string
+		openMetrics bool
+		want        string
+	}{
+		{
+			name: "prometheus",
+			want: sensorsText + `# HELP sensor_alert_transitions_total Number of times alerts moved into each state.
+# TYPE sensor_alert_transitions_total counter
+sensor_alert_transitions_total{state="pending"} 0
+sensor_alert_transitions_total{state="firing"} 1
+sensor_alert_transitions_total{state="resolved"} 0
+# HELP sensor_monitor_readings Number of sensors in the latest read.
+# TYPE sensor_monitor_readings gauge
+sensor_monitor_readings 4
+# HELP sensor_monitor_read_duration_seconds Time taken to read the sensors.
+# TYPE sensor_monitor_read_duration_seconds summary
+sensor_monitor_read_duration_seconds_sum 0.75
+sensor_monitor_read_duration_seconds_count 2
+# HELP sensor_monitor_read_errors_total Number of failed reads of the sensors.
+# TYPE sensor_monitor_read_errors_total counter
+sensor_monitor_read_errors_total 1
+# HELP sensor_monitor_last_read_timestamp_seconds When the sensors were last read successfully, in seconds since the epoch.
+# TYPE sensor_monitor_last_read_timestamp_seconds gauge
+sensor_monitor_last_read_timestamp_seconds 1.7487792e+09
+# HELP sensor_monitor_source_up Whether any chips of a source could be read at the latest read.
+# TYPE sensor_monitor_source_up gauge
+sensor_monitor_source_up{source="hwmon"} 1
+sensor_monitor_source_up{source="lm-sensors"} 0
+# HELP sensor_monitor_source_consecutive_failures Number of reads of a source in a row that returned no chips.
+# TYPE sensor_monitor_source_consecutive_failures gauge
+sensor_monitor_source_consecutive_failures{source="hwmon"} 0
+sensor_monitor_source_consecutive_failures{source="lm-sensors"} 2
+# HELP sensor_monitor_source_last_success_timestamp_seconds When any chips of a source were last read, in seconds since the epoch.
+# TYPE sensor_monitor_source_last_success_timestamp_seconds gauge
+sensor_monitor_source_last_success_timestamp_seconds{source="hwmon"} 1.7487792e+09
+`,
+		},
+		{
+			name:        "openmetrics",
+			openMetrics: true,
+			want: sensorsText + `# HELP sensor_alert_transitions Number of times alerts moved into each state.
+# TYPE sensor_alert_transitions counter
+sensor_alert_transitions_total{state="pending"} 0
+sensor_alert_transitions_total{state="firing"} 1
+sensor_alert_transitions_total{state="resolved"} 0
+# HELP sensor_monitor_readings Number of sensors in the latest read.
+# TYPE sensor_monitor_readings gauge
+sensor_monitor_readings 4
+# HELP sensor_monitor_read_duration_seconds Time taken to read the sensors.
+# TYPE sensor_monitor_read_duration_seconds summary
+sensor_monitor_read_duration_seconds_sum 0.75
+sensor_monitor_read_duration_seconds_count 2
+# HELP sensor_monitor_read_errors Number of failed reads of the sensors.
+# TYPE sensor_monitor_read_errors counter
+sensor_monitor_read_errors_total 1
+# HELP sensor_monitor_last_read_timestamp_seconds When the sensors were last read successfully, in seconds since the epoch.
+# TYPE sensor_monitor_last_read_timestamp_seconds gauge
+sensor_monitor_last_read_timestamp_seconds 1.7487792e+09
+# HELP sensor_monitor_source_up Whether any chips of a source could be read at the latest read.
+# TYPE sensor_monitor_source_up gauge
+sensor_monitor_source_up{source="hwmon"} 1
+sensor_monitor_source_up{source="lm-sensors"} 0
+# HELP sensor_monitor_source_consecutive_failures Number of reads of a source in a row that returned no chips.
+# TYPE sensor_monitor_source_consecutive_failures gauge
+sensor_monitor_source_consecutive_failures{source="hwmon"} 0
+sensor_monitor_source_consecutive_failures{source="lm-sensors"} 2
+# HELP sensor_monitor_source_last_success_timestamp_seconds When any chips of a source were last read, in seconds since the epoch.
+# TYPE sensor_monitor_source_last_success_timestamp_seconds gauge
+sensor_monitor_source_last_success_timestamp_seconds{source="hwmon"} 1.7487792e+09
+# EOF
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var b bytes.Buffer
+			require.NoError(t, newExporter().Write(&b, tt.openMetrics))
+			require.Equal(t, tt.want, b.String())
+		})
+	}
+}
+
+func TestExporter_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		accept          string
+		wantContentType string
+	}{
+		{
+			name:            "prometheus",
+			accept:          "text/plain;version=0.0.4;q=0.5,*/*;q=0.1",
+			wantContentType: ContentTypeText,
+		},
+		{
+			name:            "openmetrics",
+			accept:          "application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5",
+			wantContentType: ContentTypeOpenMetrics,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody)
+			req.Header.Set("Accept", tt.accept)
+
+			rec := httptest.NewRecorder()
+			NewExporter().ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Equal(t, tt.wantContentType, rec.Header().Get("Content-Type"))
+			require.Contains(t, rec.Body.String(), "sensor_monitor_read_errors_total 0\n")
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package alertmanager
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+)
+
+// AlertName is the alertname label of alerts, unless it is overridden by the configured labels.
+const AlertName = "SensorAlert"
+
+// expiryFactor is how many resend intervals a firing alert lasts for without being sent again. Alertmanager resolves
+// the alerts of a monitor that has stopped once they expire.
+const expiryFactor = 4
+
+// postableAlert is an alert of the /api/v2/alerts endpoint.
+type postableAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"`
+}
+
+// firing is a firing alert and when it was last sent.
+type firing struct {
+	alert  *postableAlert
+	sentAt time.Time
+}
+
+// Notifier sends alerts to Alertmanager, which routes, groups and silences them. Firing alerts are sent again every
+// resend interval so that they do not expire, and resolved alerts are sent with the time they ended.
+type Notifier struct {
+	name   string
+	cfg    *config.Alertmanager
+	host   string
+	header http.Header
+	client *notify.HTTPClient
+
+	// now tells the time alerts are sent at.
+	now func() time.Time
+
+	// mu guards the firing alerts.
+	mu sync.Mutex
+
+	// firing are the firing alerts by the key of their alert.
+	firing map[string]*firing
+}
+
+// New creates an Alertmanager notifier from its configuration.
+func New(cfg *config.Notifier) (notify.Notifier, error) {
+	if cfg.Alertmanager == nil {
+		return nil, errors.New("missing alertmanager configuration")
+	}
+
+	header := make(http.Header)
+	if cfg.Alertmanager.Username != "" {
+		credentials := cfg.Alertmanager.Username + ":" + cfg.Alertmanager.Password
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	}
+
+	return &Notifier{
+		name:   cfg.Name,
+		cfg:    cfg.Alertmanager,
+		host:   notify.Hostname(),
+		header: header,
+		client: notify.NewHTTPClient(cfg.Alertmanager.HTTPOptions),
+		now:    time.Now,
+		firing: make(map[string]*firing),
+	}, nil
+}
+
+// Name returns the name of the notifier.
+func (n *Notifier) Name() string {
+	return n.name
+}
+
+// Notify sends the alert of the notification. Alertmanager identifies alerts by their labels, so when an alert
+// escalates the alert with its old severity is ended and one with the new severity is started.
+func (n *Notifier) Notify(ctx context.Context, notification *alert.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := n.now()
+	key := notification.Key()
+	a := n.alert(notification)
+	previous, ok := n.firing[key]
+
+	if notification.State == alert.StateResolved {
+		if ok {
+			// End the alert with the labels it fired with, as its severity may have dropped since.
+			a.Labels = previous.alert.Labels
+		}
+		a.EndsAt = notification.Time
+
+		delete(n.firing, key)
+		return n.send(ctx, []*postableAlert{a})
+	}
+
+	batch := make([]*postableAlert, 0, 2)
+	if ok && !maps.Equal(previous.alert.Labels, a.Labels) {
+		previous.alert.EndsAt = notification.Time
+		batch = append(batch, previous.alert)
+	}
+
+	a.EndsAt = now.Add(expiryFactor * n.cfg.ResendInterval.Std())
+	batch = append(batch, a)
+
+	f := &firing{alert: a}
+	n.firing[key] = f
+	if err := n.send(ctx, batch); err != nil {
+		return err
+	}
+
+	f.sentAt = now
+	return nil
+}
+
+// Flush sends the firing alerts again once the resend interval has passed since they were last sent, or all of them
+// when force is true.
+func (n *Notifier) Flush(ctx context.Context, force bool) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := n.now()
+	due := make([]*firing, 0)
+	for _, key := range slices.Sorted(maps.Keys(n.firing)) {
+		f := n.firing[key]
+		if force || now.Sub(f.sentAt) >= n.cfg.ResendInterval.Std() {
+			due = append(due, f)
+		}
+	}
+
+	if len(due) == 0 {
+		return nil
+	}
+
+	batch := make([]*postableAlert, 0, len(due))
+	for _, f := range due {
+		f.alert.EndsAt = now.Add(expiryFactor * n.cfg.ResendInterval.Std())
+		batch = append(batch, f.alert)
+	}
+
+	if err := n.send(ctx, batch); err != nil {
+		return err
+	}
+
+	for _, f := range due {
+		f.sentAt = now
+	}
+
+	return nil
+}
+
+// send posts the alerts to every Alertmanager.
+func (n *Notifier) send(ctx context.Context, alerts []*postableAlert) error {
+	errs := make([]error, 0)
+	for _, u := range n.cfg.URLs {
+		endpoint := strings.TrimSuffix(u, "/") + "/api/v2/alerts"
+		if _, err := n.client.JSON(ctx, http.MethodPost, endpoint, n.header, alerts); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send to %s: %w", u, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// alert builds the alert of the notification.
+func (n *Notifier) alert(notification *alert.Notification) *postableAlert {
+	labels := map[string]string{"alertname": AlertName}
+	maps.Copy(labels, n.cfg.Labels)
+	maps.Copy(labels, map[string]string{
+		"host":     n.host,
+		"chip":     notification.Reading.Chip,
+		"sensor":   notification.Reading.Name,
+		"rule":     notification.Rule.Name,
+		"severity": notification.Severity.String(),
+	})
+
+	thresholds := notification.Thresholds()
+	limits := make([]string, 0, len(thresholds))
+	for _, name := range slices.Sorted(maps.Keys(thresholds)) {
+		limits = append(limits, name+"="+strconv.FormatFloat(thresholds[name], 'f', -1, 64))
+	}
+
+	description := fmt.Sprintf("%s on %s matched rule %q", notification.Reading.ID(), n.host, notification.Rule.Name)
+	if len(limits) > 0 {
+		description += " with thresholds " + strings.Join(limits, ", ")
+	}
+
+	return &postableAlert{
+		Labels: labels,
+		Annotations: map[string]string{
+			"summary":     notification.Message(),
+			"description": description + ".",
+			"value":       notification.Reading.Kind.Format(notification.Value),
+		},
+		StartsAt: notification.FiredAt,
+	}
+}
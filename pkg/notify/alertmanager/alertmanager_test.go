@@ -0,0 +1,134 @@
+package alertmanager
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/notifytest"
+)
+
+func TestNotifier(t *testing.T) {
+	t.Parallel()
+
+	respond := func(*notifytest.Request) (int, string) {
+		return http.StatusOK, ""
+	}
+	primary := notifytest.NewServer(t, respond)
+	secondary := notifytest.NewServer(t, respond)
+
+	cfg := config.DefaultAlertmanager()
+	cfg.URLs = []string{primary.URL, secondary.URL + "/"}
+	cfg.Labels = map[string]string{"alertname": "NodeTemperature", "team": "render"}
+	cfg.Username = "monitor"
+	cfg.Password = "hunter2"
+
+	n, err := New(&config.Notifier{Name: "alertmanager", Type: config.NotifierAlertmanager, Alertmanager: cfg})
+	require.NoError(t, err)
+
+	notifier := n.(*Notifier)
+	notifier.host = "render-01"
+	now := notifytest.FiredAt
+	notifier.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	alerts := func(r *notifytest.Request) []*postableAlert {
+		var got []*postableAlert
+		r.Decode(t, &got)
+		return got
+	}
+
+	// A warning fires and expires after four resend intervals unless it is sent again.
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 91, alert.SeverityWarning, alert.StateFiring)))
+
+	requests := primary.Requests()
+	require.Len(t, requests, 1)
+	require.Equal(t, "/api/v2/alerts", requests[0].Path)
+	require.Equal(t, "Basic bW9uaXRvcjpodW50ZXIy", requests[0].Header.Get("Authorization"))
+	require.JSONEq(t, `[{
+		"labels": {
+			"alertname": "NodeTemperature",
+			"team": "render",
+			"host": "render-01",
+			"chip": "coretemp-isa-0000",
+			"sensor": "Core 0",
+			"rule": "cores",
+			"severity": "warning"
+		},
+		"annotations": {
+			"summary": "coretemp-isa-0000/Core 0 is at 91.0°C: at or above warn threshold 90 (90.0°C)",
+			"description": "coretemp-isa-0000/Core 0 on render-01 matched rule \"cores\" with thresholds critical=100, warn=90.",
+			"value": "91.0°C"
+		},
+		"startsAt": "2025-06-01T12:00:00Z",
+		"endsAt": "2025-06-01T12:04:00Z"
+	}]`, string(requests[0].Body))
+	require.Len(t, secondary.Requests(), 1)
+
+	// Firing alerts are sent again once the resend interval has passed.
+	now = now.Add(30 * time.Second)
+	require.NoError(t, notifier.Flush(ctx, false))
+	require.Len(t, primary.Requests(), 1)
+
+	now = now.Add(30 * time.Second)
+	require.NoError(t, notifier.Flush(ctx, false))
+	requests = primary.Requests()
+	require.Len(t, requests, 2)
+	resent := alerts(requests[1])
+	require.Len(t, resent, 1)
+	require.Equal(t, notifytest.FiredAt.Add(5*time.Minute), resent[0].EndsAt)
+
+	// Escalating changes the severity label, so the warning is ended as the critical alert starts.
+	now = now.Add(10 * time.Second)
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 101, alert.SeverityCritical, alert.StateFiring)))
+	escalated := alerts(primary.Requests()[2])
+	require.Len(t, escalated, 2)
+	require.Equal(t, "warning", escalated[0].Labels["severity"])
+	require.Equal(t, notifytest.FiredAt, escalated[0].EndsAt)
+	require.Equal(t, "critical", escalated[1].Labels["severity"])
+	require.Equal(t, now.Add(4*time.Minute), escalated[1].EndsAt)
+
+	// The alert resolves with the labels it fired with, even though its severity has dropped.
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 80, alert.SeverityWarning, alert.StateResolved)))
+	resolved := alerts(primary.Requests()[3])
+	require.Len(t, resolved, 1)
+	require.Equal(t, "critical", resolved[0].Labels["severity"])
+	require.Equal(t, notifytest.FiredAt.Add(5*time.Minute), resolved[0].EndsAt)
+	require.Equal(t, "coretemp-isa-0000/Core 0 has recovered at 80.0°C after 5m0s", resolved[0].Annotations["summary"])
+
+	// Nothing is firing, so nothing is resent.
+	require.NoError(t, notifier.Flush(ctx, true))
+	require.Len(t, primary.Requests(), 4)
+	require.Len(t, secondary.Requests(), 4)
+}
+
+func TestNotifier_Errors(t *testing.T) {
+	t.Parallel()
+
+	up := notifytest.NewServer(t, func(*notifytest.Request) (int, string) {
+		return http.StatusOK, ""
+	})
+	down := notifytest.NewServer(t, func(*notifytest.Request) (int, string) {
+		return http.StatusBadRequest, `{"code": 400, "message": "invalid alert"}`
+	})
+
+	cfg := config.DefaultAlertmanager()
+	cfg.URLs = []string{down.URL, up.URL}
+
+	n, err := New(&config.Notifier{Name: "alertmanager", Type: config.NotifierAlertmanager, Alertmanager: cfg})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	err = n.Notify(ctx, notifytest.Notification("Core 0", 91, alert.SeverityWarning, alert.StateFiring))
+	require.EqualError(t, err, "failed to send to "+down.URL+`: unexpected status 400: {"code": 400, "message": "invalid alert"}`)
+	require.Len(t, up.Requests(), 1)
+
+	// An alert that failed to send is sent again on the next flush.
+	require.Error(t, n.(*Notifier).Flush(ctx, false))
+	require.Len(t, up.Requests(), 2)
+}
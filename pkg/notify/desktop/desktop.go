@@ -0,0 +1,64 @@
+package desktop
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gen2brain/beeep"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+)
+
+// Notifier sends desktop notifications. It needs a desktop session, so it is not useful on headless servers.
+type Notifier struct {
+	name string
+}
+
+// New creates a desktop notifier from its configuration.
+func New(cfg *config.Notifier) (notify.Notifier, error) {
+	return &Notifier{name: cfg.Name}, nil
+}
+
+// Name returns the name of the notifier.
+func (n *Notifier) Name() string {
+	return n.name
+}
+
+// Notify shows a desktop notification. Critical alerts are shown as an alert that plays a sound.
+func (n *Notifier) Notify(_ context.Context, notification *alert.Notification) error {
+	if notification.State == alert.StateResolved {
+		if err := beeep.Notify(
+			notify.Title(notification),
+			notification.Message(),
+			"",
+		); err != nil {
+			return fmt.Errorf("failed to send resolved notification: %w", err)
+		}
+
+		return nil
+	}
+
+	if notification.Severity >= alert.SeverityCritical {
+		if err := beeep.Alert(
+			notify.Title(notification),
+			notification.Message()+" — system will crash soon!",
+			"",
+		); err != nil {
+			return fmt.Errorf("failed to send critical notification: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := beeep.Notify(
+		notify.Title(notification),
+		notification.Message()+" — please check your system!",
+		"",
+	); err != nil {
+		return fmt.Errorf("failed to send beep notification: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+)
+
+// message is a webhook message.
+type message struct {
+	Username string  `json:"username,omitempty"`
+	Embeds   []embed `json:"embeds"`
+}
+
+// embed is a rich embed of a message.
+type embed struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Color       int       `json:"color"`
+	Fields      []field   `json:"fields"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// field is a field of an embed.
+type field struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// response is the message returned when posting with wait=true.
+type response struct {
+	ID string `json:"id"`
+}
+
+// Notifier posts alerts to a Discord webhook.
+type Notifier struct {
+	name    string
+	cfg     *config.Discord
+	host    string
+	client  *notify.HTTPClient
+	threads *notify.Threads
+}
+
+// New creates a Discord notifier from its configuration.
+func New(cfg *config.Notifier) (notify.Notifier, error) {
+	if cfg.Discord == nil {
+		return nil, errors.New("missing discord configuration")
+	}
+
+	return &Notifier{
+		name:    cfg.Name,
+		cfg:     cfg.Discord,
+		host:    notify.Hostname(),
+		client:  notify.NewHTTPClient(cfg.Discord.HTTPOptions),
+		threads: notify.NewThreads(),
+	}, nil
+}
+
+// Name returns the name of the notifier.
+func (n *Notifier) Name() string {
+	return n.name
+}
+
+// Notify posts the notification. A resolved alert edits the message posted for the alert instead, so the channel shows
+// that it has recovered without another message.
+func (n *Notifier) Notify(ctx context.Context, notification *alert.Notification) error {
+	msg, err := n.message(notification)
+	if err != nil {
+		return err
+	}
+
+	key := notification.Key()
+	if id, ok := n.threads.Get(key); ok && notification.State == alert.StateResolved {
+		n.threads.Delete(key)
+		if _, err := n.client.JSON(ctx, http.MethodPatch, n.url("messages/"+id, nil), nil, msg); err != nil {
+			return fmt.Errorf("failed to edit discord message: %w", err)
+		}
+		return nil
+	}
+
+	body, err := n.client.JSON(ctx, http.MethodPost, n.url("", url.Values{"wait": {"true"}}), nil, msg)
+	if err != nil {
+		return fmt.Errorf("failed to post to discord: %w", err)
+	}
+
+	if notification.State == alert.StateResolved {
+		return nil
+	}
+
+	resp := new(response)
+	if err := json.Unmarshal(body, resp); err != nil {
+		return fmt.Errorf("failed to decode discord response: %w", err)
+	}
+	n.threads.Set(key, resp.ID)
+
+	return nil
+}
+
+// url returns the URL of the webhook, or of a path below it, with the query parameters added.
+func (n *Notifier) url(path string, query url.Values) string {
+	u, err := url.Parse(n.cfg.WebhookURL)
+	if err != nil {
+		// The URL is validated when the configuration is loaded.
+		return n.cfg.WebhookURL
+	}
+
+	if path != "" {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + path
+	}
+
+	q := u.Query()
+	for k, v := range query {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+// message builds the message for the notification.
+func (n *Notifier) message(notification *alert.Notification) (*message, error) {
+	color, err := strconv.ParseInt(strings.TrimPrefix(notify.Color(notification), "#"), 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid colour: %w", err)
+	}
+
+	facts := notify.Facts(notification, n.host)
+	fields := make([]field, 0, len(facts))
+	for _, f := range facts {
+		fields = append(fields, field{Name: f.Name, Value: f.Value, Inline: true})
+	}
+
+	return &message{
+		Username: n.cfg.Username,
+		Embeds: []embed{
+			{
+				Title:       notify.Title(notification),
+				Description: notification.Message(),
+				Color:       int(color),
+				Fields:      fields,
+				Timestamp:   notification.Time,
+			},
+		},
+	}, nil
+}
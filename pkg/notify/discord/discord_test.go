@@ -0,0 +1,75 @@
+package discord
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/notifytest"
+)
+
+func TestNotifier_Notify(t *testing.T) {
+	t.Parallel()
+
+	srv := notifytest.NewServer(t, func(r *notifytest.Request) (int, string) {
+		if r.Method == http.MethodPatch {
+			return http.StatusOK, "{}"
+		}
+		return http.StatusOK, `{"id": "1122334455"}`
+	})
+
+	n, err := New(&config.Notifier{Name: "discord", Type: config.NotifierDiscord, Discord: &config.Discord{
+		WebhookURL:  srv.URL + "/api/webhooks/123/abc?thread_id=42",
+		Username:    "Sensor Monitor",
+		HTTPOptions: config.DefaultHTTPOptions(),
+	}})
+	require.NoError(t, err)
+	n.(*Notifier).host = "render-01"
+
+	ctx := context.Background()
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 91, alert.SeverityWarning, alert.StateFiring)))
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 80, alert.SeverityWarning, alert.StateResolved)))
+
+	// A resolved alert that was never posted is posted as a new message.
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 1", 80, alert.SeverityWarning, alert.StateResolved)))
+
+	requests := srv.Requests()
+	require.Len(t, requests, 3)
+
+	require.Equal(t, http.MethodPost, requests[0].Method)
+	require.Equal(t, "/api/webhooks/123/abc", requests[0].Path)
+	require.Equal(t, "thread_id=42&wait=true", requests[0].Query)
+	require.JSONEq(t, `{
+		"username": "Sensor Monitor",
+		"embeds": [{
+			"title": "⚠ Sensor Alert",
+			"description": "coretemp-isa-0000/Core 0 is at 91.0°C: at or above warn threshold 90 (90.0°C)",
+			"color": 15116032,
+			"fields": [
+				{"name": "Sensor", "value": "coretemp-isa-0000/Core 0", "inline": true},
+				{"name": "Value", "value": "91.0°C", "inline": true},
+				{"name": "Severity", "value": "warning", "inline": true},
+				{"name": "State", "value": "firing", "inline": true},
+				{"name": "Rule", "value": "cores", "inline": true},
+				{"name": "Host", "value": "render-01", "inline": true}
+			],
+			"timestamp": "2025-06-01T12:00:00Z"
+		}]
+	}`, string(requests[0].Body))
+
+	// The resolved alert edits the message posted when it fired.
+	require.Equal(t, http.MethodPatch, requests[1].Method)
+	require.Equal(t, "/api/webhooks/123/abc/messages/1122334455", requests[1].Path)
+	require.Equal(t, "thread_id=42", requests[1].Query)
+
+	var edited message
+	requests[1].Decode(t, &edited)
+	require.Equal(t, "✅ Sensor Recovered", edited.Embeds[0].Title)
+	require.Equal(t, 0x2e7d32, edited.Embeds[0].Color)
+
+	require.Equal(t, http.MethodPost, requests[2].Method)
+}
@@ -0,0 +1,340 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+)
+
+// htmlBody is the HTML body of an email, listing one or more notifications.
+var htmlBody = template.Must(template.New("email").Funcs(template.FuncMap{"color": notify.Color}).Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif;">
+<p>{{ .Summary }}</p>
+<table cellpadding="6" style="border-collapse: collapse;">
+<tr style="text-align: left;"><th>Time</th><th>Sensor</th><th>State</th><th>Severity</th><th>Value</th><th>Details</th></tr>
+{{- range .Notifications }}
+<tr style="border-top: 1px solid #ddd;">
+<td>{{ .Time.Format "2006-01-02 15:04:05 MST" }}</td>
+<td>{{ .Reading.ID }}</td>
+<td>{{ .State }}</td>
+<td style="color: {{ color . }}; font-weight: bold;">{{ .Severity }}</td>
+<td>{{ .Reading.Kind.Format .Value }}</td>
+<td>{{ .Message }}</td>
+</tr>
+{{- end }}
+</table>
+</body>
+</html>
+`))
+
+// maxPending is the most notifications kept for the next digest while the SMTP server is down. The oldest are dropped
+// once there are more, so that a server that stays down does not grow the digest without bound.
+const maxPending = 1000
+
+// Notifier sends alerts by email. In digest mode alerts that are not critical are batched into a single email.
+type Notifier struct {
+	name string
+	cfg  *config.Email
+	host string
+
+	// now tells the time the digest is sent by.
+	now func() time.Time
+
+	// tlsConfig secures the connection to the SMTP server.
+	tlsConfig *tls.Config
+
+	// mu guards the batched notifications.
+	mu sync.Mutex
+
+	// pending are the notifications batched for the next digest.
+	pending []*alert.Notification
+
+	// pendingSince is when the first notification of the next digest was batched.
+	pendingSince time.Time
+
+	// dropped counts the notifications dropped from the digest because it was full since a digest was last sent.
+	dropped int
+}
+
+// New creates an email notifier from its configuration.
+func New(cfg *config.Notifier) (notify.Notifier, error) {
+	if cfg.Email == nil {
+		return nil, fmt.Errorf("missing email configuration")
+	}
+
+	return &Notifier{
+		name: cfg.Name,
+		cfg:  cfg.Email,
+		host: notify.Hostname(),
+		now:  time.Now,
+		tlsConfig: &tls.Config{
+			ServerName: cfg.Email.Host,
+			MinVersion: tls.VersionTLS12,
+		},
+		pending: make([]*alert.Notification, 0),
+	}, nil
+}
+
+// Name returns the name of the notifier.
+func (n *Notifier) Name() string {
+	return n.name
+}
+
+// Notify emails the notification, or batches it for the next digest if it is not a critical alert firing.
+func (n *Notifier) Notify(ctx context.Context, notification *alert.Notification) error {
+	critical := notification.State == alert.StateFiring && notification.Severity >= alert.SeverityCritical
+	if n.cfg.Digest > 0 && !critical {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+
+		if len(n.pending) == 0 {
+			n.pendingSince = n.now()
+		}
+		n.pending = append(n.pending, notification)
+		n.trim()
+		return nil
+	}
+
+	return n.send(ctx, []*alert.Notification{notification})
+}
+
+// Flush emails the digest once the digest interval has passed since its first notification was batched. A digest that
+// fails to send is kept, ahead of any notifications batched meanwhile, and sent again on the next flush, dropping the
+// oldest notifications beyond maxPending.
+func (n *Notifier) Flush(ctx context.Context, force bool) error {
+	n.mu.Lock()
+	if len(n.pending) == 0 || (!force && n.now().Sub(n.pendingSince) < n.cfg.Digest.Std()) {
+		n.mu.Unlock()
+		return nil
+	}
+
+	notifications, since := n.pending, n.pendingSince
+	n.pending = make([]*alert.Notification, 0)
+	n.mu.Unlock()
+
+	if err := n.send(ctx, notifications); err != nil {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+
+		n.pending = append(notifications, n.pending...)
+		n.pendingSince = since
+		n.trim()
+		if n.dropped > 0 {
+			return fmt.Errorf("%w (%d notifications kept, %d dropped)", err, len(n.pending), n.dropped)
+		}
+		return fmt.Errorf("%w (%d notifications kept)", err, len(n.pending))
+	}
+
+	n.mu.Lock()
+	n.dropped = 0
+	n.mu.Unlock()
+	return nil
+}
+
+// trim drops the oldest notifications of the digest beyond maxPending. It is called with the lock held.
+func (n *Notifier) trim() {
+	if over := len(n.pending) - maxPending; over > 0 {
+		n.dropped += over
+		n.pending = slices.Delete(n.pending, 0, over)
+	}
+}
+
+// send emails the notifications in a single message.
+func (n *Notifier) send(ctx context.Context, notifications []*alert.Notification) error {
+	msg, err := n.message(notifications)
+	if err != nil {
+		return err
+	}
+
+	if err := n.deliver(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// subject returns the subject of an email holding the notifications.
+func (n *Notifier) subject(notifications []*alert.Notification) string {
+	if len(notifications) > 1 {
+		return fmt.Sprintf("%d sensor alerts on %s", len(notifications), n.host)
+	}
+
+	first := notifications[0]
+	if first.State == alert.StateResolved {
+		return fmt.Sprintf("[RESOLVED] %s on %s", first.Reading.ID(), n.host)
+	}
+
+	return fmt.Sprintf("[%s] %s on %s", strings.ToUpper(first.Severity.String()), first.Reading.ID(), n.host)
+}
+
+// message builds the email holding the notifications, with both a plain text and an HTML body.
+func (n *Notifier) message(notifications []*alert.Notification) ([]byte, error) {
+	subject := n.subject(notifications)
+	now := n.now()
+
+	var buf bytes.Buffer
+	parts := multipart.NewWriter(&buf)
+
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", n.cfg.From)
+	header("To", strings.Join(n.cfg.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", fmt.Sprintf("<%d.sensor-monitor@%s>", now.UnixNano(), n.host))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	buf.WriteString("\r\n")
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s\n\n", subject)
+	for _, notification := range notifications {
+		fmt.Fprintf(&text, "%s  %s\n", notification.Time.Format("2006-01-02 15:04:05 MST"), notification.Message())
+	}
+
+	var html bytes.Buffer
+	if err := htmlBody.Execute(&html, map[string]any{
+		"Summary":       subject,
+		"Notifications": notifications,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to render email: %w", err)
+	}
+
+	for _, part := range []struct {
+		contentType string
+		body        []byte
+	}{
+		{contentType: "text/plain; charset=utf-8", body: []byte(text.String())},
+		{contentType: "text/html; charset=utf-8", body: html.Bytes()},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create email part: %w", err)
+		}
+
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write(part.body); err != nil {
+			return nil, fmt.Errorf("failed to write email part: %w", err)
+		}
+
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("failed to write email part: %w", err)
+		}
+	}
+
+	if err := parts.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish email: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// deliver sends the message to the SMTP server.
+func (n *Notifier) deliver(ctx context.Context, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, n.cfg.Timeout.Std())
+	defer cancel()
+
+	dialer := new(net.Dialer)
+	conn, err := dialer.DialContext(ctx, "tcp", n.cfg.Addr())
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+
+	// Abort the conversation if the context ends part way through.
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.Close()
+	})
+	defer stop()
+
+	if n.cfg.TLS == config.EmailTLSImplicit {
+		tlsConn := tls.Client(conn, n.tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return fmt.Errorf("failed to establish TLS: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	c, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer c.Close()
+
+	if err := c.Hello(n.host); err != nil {
+		return fmt.Errorf("failed to greet server: %w", err)
+	}
+
+	if n.cfg.TLS == config.EmailTLSStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("server does not support STARTTLS")
+		}
+
+		if err := c.StartTLS(n.tlsConfig); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+
+	if n.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	from, err := mail.ParseAddress(n.cfg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender: %w", err)
+	}
+
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+
+	for _, to := range n.cfg.To {
+		addr, err := mail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("invalid recipient: %w", err)
+		}
+
+		if err := c.Rcpt(addr.Address); err != nil {
+			return fmt.Errorf("failed to add recipient %s: %w", addr.Address, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start message: %w", err)
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to finish message: %w", err)
+	}
+
+	return c.Quit()
+}
@@ -0,0 +1,447 @@
+package email
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// received is an email accepted by the fake SMTP server.
+type received struct {
+	from string
+	to   []string
+	auth string
+	tls  bool
+	data string
+}
+
+// fakeSMTP is an in-process SMTP server supporting STARTTLS, implicit TLS and PLAIN authentication.
+type fakeSMTP struct {
+	ln        net.Listener
+	tlsConfig *tls.Config
+	implicit  bool
+	password  string
+
+	mu       sync.Mutex
+	messages []*received
+}
+
+// newFakeSMTP starts a fake SMTP server, returning it and a TLS configuration trusting its certificate.
+func newFakeSMTP(t *testing.T, implicit bool) (*fakeSMTP, *tls.Config) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+
+	s := &fakeSMTP{
+		ln: ln,
+		tlsConfig: &tls.Config{
+			Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+			MinVersion:   tls.VersionTLS12,
+		},
+		implicit: implicit,
+		password: "hunter2",
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s, &tls.Config{RootCAs: roots, ServerName: "127.0.0.1", MinVersion: tls.VersionTLS12}
+}
+
+// port returns the port the server listens on.
+func (s *fakeSMTP) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+// received returns the emails accepted so far.
+func (s *fakeSMTP) received() []*received {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*received(nil), s.messages...)
+}
+
+// serve speaks just enough SMTP to accept emails from net/smtp.
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+
+	if s.implicit {
+		conn = tls.Server(conn, s.tlsConfig)
+	}
+
+	tp := textproto.NewConn(conn)
+	msg := new(received)
+	reply := func(format string, args ...any) {
+		_ = tp.PrintfLine(format, args...)
+	}
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "EHLO":
+			_, msg.tls = conn.(*tls.Conn)
+			reply("250-fake")
+			if !msg.tls {
+				reply("250-STARTTLS")
+			}
+			reply("250 AUTH PLAIN")
+		case "STARTTLS":
+			reply("220 ready to start TLS")
+			conn = tls.Server(conn, s.tlsConfig)
+			tp = textproto.NewConn(conn)
+		case "AUTH":
+			_, initial, _ := strings.Cut(arg, " ")
+			decoded, err := base64.StdEncoding.DecodeString(initial)
+			parts := strings.Split(string(decoded), "\x00")
+			if err != nil || len(parts) != 3 || parts[2] != s.password {
+				reply("535 authentication failed")
+				continue
+			}
+			msg.auth = parts[1]
+			reply("235 authenticated")
+		case "MAIL":
+			msg.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			reply("250 ok")
+		case "RCPT":
+			msg.to = append(msg.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.data = string(data)
+
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+
+			msg = &received{tls: msg.tls, auth: msg.auth}
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+// newNotifier creates an email notifier sending to the fake server.
+func newNotifier(t *testing.T, s *fakeSMTP, tlsConfig *tls.Config, modify func(e *config.Email)) *Notifier {
+	t.Helper()
+
+	cfg := config.DefaultEmail()
+	cfg.Host = "127.0.0.1"
+	cfg.Port = s.port()
+	cfg.Username = "monitor"
+	cfg.Password = "hunter2"
+	cfg.From = "Sensor Monitor <monitor@example.com>"
+	cfg.To = []string{"ops@example.com", "Lab <lab@example.com>"}
+	if modify != nil {
+		modify(cfg)
+	}
+
+	n, err := New(&config.Notifier{Name: "email", Type: config.NotifierEmail, Email: cfg})
+	require.NoError(t, err)
+
+	notifier := n.(*Notifier)
+	notifier.tlsConfig = tlsConfig
+	notifier.host = "render-01"
+	return notifier
+}
+
+// testNotification returns a notification of the sensor in the given state.
+func testNotification(feature string, value float64, severity alert.Severity, state alert.State) *alert.Notification {
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	return &alert.Notification{
+		Event: &alert.Event{
+			Alert: alert.Alert{
+				Reading: &sensors.Reading{
+					Chip: "coretemp-isa-0000",
+					Feature: &sensors.Feature{
+						Name:   feature,
+						Kind:   sensors.KindTemperature,
+						Values: map[sensors.Subfeature]float64{sensors.SubfeatureInput: value},
+					},
+				},
+				Rule:       &config.Rule{Name: "cores"},
+				State:      state,
+				Severity:   severity,
+				Value:      value,
+				Reason:     "at or above warn threshold 90 (90.0°C)",
+				FiredAt:    at,
+				ResolvedAt: at.Add(5 * time.Minute),
+			},
+			Time: at,
+		},
+	}
+}
+
+// parsed is an email split into its subject and bodies.
+type parsed struct {
+	subject string
+	to      string
+	text    string
+	html    string
+}
+
+// parse splits an email into its subject and bodies.
+func parse(t *testing.T, data string) *parsed {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	p := &parsed{subject: subject, to: msg.Header.Get("To")}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		// The multipart reader decodes quoted-printable parts itself.
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+
+		switch {
+		case strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain"):
+			p.text = string(body)
+		case strings.HasPrefix(part.Header.Get("Content-Type"), "text/html"):
+			p.html = string(body)
+		}
+	}
+
+	return p
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		implicit bool
+		tls      config.EmailTLS
+	}{
+		{name: "starttls", tls: config.EmailTLSStartTLS},
+		{name: "implicit tls", implicit: true, tls: config.EmailTLSImplicit},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, tlsConfig := newFakeSMTP(t, test.implicit)
+			n := newNotifier(t, s, tlsConfig, func(e *config.Email) {
+				e.TLS = test.tls
+			})
+
+			require.NoError(t, n.Notify(context.Background(), testNotification("Core 0", 91, alert.SeverityWarning, alert.StateFiring)))
+
+			messages := s.received()
+			require.Len(t, messages, 1)
+			require.True(t, messages[0].tls)
+			require.Equal(t, "monitor", messages[0].auth)
+			require.Equal(t, "monitor@example.com", messages[0].from)
+			require.Equal(t, []string{"ops@example.com", "lab@example.com"}, messages[0].to)
+
+			email := parse(t, messages[0].data)
+			require.Equal(t, "[WARNING] coretemp-isa-0000/Core 0 on render-01", email.subject)
+			require.Equal(t, "ops@example.com, Lab <lab@example.com>", email.to)
+			require.Equal(t, "[WARNING] coretemp-isa-0000/Core 0 on render-01\n\n"+
+				"2025-06-01 12:00:00 UTC  coretemp-isa-0000/Core 0 is at 91.0°C: at or above warn threshold 90 (90.0°C)\n", email.text)
+			require.Contains(t, email.html, "<td>coretemp-isa-0000/Core 0</td>")
+			require.Contains(t, email.html, "color: #e6a700;")
+		})
+	}
+}
+
+func TestNotifier_Digest(t *testing.T) {
+	t.Parallel()
+
+	s, tlsConfig := newFakeSMTP(t, false)
+	n := newNotifier(t, s, tlsConfig, func(e *config.Email) {
+		e.Digest = config.Duration(5 * time.Minute)
+	})
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	n.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Warnings and resolved alerts are batched.
+	require.NoError(t, n.Notify(ctx, testNotification("Core 0", 91, alert.SeverityWarning, alert.StateFiring)))
+	require.NoError(t, n.Notify(ctx, testNotification("Core 1", 80, alert.SeverityCritical, alert.StateResolved)))
+	require.Empty(t, s.received())
+
+	// Critical alerts are sent straight away.
+	require.NoError(t, n.Notify(ctx, testNotification("Core 2", 101, alert.SeverityCritical, alert.StateFiring)))
+	require.Len(t, s.received(), 1)
+	require.Equal(t, "[CRITICAL] coretemp-isa-0000/Core 2 on render-01", parse(t, s.received()[0].data).subject)
+
+	// The digest is sent once the interval has passed since the first batched alert.
+	now = now.Add(4 * time.Minute)
+	require.NoError(t, n.Flush(ctx, false))
+	require.Len(t, s.received(), 1)
+
+	now = now.Add(time.Minute)
+	require.NoError(t, n.Flush(ctx, false))
+	require.Len(t, s.received(), 2)
+
+	digest := parse(t, s.received()[1].data)
+	require.Equal(t, "2 sensor alerts on render-01", digest.subject)
+	require.Contains(t, digest.text, "coretemp-isa-0000/Core 0 is at 91.0°C")
+	require.Contains(t, digest.text, "coretemp-isa-0000/Core 1 has recovered at 80.0°C after 5m0s")
+	require.Contains(t, digest.html, "color: #2e7d32;")
+
+	// Nothing is sent when nothing is batched, and forcing a flush sends whatever is batched.
+	require.NoError(t, n.Flush(ctx, true))
+	require.Len(t, s.received(), 2)
+
+	require.NoError(t, n.Notify(ctx, testNotification("Core 3", 92, alert.SeverityWarning, alert.StateFiring)))
+	require.NoError(t, n.Flush(ctx, true))
+	require.Len(t, s.received(), 3)
+	require.Equal(t, "[WARNING] coretemp-isa-0000/Core 3 on render-01", parse(t, s.received()[2].data).subject)
+}
+
+func TestNotifier_Digest_Failed(t *testing.T) {
+	t.Parallel()
+
+	s, tlsConfig := newFakeSMTP(t, false)
+	n := newNotifier(t, s, tlsConfig, func(e *config.Email) {
+		e.Digest = config.Duration(5 * time.Minute)
+		e.Password = "wrong"
+	})
+	ctx := context.Background()
+
+	require.NoError(t, n.Notify(ctx, testNotification("Core 0", 91, alert.SeverityWarning, alert.StateFiring)))
+	require.ErrorContains(t, n.Flush(ctx, true), "failed to send email: failed to authenticate")
+
+	// The digest that failed is sent with the notifications batched since.
+	require.NoError(t, n.Notify(ctx, testNotification("Core 1", 92, alert.SeverityWarning, alert.StateFiring)))
+	n.cfg.Password = "hunter2"
+	require.NoError(t, n.Flush(ctx, true))
+
+	messages := s.received()
+	require.Len(t, messages, 1)
+
+	digest := parse(t, messages[0].data)
+	require.Equal(t, "2 sensor alerts on render-01", digest.subject)
+	require.Less(t, strings.Index(digest.text, "Core 0 is at"), strings.Index(digest.text, "Core 1 is at"))
+}
+
+func TestNotifier_Digest_Full(t *testing.T) {
+	t.Parallel()
+
+	s, tlsConfig := newFakeSMTP(t, false)
+	n := newNotifier(t, s, tlsConfig, func(e *config.Email) {
+		e.Digest = config.Duration(5 * time.Minute)
+		e.Password = "wrong"
+	})
+	ctx := context.Background()
+
+	// While the server is down the digest is kept up to its limit, dropping the oldest notifications.
+	for i := range maxPending + 2 {
+		feature := fmt.Sprintf("Core %d", i)
+		require.NoError(t, n.Notify(ctx, testNotification(feature, 91, alert.SeverityWarning, alert.StateFiring)))
+	}
+	require.ErrorContains(t, n.Flush(ctx, true), "failed to authenticate")
+	require.ErrorContains(t, n.Flush(ctx, true), "(1000 notifications kept, 2 dropped)")
+
+	n.cfg.Password = "hunter2"
+	require.NoError(t, n.Flush(ctx, true))
+
+	messages := s.received()
+	require.Len(t, messages, 1)
+
+	digest := parse(t, messages[0].data)
+	require.Equal(t, "1000 sensor alerts on render-01", digest.subject)
+	require.NotContains(t, digest.text, "Core 0 is at")
+	require.NotContains(t, digest.text, "Core 1 is at")
+	require.Contains(t, digest.text, "Core 2 is at")
+}
+
+func TestNotifier_Notify_Errors(t *testing.T) {
+	t.Parallel()
+
+	s, tlsConfig := newFakeSMTP(t, false)
+	n := newNotifier(t, s, tlsConfig, func(e *config.Email) {
+		e.Password = "wrong"
+	})
+
+	err := n.Notify(context.Background(), testNotification("Core 0", 91, alert.SeverityWarning, alert.StateFiring))
+	require.ErrorContains(t, err, `failed to send email: failed to authenticate: 535 "authentication failed"`)
+
+	// Nothing listens on the port once the listener is closed.
+	require.NoError(t, s.ln.Close())
+	err = n.Notify(context.Background(), testNotification("Core 0", 91, alert.SeverityWarning, alert.StateFiring))
+	require.ErrorContains(t, err, "failed to send email: failed to connect")
+	require.Empty(t, s.received())
+}
@@ -0,0 +1,146 @@
+package notify
+
+import (
+	"os"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+)
+
+const (
+	// ColorWarning is the colour of warnings.
+	ColorWarning = "#e6a700"
+
+	// ColorCritical is the colour of critical alerts.
+	ColorCritical = "#d32f2f"
+
+	// ColorResolved is the colour of resolved alerts.
+	ColorResolved = "#2e7d32"
+)
+
+// Title returns the headline of a notification, matching the desktop notifications.
+func Title(n *alert.Notification) string {
+	switch {
+	case n.State == alert.StateResolved:
+		return "✅ Sensor Recovered"
+	case n.Severity >= alert.SeverityCritical:
+		return "🔥 Sensor Critical!"
+	default:
+		return "⚠ Sensor Alert"
+	}
+}
+
+// Color returns the colour of a notification as a hex triplet, e.g. "#d32f2f".
+func Color(n *alert.Notification) string {
+	switch {
+	case n.State == alert.StateResolved:
+		return ColorResolved
+	case n.Severity >= alert.SeverityCritical:
+		return ColorCritical
+	default:
+		return ColorWarning
+	}
+}
+
+// Level returns the level of a notification, used by push services to choose its priority.
+func Level(n *alert.Notification) config.Level {
+	switch {
+	case n.State == alert.StateResolved:
+		return config.LevelResolved
+	case n.Severity >= alert.SeverityCritical:
+		return config.LevelCritical
+	default:
+		return config.LevelWarning
+	}
+}
+
+// Summary returns the message of a notification and the host it is about, for push services that show short plain
+// text.
+func Summary(n *alert.Notification, host string) string {
+	return n.Message() + "\nHost: " + host
+}
+
+// IncidentKey returns a key identifying an alert on the host, the same across restarts of the monitor, used by
+// incident management services to group the events of the alert.
+func IncidentKey(n *alert.Notification, host string) string {
+	return host + "/" + n.Key()
+}
+
+// Truncate shortens s to at most limit characters, for services that limit the length of a field.
+func Truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
+
+// Fact is a labelled detail of a notification, shown as a field by chat services.
+type Fact struct {
+	Name  string
+	Value string
+}
+
+// Facts returns the details of a notification shown alongside its message.
+func Facts(n *alert.Notification, host string) []Fact {
+	return []Fact{
+		{Name: "Sensor", Value: n.Reading.ID()},
+		{Name: "Value", Value: n.Reading.Kind.Format(n.Value)},
+		{Name: "Severity", Value: n.Severity.String()},
+		{Name: "State", Value: n.State.String()},
+		{Name: "Rule", Value: n.Rule.Name},
+		{Name: "Host", Value: host},
+	}
+}
+
+// Hostname returns the name of the host, or "unknown" if it cannot be found.
+func Hostname() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+
+	return host
+}
+
+// Threads remembers the message posted when each alert fired, so that later notifications of the alert can reply to
+// or edit it.
+type Threads struct {
+	mu  sync.Mutex
+	ids map[string]string
+}
+
+// NewThreads creates an empty set of threads.
+func NewThreads() *Threads {
+	return &Threads{
+		ids: make(map[string]string),
+	}
+}
+
+// Get returns the message posted for the alert with the given key.
+func (t *Threads) Get(key string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id, ok := t.ids[key]
+	return id, ok
+}
+
+// Set records the message posted for the alert with the given key.
+func (t *Threads) Set(key, id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.ids[key] = id
+}
+
+// Delete forgets the message posted for the alert with the given key, once the alert has resolved.
+func (t *Threads) Delete(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.ids, key)
+}
@@ -0,0 +1,29 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		limit int
+		want  string
+	}{
+		{name: "short", input: "Core 0 is at 91.0°C", limit: 19, want: "Core 0 is at 91.0°C"},
+		{name: "long", input: "Core 0 is at 91.0°C", limit: 18, want: "Core 0 is at 91.0…"},
+		{name: "multi-byte characters are kept whole", input: "91.0°C is too hot", limit: 6, want: "91.0°…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, Truncate(tt.input, tt.limit))
+		})
+	}
+}
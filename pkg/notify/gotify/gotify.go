@@ -0,0 +1,65 @@
+package gotify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+)
+
+// message is a message created through the Gotify API.
+type message struct {
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	Priority int    `json:"priority"`
+}
+
+// Notifier sends alerts to a Gotify server.
+type Notifier struct {
+	name   string
+	cfg    *config.Gotify
+	host   string
+	client *notify.HTTPClient
+}
+
+// New creates a Gotify notifier from its configuration.
+func New(cfg *config.Notifier) (notify.Notifier, error) {
+	if cfg.Gotify == nil {
+		return nil, errors.New("missing gotify configuration")
+	}
+
+	return &Notifier{
+		name:   cfg.Name,
+		cfg:    cfg.Gotify,
+		host:   notify.Hostname(),
+		client: notify.NewHTTPClient(cfg.Gotify.HTTPOptions),
+	}, nil
+}
+
+// Name returns the name of the notifier.
+func (n *Notifier) Name() string {
+	return n.name
+}
+
+// Notify sends the notification with the priority of its level.
+func (n *Notifier) Notify(ctx context.Context, notification *alert.Notification) error {
+	msg := &message{
+		Title:    notify.Title(notification),
+		Message:  notify.Summary(notification, n.host),
+		Priority: n.cfg.Priority.For(notify.Level(notification)),
+	}
+
+	// The token is sent in a header rather than the query so that it is not logged with the URL.
+	header := http.Header{"X-Gotify-Key": {n.cfg.Token}}
+	endpoint := strings.TrimSuffix(n.cfg.URL, "/") + "/message"
+	if _, err := n.client.JSON(ctx, http.MethodPost, endpoint, header, msg); err != nil {
+		return fmt.Errorf("failed to send to gotify: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package gotify
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/notifytest"
+)
+
+func TestNotifier_Notify(t *testing.T) {
+	t.Parallel()
+
+	srv := notifytest.NewServer(t, func(r *notifytest.Request) (int, string) {
+		if r.Header.Get("X-Gotify-Key") != "app-token" {
+			return http.StatusUnauthorized, `{"error": "Unauthorized"}`
+		}
+		return http.StatusOK, `{"id": 25}`
+	})
+
+	cfg := config.DefaultGotify()
+	cfg.URL = srv.URL + "/"
+	cfg.Token = "app-token"
+	cfg.Priority.Critical = 10
+
+	n, err := New(&config.Notifier{Name: "gotify", Type: config.NotifierGotify, Gotify: cfg})
+	require.NoError(t, err)
+	n.(*Notifier).host = "render-01"
+
+	ctx := context.Background()
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 101, alert.SeverityCritical, alert.StateFiring)))
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 80, alert.SeverityCritical, alert.StateResolved)))
+
+	requests := srv.Requests()
+	require.Len(t, requests, 2)
+	require.Equal(t, "/message", requests[0].Path)
+	require.JSONEq(t, `{
+		"title": "🔥 Sensor Critical!",
+		"message": "coretemp-isa-0000/Core 0 is at 101.0°C: at or above warn threshold 90 (90.0°C)\nHost: render-01",
+		"priority": 10
+	}`, string(requests[0].Body))
+
+	var resolved message
+	requests[1].Decode(t, &resolved)
+	require.Equal(t, "✅ Sensor Recovered", resolved.Title)
+	require.Equal(t, 2, resolved.Priority)
+
+	cfg.Token = "revoked"
+	err = n.Notify(ctx, notifytest.Notification("Core 0", 91, alert.SeverityWarning, alert.StateFiring))
+	require.EqualError(t, err, `failed to send to gotify: unexpected status 401: {"error": "Unauthorized"}`)
+}
@@ -0,0 +1,148 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+)
+
+// maxResponseSize is the most of a response body that is read.
+const maxResponseSize = 1 << 20
+
+// StatusError is returned when a request is answered with an unsuccessful status code.
+type StatusError struct {
+	// StatusCode is the status code of the response.
+	StatusCode int
+
+	// Body is the start of the response body, to help explain the failure.
+	Body string
+}
+
+// Error returns the status code and the response body.
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("unexpected status %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+// Permanent reports whether the request was rejected for good, i.e. a client error other than a timeout or rate
+// limiting, so that sending it again would fail the same way.
+func (e *StatusError) Permanent() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+
+	return e.StatusCode >= http.StatusBadRequest && e.StatusCode < http.StatusInternalServerError
+}
+
+// retryable reports whether the request may succeed if it is sent again.
+func (e *StatusError) retryable() bool {
+	return e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode >= http.StatusInternalServerError
+}
+
+// HTTPClient sends the requests of notifiers, retrying failed requests with exponential backoff.
+type HTTPClient struct {
+	client  *http.Client
+	retries int
+	backoff time.Duration
+}
+
+// NewHTTPClient creates a client with the given timeout and retry settings.
+func NewHTTPClient(opts config.HTTPOptions) *HTTPClient {
+	return &HTTPClient{
+		client:  &http.Client{Timeout: opts.Timeout.Std()},
+		retries: opts.Retries,
+		backoff: opts.Backoff.Std(),
+	}
+}
+
+// Do sends the request built by newRequest, returning the response body of the first successful attempt. The request
+// is built again for every attempt so that its body can be read each time. Network errors, rate limiting and server
+// errors are retried, as are request timeouts; other failures are returned straight away.
+func (c *HTTPClient) Do(ctx context.Context, newRequest func(ctx context.Context) (*http.Request, error)) ([]byte, error) {
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		body, err := c.do(req)
+		if err == nil {
+			return body, nil
+		}
+
+		statusErr := new(StatusError)
+		if errors.As(err, &statusErr) && !statusErr.retryable() {
+			return nil, err
+		}
+
+		if attempt >= c.retries {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt+1, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt+1, errors.Join(err, ctx.Err()))
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// JSON sends the payload encoded as JSON with the given method and headers, returning the response body.
+func (c *HTTPClient) JSON(ctx context.Context, method, url string, header http.Header, payload any) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	return c.Do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "sensor-monitor")
+		return req, nil
+	})
+}
+
+// do sends the request once.
+func (c *HTTPClient) do(req *http.Request) ([]byte, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		const maxBody = 200
+		if len(body) > maxBody {
+			body = body[:maxBody]
+		}
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return body, nil
+}
@@ -0,0 +1,142 @@
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+)
+
+// message is the content of an m.room.message event.
+type message struct {
+	MsgType       string     `json:"msgtype"`
+	Body          string     `json:"body"`
+	Format        string     `json:"format"`
+	FormattedBody string     `json:"formatted_body"`
+	RelatesTo     *relatesTo `json:"m.relates_to,omitempty"`
+}
+
+// relatesTo relates an event to the root of its thread.
+type relatesTo struct {
+	RelType       string    `json:"rel_type"`
+	EventID       string    `json:"event_id"`
+	IsFallingBack bool      `json:"is_falling_back"`
+	InReplyTo     inReplyTo `json:"m.in_reply_to"`
+}
+
+// inReplyTo is the reply fallback for clients that do not support threads.
+type inReplyTo struct {
+	EventID string `json:"event_id"`
+}
+
+// response is the response to sending an event.
+type response struct {
+	EventID string `json:"event_id"`
+}
+
+// Notifier posts alerts to a Matrix room.
+type Notifier struct {
+	name    string
+	cfg     *config.Matrix
+	host    string
+	client  *notify.HTTPClient
+	threads *notify.Threads
+
+	// txn numbers the transactions, so that a retried request is not posted twice.
+	txn atomic.Uint64
+
+	// started makes the transaction IDs unique across restarts.
+	started int64
+}
+
+// New creates a Matrix notifier from its configuration.
+func New(cfg *config.Notifier) (notify.Notifier, error) {
+	if cfg.Matrix == nil {
+		return nil, errors.New("missing matrix configuration")
+	}
+
+	return &Notifier{
+		name:    cfg.Name,
+		cfg:     cfg.Matrix,
+		host:    notify.Hostname(),
+		client:  notify.NewHTTPClient(cfg.Matrix.HTTPOptions),
+		threads: notify.NewThreads(),
+		started: time.Now().UnixNano(),
+	}, nil
+}
+
+// Name returns the name of the notifier.
+func (n *Notifier) Name() string {
+	return n.name
+}
+
+// Notify posts the notification to the room. Later notifications of an alert, including when it resolves, are
+// posted in the thread of the message posted when it fired.
+func (n *Notifier) Notify(ctx context.Context, notification *alert.Notification) error {
+	key := notification.Key()
+	msg := n.message(notification)
+	root, threaded := n.threads.Get(key)
+	if threaded {
+		msg.RelatesTo = &relatesTo{
+			RelType:       "m.thread",
+			EventID:       root,
+			IsFallingBack: true,
+			InReplyTo:     inReplyTo{EventID: root},
+		}
+	}
+
+	txnID := fmt.Sprintf("sensor-monitor.%d.%d", n.started, n.txn.Add(1))
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		strings.TrimSuffix(n.cfg.Homeserver, "/"), url.PathEscape(n.cfg.RoomID), url.PathEscape(txnID))
+	header := http.Header{"Authorization": {"Bearer " + n.cfg.AccessToken}}
+
+	body, err := n.client.JSON(ctx, http.MethodPut, endpoint, header, msg)
+	if err != nil {
+		return fmt.Errorf("failed to post to matrix: %w", err)
+	}
+
+	switch {
+	case notification.State == alert.StateResolved:
+		n.threads.Delete(key)
+	case !threaded:
+		resp := new(response)
+		if err := json.Unmarshal(body, resp); err != nil {
+			return fmt.Errorf("failed to decode matrix response: %w", err)
+		}
+		n.threads.Set(key, resp.EventID)
+	}
+
+	return nil
+}
+
+// message builds the message for the notification, with an HTML body coloured by severity.
+func (n *Notifier) message(notification *alert.Notification) *message {
+	title := notify.Title(notification)
+
+	var text, formatted strings.Builder
+	fmt.Fprintf(&text, "%s\n%s\n", title, notification.Message())
+	fmt.Fprintf(&formatted, `<h4><font data-mx-color="%s" color="%s">%s</font></h4><p>%s</p><ul>`,
+		notify.Color(notification), notify.Color(notification), html.EscapeString(title), html.EscapeString(notification.Message()))
+	for _, f := range notify.Facts(notification, n.host) {
+		fmt.Fprintf(&text, "%s: %s\n", f.Name, f.Value)
+		fmt.Fprintf(&formatted, "<li><b>%s:</b> %s</li>", html.EscapeString(f.Name), html.EscapeString(f.Value))
+	}
+	formatted.WriteString("</ul>")
+
+	return &message{
+		MsgType:       "m.text",
+		Body:          strings.TrimSuffix(text.String(), "\n"),
+		Format:        "org.matrix.custom.html",
+		FormattedBody: formatted.String(),
+	}
+}
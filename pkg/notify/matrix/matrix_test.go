@@ -0,0 +1,77 @@
+package matrix
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/notifytest"
+)
+
+func TestNotifier_Notify(t *testing.T) {
+	t.Parallel()
+
+	srv := notifytest.NewServer(t, func(r *notifytest.Request) (int, string) {
+		if r.Header.Get("Authorization") != "Bearer syt_token" {
+			return http.StatusUnauthorized, `{"errcode": "M_UNKNOWN_TOKEN"}`
+		}
+		return http.StatusOK, `{"event_id": "$root:example.com"}`
+	})
+
+	n, err := New(&config.Notifier{Name: "matrix", Type: config.NotifierMatrix, Matrix: &config.Matrix{
+		Homeserver:  srv.URL + "/",
+		RoomID:      "!alerts:example.com",
+		AccessToken: "syt_token",
+		HTTPOptions: config.DefaultHTTPOptions(),
+	}})
+	require.NoError(t, err)
+	notifier := n.(*Notifier)
+	notifier.host = "render-01"
+
+	ctx := context.Background()
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 91, alert.SeverityWarning, alert.StateFiring)))
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 80, alert.SeverityWarning, alert.StateResolved)))
+
+	requests := srv.Requests()
+	require.Len(t, requests, 2)
+
+	require.Equal(t, http.MethodPut, requests[0].Method)
+	require.True(t, strings.HasPrefix(requests[0].Path, "/_matrix/client/v3/rooms/%21alerts:example.com/send/m.room.message/sensor-monitor."))
+	require.NotEqual(t, requests[0].Path, requests[1].Path)
+
+	var fired message
+	requests[0].Decode(t, &fired)
+	require.Equal(t, message{
+		MsgType: "m.text",
+		Body: "⚠ Sensor Alert\ncoretemp-isa-0000/Core 0 is at 91.0°C: at or above warn threshold 90 (90.0°C)\n" +
+			"Sensor: coretemp-isa-0000/Core 0\nValue: 91.0°C\nSeverity: warning\nState: firing\nRule: cores\nHost: render-01",
+		Format: "org.matrix.custom.html",
+		FormattedBody: `<h4><font data-mx-color="#e6a700" color="#e6a700">⚠ Sensor Alert</font></h4>` +
+			`<p>coretemp-isa-0000/Core 0 is at 91.0°C: at or above warn threshold 90 (90.0°C)</p><ul>` +
+			`<li><b>Sensor:</b> coretemp-isa-0000/Core 0</li><li><b>Value:</b> 91.0°C</li>` +
+			`<li><b>Severity:</b> warning</li><li><b>State:</b> firing</li><li><b>Rule:</b> cores</li>` +
+			`<li><b>Host:</b> render-01</li></ul>`,
+	}, fired)
+
+	// The resolved alert is posted in the thread of the message posted when it fired.
+	var resolved message
+	requests[1].Decode(t, &resolved)
+	require.Equal(t, &relatesTo{
+		RelType:       "m.thread",
+		EventID:       "$root:example.com",
+		IsFallingBack: true,
+		InReplyTo:     inReplyTo{EventID: "$root:example.com"},
+	}, resolved.RelatesTo)
+
+	_, ok := notifier.threads.Get("cores:coretemp-isa-0000/Core 0")
+	require.False(t, ok)
+
+	notifier.cfg.AccessToken = "expired"
+	err = n.Notify(ctx, notifytest.Notification("Core 0", 91, alert.SeverityWarning, alert.StateFiring))
+	require.EqualError(t, err, `failed to post to matrix: unexpected status 401: {"errcode": "M_UNKNOWN_TOKEN"}`)
+}
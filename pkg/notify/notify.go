@@ -0,0 +1,275 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+)
+
+// Notifier sends notifications somewhere, such as the desktop or a chat service.
+type Notifier interface {
+	// Name identifies the notifier in logs.
+	Name() string
+
+	// Notify sends a notification.
+	Notify(ctx context.Context, n *alert.Notification) error
+}
+
+// Flusher is implemented by notifiers that batch notifications, such as an email digest, or send them again
+// periodically, such as to Alertmanager. It is called after every evaluation.
+type Flusher interface {
+	// Flush sends the notifications that are due. When force is true, every notification is sent regardless of when it
+	// is due, e.g. when the monitor is shutting down.
+	Flush(ctx context.Context, force bool) error
+}
+
+// Recorder records every notification sent to a notifier, such as in a history store.
+type Recorder interface {
+	// RecordNotification records a notification sent to the named notifier, with the error sending it failed with.
+	RecordNotification(notifier string, n *alert.Notification, err error)
+}
+
+// Factory creates a notifier from its configuration.
+type Factory func(cfg *config.Notifier) (Notifier, error)
+
+// Registry holds the factory of every notifier type.
+type Registry struct {
+	factories map[config.NotifierType]Factory
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[config.NotifierType]Factory),
+	}
+}
+
+// Register adds the factory for a notifier type, replacing any already registered.
+func (r *Registry) Register(t config.NotifierType, factory Factory) {
+	r.factories[t] = factory
+}
+
+// New creates the notifier described by the configuration.
+func (r *Registry) New(cfg *config.Notifier) (Notifier, error) {
+	factory, ok := r.factories[cfg.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown notifier type %q", cfg.Type)
+	}
+
+	n, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s notifier %q: %w", cfg.Type, cfg.Name, err)
+	}
+
+	return n, nil
+}
+
+// target is a notifier together with the filters applied to what is sent to it.
+type target struct {
+	cfg         *config.Notifier
+	notifier    Notifier
+	minSeverity alert.Severity
+	throttle    *alert.Throttle
+}
+
+// Dispatcher sends the notifications of each evaluation to every configured notifier.
+type Dispatcher struct {
+	targets []*target
+
+	// recorder records every notification sent, if set.
+	recorder Recorder
+}
+
+// NewDispatcher creates the notifiers described by the configuration.
+func NewDispatcher(registry *Registry, cfgs []*config.Notifier, clock alert.Clock) (*Dispatcher, error) {
+	targets := make([]*target, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		n, err := registry.New(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		minSeverity, err := alert.ParseSeverity(string(cfg.MinSeverity))
+		if err != nil {
+			return nil, fmt.Errorf("invalid notifier %q: %w", cfg.Name, err)
+		}
+
+		throttle := alert.NewThrottle(clock, 0, 0)
+		if cfg.RateLimit != nil {
+			throttle = alert.NewThrottle(clock, cfg.RateLimit.Count, cfg.RateLimit.Per.Std())
+		}
+
+		targets = append(targets, &target{
+			cfg:         cfg,
+			notifier:    n,
+			minSeverity: minSeverity,
+			throttle:    throttle,
+		})
+	}
+
+	return &Dispatcher{targets: targets}, nil
+}
+
+// Adopt takes over the notifiers of prev whose configuration has not changed, with the state of their throttle and
+// whatever they have batched, so that reloading the configuration neither notifies again nor loses a digest. It must
+// be called before the dispatcher is used. The notifiers of prev that are not taken over are flushed; every failure to
+// flush is returned joined together.
+func (d *Dispatcher) Adopt(ctx context.Context, prev *Dispatcher) error {
+	adopted := make(map[*target]bool, len(prev.targets))
+	for i, t := range d.targets {
+		for _, old := range prev.targets {
+			if !adopted[old] && reflect.DeepEqual(old.cfg, t.cfg) {
+				adopted[old] = true
+				d.targets[i] = old
+				break
+			}
+		}
+	}
+
+	errs := make([]error, 0)
+	for _, t := range prev.targets {
+		if adopted[t] {
+			continue
+		}
+
+		if err := t.flush(ctx, true); err != nil {
+			errs = append(errs, fmt.Errorf("failed to flush %s: %w", t.notifier.Name(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// SetRecorder sets the recorder every notification sent is recorded with.
+func (d *Dispatcher) SetRecorder(r Recorder) {
+	d.recorder = r
+}
+
+// Dispatch sends the notifications for the events of an evaluation, and reminders for the alerts still firing, to
+// every notifier. Notifiers are sent to concurrently, so that one that is slow or down, retrying until the context is
+// done, does not hold up the others. A notifier failing does not stop the notification being sent to the others;
+// every failure is returned joined together.
+func (d *Dispatcher) Dispatch(ctx context.Context, events []*alert.Event, alerts []*alert.Alert) error {
+	outcomes := make([][]*outcome, len(d.targets))
+	flushErrs := d.each(func(i int, t *target) error {
+		for _, n := range t.throttle.Filter(t.events(events), t.alerts(alerts)) {
+			outcomes[i] = append(outcomes[i], &outcome{notification: n, err: t.send(ctx, n)})
+		}
+
+		return t.flush(ctx, false)
+	})
+
+	// Notifications are recorded once every notifier is done, in the order of the notifiers, so that the recorder
+	// is never called concurrently.
+	errs := make([]error, 0)
+	for i, t := range d.targets {
+		for _, o := range outcomes[i] {
+			if d.recorder != nil {
+				d.recorder.RecordNotification(t.notifier.Name(), o.notification, o.err)
+			}
+
+			if o.err != nil {
+				errs = append(errs, fmt.Errorf("failed to notify %s: %w", t.notifier.Name(), o.err))
+			}
+		}
+	}
+
+	return errors.Join(append(errs, flushErrs)...)
+}
+
+// Flush sends the notifications batched by every notifier that are due, or all of them when force is true. Notifiers
+// are flushed concurrently, like they are sent to.
+func (d *Dispatcher) Flush(ctx context.Context, force bool) error {
+	return d.each(func(_ int, t *target) error {
+		return t.flush(ctx, force)
+	})
+}
+
+// outcome is a notification sent to a notifier, with the error sending it failed with.
+type outcome struct {
+	notification *alert.Notification
+	err          error
+}
+
+// each calls f for every target concurrently, where f flushes the target, returning the failures to flush joined
+// together in the order of the targets.
+func (d *Dispatcher) each(f func(i int, t *target) error) error {
+	errs := make([]error, len(d.targets))
+
+	var wg sync.WaitGroup
+	for i, t := range d.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := f(i, t); err != nil {
+				errs[i] = fmt.Errorf("failed to flush %s: %w", t.notifier.Name(), err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// send sends a notification, turning a panicking notifier into an error so that it cannot stop the monitor.
+func (t *target) send(ctx context.Context, n *alert.Notification) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("notifier panicked: %v", r)
+		}
+	}()
+
+	return t.notifier.Notify(ctx, n)
+}
+
+// flush flushes the notifier if it batches notifications, turning a panic into an error like send.
+func (t *target) flush(ctx context.Context, force bool) (err error) {
+	f, ok := t.notifier.(Flusher)
+	if !ok {
+		return nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("notifier panicked: %v", r)
+		}
+	}()
+
+	return f.Flush(ctx, force)
+}
+
+// events returns the events severe enough for the notifier. An alert resolving is judged by the highest severity it
+// fired at, so that a notifier told of the alert firing is told of it resolving even if it became less severe
+// meanwhile.
+func (t *target) events(events []*alert.Event) []*alert.Event {
+	filtered := make([]*alert.Event, 0, len(events))
+	for _, e := range events {
+		severity := e.Severity
+		if e.State == alert.StateResolved {
+			severity = e.PeakSeverity
+		}
+
+		if severity >= t.minSeverity {
+			filtered = append(filtered, e)
+		}
+	}
+
+	return filtered
+}
+
+// alerts returns the alerts severe enough for the notifier.
+func (t *target) alerts(alerts []*alert.Alert) []*alert.Alert {
+	filtered := make([]*alert.Alert, 0, len(alerts))
+	for _, a := range alerts {
+		if a.Severity >= t.minSeverity {
+			filtered = append(filtered, a)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,335 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// fakeNotifier records the notifications it is sent, optionally failing or panicking on each.
+type fakeNotifier struct {
+	name     string
+	err      error
+	panics   bool
+	messages []string
+}
+
+func (n *fakeNotifier) Name() string {
+	return n.name
+}
+
+func (n *fakeNotifier) Notify(_ context.Context, notification *alert.Notification) error {
+	if n.panics {
+		panic("boom")
+	}
+
+	n.messages = append(n.messages, notification.Message())
+	return n.err
+}
+
+// fixedClock always returns the same time.
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+// fakeRecorder records the notifications sent, as "<notifier>: <alert key>", followed by the error if sending failed.
+type fakeRecorder struct {
+	sent []string
+}
+
+func (r *fakeRecorder) RecordNotification(notifier string, n *alert.Notification, err error) {
+	sent := notifier + ": " + n.Key()
+	if err != nil {
+		sent += ": " + err.Error()
+	}
+
+	r.sent = append(r.sent, sent)
+}
+
+func TestRegistry_New(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	registry.Register(config.NotifierDesktop, func(cfg *config.Notifier) (Notifier, error) {
+		return &fakeNotifier{name: cfg.Name}, nil
+	})
+	registry.Register("broken", func(*config.Notifier) (Notifier, error) {
+		return nil, errors.New("missing url")
+	})
+
+	n, err := registry.New(&config.Notifier{Name: "laptop", Type: config.NotifierDesktop})
+	require.NoError(t, err)
+	require.Equal(t, "laptop", n.Name())
+
+	_, err = registry.New(&config.Notifier{Name: "pager", Type: "pager"})
+	require.EqualError(t, err, `unknown notifier type "pager"`)
+
+	_, err = registry.New(&config.Notifier{Name: "hook", Type: "broken"})
+	require.EqualError(t, err, `failed to create broken notifier "hook": missing url`)
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	t.Parallel()
+
+	notifiers := map[string]*fakeNotifier{
+		"failing":   {name: "failing", err: errors.New("connection refused")},
+		"panicking": {name: "panicking", panics: true},
+		"all":       {name: "all"},
+		"critical":  {name: "critical"},
+	}
+
+	registry := NewRegistry()
+	registry.Register("fake", func(cfg *config.Notifier) (Notifier, error) {
+		return notifiers[cfg.Name], nil
+	})
+
+	clock := fixedClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	d, err := NewDispatcher(registry, []*config.Notifier{
+		{Name: "failing", Type: "fake", MinSeverity: config.SeverityWarning},
+		{Name: "panicking", Type: "fake", MinSeverity: config.SeverityWarning},
+		{Name: "all", Type: "fake", MinSeverity: config.SeverityWarning},
+		{Name: "critical", Type: "fake", MinSeverity: config.SeverityCritical},
+	}, clock)
+	require.NoError(t, err)
+
+	recorder := new(fakeRecorder)
+	d.SetRecorder(recorder)
+
+	evaluator := alert.NewEvaluator([]*config.Rule{
+		{Name: "cpu", Match: "*", Warn: &config.Threshold{Offset: 80}, Critical: &config.Threshold{Offset: 95}},
+	}, alert.WithClock(clock))
+	events := evaluator.Evaluate(sensors.NewSnapshot(clock.Now(), []*sensors.Chip{
+		{
+			Name: "coretemp-isa-0000",
+			Features: []*sensors.Feature{
+				{Name: "Core 0", Kind: sensors.KindTemperature, Values: map[sensors.Subfeature]float64{
+					sensors.SubfeatureInput: 85,
+				}},
+				{Name: "Core 1", Kind: sensors.KindTemperature, Values: map[sensors.Subfeature]float64{
+					sensors.SubfeatureInput: 96,
+				}},
+			},
+		},
+	}))
+
+	err = d.Dispatch(context.Background(), events, evaluator.Alerts())
+	require.EqualError(t, err, "failed to notify failing: connection refused\n"+
+		"failed to notify failing: connection refused\n"+
+		"failed to notify panicking: notifier panicked: boom\n"+
+		"failed to notify panicking: notifier panicked: boom")
+
+	// The failing notifiers do not stop the others being sent to.
+	require.Equal(t, []string{
+		"coretemp-isa-0000/Core 0 is at 85.0°C: at or above warn threshold 80 (80.0°C)",
+		"coretemp-isa-0000/Core 1 is at 96.0°C: at or above critical threshold 95 (95.0°C)",
+	}, notifiers["all"].messages)
+	require.Equal(t, notifiers["all"].messages, notifiers["failing"].messages)
+
+	// Notifiers only receive alerts at or above their minimum severity.
+	require.Equal(t, []string{
+		"coretemp-isa-0000/Core 1 is at 96.0°C: at or above critical threshold 95 (95.0°C)",
+	}, notifiers["critical"].messages)
+
+	// Every notification is recorded, whether or not it could be sent.
+	require.Equal(t, []string{
+		"failing: cpu:coretemp-isa-0000/Core 0: connection refused",
+		"failing: cpu:coretemp-isa-0000/Core 1: connection refused",
+		"panicking: cpu:coretemp-isa-0000/Core 0: notifier panicked: boom",
+		"panicking: cpu:coretemp-isa-0000/Core 1: notifier panicked: boom",
+		"all: cpu:coretemp-isa-0000/Core 0",
+		"all: cpu:coretemp-isa-0000/Core 1",
+		"critical: cpu:coretemp-isa-0000/Core 1",
+	}, recorder.sent)
+}
+
+func TestDispatcher_Dispatch_Resolved(t *testing.T) {
+	t.Parallel()
+
+	critical := &fakeNotifier{name: "critical"}
+	registry := NewRegistry()
+	registry.Register("fake", func(*config.Notifier) (Notifier, error) { return critical, nil })
+
+	clock := fixedClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	d, err := NewDispatcher(registry, []*config.Notifier{
+		{Name: "critical", Type: "fake", MinSeverity: config.SeverityCritical},
+	}, clock)
+	require.NoError(t, err)
+
+	evaluator := alert.NewEvaluator([]*config.Rule{
+		{Name: "cpu", Match: "*", Warn: &config.Threshold{Offset: 80}, Critical: &config.Threshold{Offset: 95}},
+	}, alert.WithClock(clock))
+	dispatch := func(value float64) {
+		events := evaluator.Evaluate(sensors.NewSnapshot(clock.Now(), []*sensors.Chip{{
+			Name: "coretemp-isa-0000",
+			Features: []*sensors.Feature{
+				{Name: "Core 0", Kind: sensors.KindTemperature, Values: map[sensors.Subfeature]float64{
+					sensors.SubfeatureInput: value,
+				}},
+			},
+		}}))
+		require.NoError(t, d.Dispatch(context.Background(), events, evaluator.Alerts()))
+	}
+
+	// An alert that fired as critical and became a warning before it resolved is resolved at the notifier it was
+	// sent to as critical.
+	dispatch(96)
+	dispatch(85)
+	dispatch(60)
+	require.Equal(t, []string{
+		"coretemp-isa-0000/Core 0 is at 96.0°C: at or above critical threshold 95 (95.0°C)",
+		"coretemp-isa-0000/Core 0 has recovered at 60.0°C after 0s",
+	}, critical.messages)
+
+	// An alert that only ever fired as a warning is not.
+	dispatch(85)
+	dispatch(60)
+	require.Len(t, critical.messages, 2)
+}
+
+// slowNotifier blocks until the context is done, like a notifier retrying an endpoint that is down.
+type slowNotifier struct {
+	fakeNotifier
+}
+
+func (n *slowNotifier) Notify(ctx context.Context, _ *alert.Notification) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// ctxNotifier fails if the context is done by the time it is sent to, and records the notification otherwise.
+type ctxNotifier struct {
+	fakeNotifier
+}
+
+func (n *ctxNotifier) Notify(ctx context.Context, notification *alert.Notification) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return n.fakeNotifier.Notify(ctx, notification)
+}
+
+func TestDispatcher_Dispatch_Slow(t *testing.T) {
+	t.Parallel()
+
+	slow := &slowNotifier{fakeNotifier{name: "slow"}}
+	working := &ctxNotifier{fakeNotifier{name: "working"}}
+	registry := NewRegistry()
+	registry.Register("slow", func(*config.Notifier) (Notifier, error) { return slow, nil })
+	registry.Register("working", func(*config.Notifier) (Notifier, error) { return working, nil })
+
+	clock := fixedClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	d, err := NewDispatcher(registry, []*config.Notifier{
+		{Name: "slow", Type: "slow", MinSeverity: config.SeverityWarning},
+		{Name: "working", Type: "working", MinSeverity: config.SeverityWarning},
+	}, clock)
+	require.NoError(t, err)
+
+	evaluator := alert.NewEvaluator([]*config.Rule{
+		{Name: "cpu", Match: "*", Critical: &config.Threshold{Offset: 95}},
+	}, alert.WithClock(clock))
+	events := evaluator.Evaluate(sensors.NewSnapshot(clock.Now(), []*sensors.Chip{{
+		Name: "coretemp-isa-0000",
+		Features: []*sensors.Feature{
+			{Name: "Core 0", Kind: sensors.KindTemperature, Values: map[sensors.Subfeature]float64{
+				sensors.SubfeatureInput: 96,
+			}},
+		},
+	}}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// A notifier that takes until the context is done does not leave the notifiers after it without time to send.
+	err = d.Dispatch(ctx, events, evaluator.Alerts())
+	require.EqualError(t, err, "failed to notify slow: context deadline exceeded")
+	require.Equal(t, []string{
+		"coretemp-isa-0000/Core 0 is at 96.0°C: at or above critical threshold 95 (95.0°C)",
+	}, working.messages)
+}
+
+// fakeFlusher batches the notifications it is sent until it is flushed.
+type fakeFlusher struct {
+	fakeNotifier
+	batched int
+	flushes []bool
+}
+
+func (f *fakeFlusher) Notify(context.Context, *alert.Notification) error {
+	f.batched++
+	return nil
+}
+
+func (f *fakeFlusher) Flush(_ context.Context, force bool) error {
+	f.flushes = append(f.flushes, force)
+	return f.err
+}
+
+func TestDispatcher_Flush(t *testing.T) {
+	t.Parallel()
+
+	flusher := &fakeFlusher{fakeNotifier: fakeNotifier{name: "digest", err: errors.New("smtp down")}}
+	plain := &fakeNotifier{name: "plain"}
+
+	registry := NewRegistry()
+	registry.Register("flusher", func(*config.Notifier) (Notifier, error) { return flusher, nil })
+	registry.Register("plain", func(*config.Notifier) (Notifier, error) { return plain, nil })
+
+	d, err := NewDispatcher(registry, []*config.Notifier{
+		{Name: "digest", Type: "flusher", MinSeverity: config.SeverityWarning},
+		{Name: "plain", Type: "plain", MinSeverity: config.SeverityWarning},
+	}, fixedClock{now: time.Now()})
+	require.NoError(t, err)
+
+	// Every dispatch gives batching notifiers the chance to send what is due.
+	require.EqualError(t, d.Dispatch(context.Background(), nil, nil), "failed to flush digest: smtp down")
+	require.EqualError(t, d.Flush(context.Background(), true), "failed to flush digest: smtp down")
+	require.Equal(t, []bool{false, true}, flusher.flushes)
+}
+
+func TestDispatcher_Adopt(t *testing.T) {
+	t.Parallel()
+
+	created := make(map[string][]*fakeFlusher)
+	registry := NewRegistry()
+	registry.Register("flusher", func(cfg *config.Notifier) (Notifier, error) {
+		f := &fakeFlusher{fakeNotifier: fakeNotifier{name: cfg.Name}}
+		created[cfg.Name] = append(created[cfg.Name], f)
+		return f, nil
+	})
+
+	clock := fixedClock{now: time.Now()}
+	prev, err := NewDispatcher(registry, []*config.Notifier{
+		{Name: "kept", Type: "flusher", MinSeverity: config.SeverityWarning},
+		{Name: "changed", Type: "flusher", MinSeverity: config.SeverityWarning},
+		{Name: "removed", Type: "flusher", MinSeverity: config.SeverityWarning},
+	}, clock)
+	require.NoError(t, err)
+
+	d, err := NewDispatcher(registry, []*config.Notifier{
+		{Name: "added", Type: "flusher", MinSeverity: config.SeverityWarning},
+		{Name: "changed", Type: "flusher", MinSeverity: config.SeverityCritical},
+		{Name: "kept", Type: "flusher", MinSeverity: config.SeverityWarning},
+	}, clock)
+	require.NoError(t, err)
+	require.NoError(t, d.Adopt(context.Background(), prev))
+
+	// The unchanged notifier is taken over, while those changed or removed are flushed of what they batched.
+	require.Same(t, created["kept"][0], d.targets[2].notifier)
+	require.Same(t, created["changed"][1], d.targets[1].notifier)
+	require.Same(t, created["added"][0], d.targets[0].notifier)
+	require.Empty(t, created["kept"][0].flushes)
+	require.Equal(t, []bool{true}, created["changed"][0].flushes)
+	require.Equal(t, []bool{true}, created["removed"][0].flushes)
+}
@@ -0,0 +1,128 @@
+// Package notifytest provides helpers for testing notifiers.
+package notifytest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// FiredAt is when the alerts of test notifications fired.
+var FiredAt = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// Notification returns a notification of the "cores" rule for a core temperature sensor. Resolved alerts resolve
+// five minutes after they fired.
+func Notification(feature string, value float64, severity alert.Severity, state alert.State) *alert.Notification {
+	at := FiredAt
+	if state == alert.StateResolved {
+		at = FiredAt.Add(5 * time.Minute)
+	}
+
+	return &alert.Notification{
+		Event: &alert.Event{
+			Alert: alert.Alert{
+				Reading: &sensors.Reading{
+					Chip:    "coretemp-isa-0000",
+					Adapter: "ISA adapter",
+					Feature: &sensors.Feature{
+						Name: feature,
+						Kind: sensors.KindTemperature,
+						Values: map[sensors.Subfeature]float64{
+							sensors.SubfeatureInput: value,
+							sensors.SubfeatureCrit:  100,
+						},
+					},
+				},
+				Rule: &config.Rule{
+					Name:     "cores",
+					Warn:     &config.Threshold{Offset: 90},
+					Critical: &config.Threshold{Limit: sensors.SubfeatureCrit},
+				},
+				State:      state,
+				Severity:   severity,
+				Value:      value,
+				Threshold:  90,
+				Reason:     "at or above warn threshold 90 (90.0°C)",
+				StartedAt:  FiredAt,
+				FiredAt:    FiredAt,
+				ResolvedAt: at,
+			},
+			From: alert.StatePending,
+			Time: at,
+		},
+	}
+}
+
+// Request is a request received by a Server. The path is kept escaped, as it was sent.
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   []byte
+}
+
+// Decode decodes the JSON body of the request.
+func (r *Request) Decode(t *testing.T, v any) {
+	t.Helper()
+	require.NoError(t, json.Unmarshal(r.Body, v))
+}
+
+// Server is an HTTP server recording the requests it receives.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []*Request
+}
+
+// NewServer starts a server answering every request with the status and body returned by respond. The server is
+// closed when the test finishes.
+func NewServer(t *testing.T, respond func(r *Request) (int, string)) *Server {
+	t.Helper()
+
+	s := new(Server)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		req := &Request{
+			Method: r.Method,
+			Path:   r.URL.EscapedPath(),
+			Query:  r.URL.RawQuery,
+			Header: r.Header.Clone(),
+			Body:   body,
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, req)
+		s.mu.Unlock()
+
+		status, resp := respond(req)
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, resp)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Request(nil), s.requests...)
+}
@@ -0,0 +1,88 @@
+package ntfy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+)
+
+// message is a message published as JSON.
+type message struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// Notifier publishes alerts to an ntfy topic.
+type Notifier struct {
+	name   string
+	cfg    *config.Ntfy
+	host   string
+	server string
+	topic  string
+	client *notify.HTTPClient
+}
+
+// New creates an ntfy notifier from its configuration.
+func New(cfg *config.Notifier) (notify.Notifier, error) {
+	if cfg.Ntfy == nil {
+		return nil, errors.New("missing ntfy configuration")
+	}
+
+	// Messages are published as JSON to the server the topic is on, naming the topic in the message.
+	u, err := url.Parse(cfg.Ntfy.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse topic URL: %w", err)
+	}
+
+	topic := path.Base(u.Path)
+	if topic == "/" || topic == "." {
+		return nil, fmt.Errorf("topic URL %q has no topic", cfg.Ntfy.URL)
+	}
+	u.Path = path.Dir(u.Path)
+
+	return &Notifier{
+		name:   cfg.Name,
+		cfg:    cfg.Ntfy,
+		host:   notify.Hostname(),
+		server: u.String(),
+		topic:  topic,
+		client: notify.NewHTTPClient(cfg.Ntfy.HTTPOptions),
+	}, nil
+}
+
+// Name returns the name of the notifier.
+func (n *Notifier) Name() string {
+	return n.name
+}
+
+// Notify publishes the notification with the priority of its level.
+func (n *Notifier) Notify(ctx context.Context, notification *alert.Notification) error {
+	msg := &message{
+		Topic:    n.topic,
+		Title:    notify.Title(notification),
+		Message:  notify.Summary(notification, n.host),
+		Priority: n.cfg.Priority.For(notify.Level(notification)),
+		Tags:     n.cfg.Tags,
+	}
+
+	var header http.Header
+	if n.cfg.Token != "" {
+		header = http.Header{"Authorization": {"Bearer " + n.cfg.Token}}
+	}
+
+	if _, err := n.client.JSON(ctx, http.MethodPost, n.server, header, msg); err != nil {
+		return fmt.Errorf("failed to publish to ntfy: %w", err)
+	}
+
+	return nil
+}
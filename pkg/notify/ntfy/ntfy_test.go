@@ -0,0 +1,104 @@
+package ntfy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/notifytest"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		url        string
+		wantServer string
+		wantTopic  string
+		wantErr    string
+	}{
+		{
+			name:       "ntfy.sh",
+			url:        "https://ntfy.sh/build-box-alerts",
+			wantServer: "https://ntfy.sh/",
+			wantTopic:  "build-box-alerts",
+		},
+		{
+			name:       "self-hosted under a path",
+			url:        "https://example.com/ntfy/alerts",
+			wantServer: "https://example.com/ntfy",
+			wantTopic:  "alerts",
+		},
+		{
+			name:    "no topic",
+			url:     "https://ntfy.sh/",
+			wantErr: `topic URL "https://ntfy.sh/" has no topic`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := config.DefaultNtfy()
+			cfg.URL = tt.url
+
+			n, err := New(&config.Notifier{Name: "ntfy", Type: config.NotifierNtfy, Ntfy: cfg})
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantServer, n.(*Notifier).server)
+			require.Equal(t, tt.wantTopic, n.(*Notifier).topic)
+		})
+	}
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	t.Parallel()
+
+	srv := notifytest.NewServer(t, func(*notifytest.Request) (int, string) {
+		return http.StatusOK, `{"id": "sPs1"}`
+	})
+
+	cfg := config.DefaultNtfy()
+	cfg.URL = srv.URL + "/build-box-alerts"
+	cfg.Token = "tk_token"
+	cfg.Tags = []string{"thermometer"}
+
+	n, err := New(&config.Notifier{Name: "ntfy", Type: config.NotifierNtfy, Ntfy: cfg})
+	require.NoError(t, err)
+	n.(*Notifier).host = "render-01"
+
+	ctx := context.Background()
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 91, alert.SeverityWarning, alert.StateFiring)))
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 101, alert.SeverityCritical, alert.StateFiring)))
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 80, alert.SeverityCritical, alert.StateResolved)))
+
+	requests := srv.Requests()
+	require.Len(t, requests, 3)
+	require.Equal(t, "/", requests[0].Path)
+	require.Equal(t, "Bearer tk_token", requests[0].Header.Get("Authorization"))
+	require.JSONEq(t, `{
+		"topic": "build-box-alerts",
+		"title": "⚠ Sensor Alert",
+		"message": "coretemp-isa-0000/Core 0 is at 91.0°C: at or above warn threshold 90 (90.0°C)\nHost: render-01",
+		"priority": 4,
+		"tags": ["thermometer"]
+	}`, string(requests[0].Body))
+
+	priorities := make([]int, 0, len(requests))
+	for _, r := range requests {
+		var msg message
+		r.Decode(t, &msg)
+		priorities = append(priorities, msg.Priority)
+	}
+	require.Equal(t, []int{4, 5, 3}, priorities)
+}
@@ -0,0 +1,126 @@
+package opsgenie
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+)
+
+// source is the source recorded on alerts and their actions.
+const source = "sensor-monitor"
+
+// maxMessage is the longest message Opsgenie accepts.
+const maxMessage = 130
+
+// create is the request creating an alert.
+type create struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details"`
+	Entity      string            `json:"entity"`
+	Source      string            `json:"source"`
+	Priority    string            `json:"priority"`
+}
+
+// action is the request closing or acknowledging an alert.
+type action struct {
+	Source string `json:"source"`
+	Note   string `json:"note"`
+}
+
+// Notifier creates Opsgenie alerts. Every alert of a sensor and rule is one Opsgenie alert, created when it fires and
+// closed or acknowledged when it recovers.
+type Notifier struct {
+	name   string
+	cfg    *config.Opsgenie
+	host   string
+	client *notify.HTTPClient
+}
+
+// New creates an Opsgenie notifier from its configuration.
+func New(cfg *config.Notifier) (notify.Notifier, error) {
+	if cfg.Opsgenie == nil {
+		return nil, errors.New("missing opsgenie configuration")
+	}
+
+	return &Notifier{
+		name:   cfg.Name,
+		cfg:    cfg.Opsgenie,
+		host:   notify.Hostname(),
+		client: notify.NewHTTPClient(cfg.Opsgenie.HTTPOptions),
+	}, nil
+}
+
+// Name returns the name of the notifier.
+func (n *Notifier) Name() string {
+	return n.name
+}
+
+// Notify creates the alert of a firing notification, or closes or acknowledges it once it resolves. Creating an alert
+// with the alias of an open alert adds to that alert rather than opening another.
+func (n *Notifier) Notify(ctx context.Context, notification *alert.Notification) error {
+	alias := notify.IncidentKey(notification, n.host)
+	header := http.Header{"Authorization": {"GenieKey " + n.cfg.APIKey}}
+
+	if notification.State != alert.StateResolved {
+		if _, err := n.client.JSON(ctx, http.MethodPost, n.endpoint(), header, n.create(notification, alias)); err != nil {
+			return fmt.Errorf("failed to create opsgenie alert: %w", err)
+		}
+		return nil
+	}
+
+	verb := "close"
+	if !n.cfg.AutoResolve {
+		verb = "acknowledge"
+	}
+
+	endpoint := n.endpoint(alias, verb) + "?identifierType=alias"
+	body := &action{Source: source, Note: notification.Message()}
+	if _, err := n.client.JSON(ctx, http.MethodPost, endpoint, header, body); err != nil {
+		return fmt.Errorf("failed to %s opsgenie alert: %w", verb, err)
+	}
+
+	return nil
+}
+
+// create builds the request creating the alert of the notification.
+func (n *Notifier) create(notification *alert.Notification, alias string) *create {
+	details := make(map[string]string)
+	for _, f := range notify.Facts(notification, n.host) {
+		details[f.Name] = f.Value
+	}
+
+	priority := n.cfg.Priority.Warning
+	if notification.Severity >= alert.SeverityCritical {
+		priority = n.cfg.Priority.Critical
+	}
+
+	return &create{
+		Message:     notify.Truncate(notification.Message(), maxMessage),
+		Alias:       alias,
+		Description: notify.Summary(notification, n.host),
+		Tags:        n.cfg.Tags,
+		Details:     details,
+		Entity:      n.host,
+		Source:      source,
+		Priority:    priority,
+	}
+}
+
+// endpoint returns the URL of the alerts API, or of an alert and action when given.
+func (n *Notifier) endpoint(elems ...string) string {
+	for i, e := range elems {
+		elems[i] = url.PathEscape(e)
+	}
+
+	return strings.TrimSuffix(n.cfg.APIURL, "/") + "/" + strings.Join(append([]string{"v2", "alerts"}, elems...), "/")
+}
@@ -0,0 +1,94 @@
+package opsgenie
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/notifytest"
+)
+
+func TestNotifier_Notify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		autoResolve bool
+		wantPath    string
+	}{
+		{
+			name:        "auto resolve",
+			autoResolve: true,
+			wantPath:    "/v2/alerts/render-01%2Fcores:coretemp-isa-0000%2FCore%200/close",
+		},
+		{
+			name:        "acknowledge",
+			autoResolve: false,
+			wantPath:    "/v2/alerts/render-01%2Fcores:coretemp-isa-0000%2FCore%200/acknowledge",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := notifytest.NewServer(t, func(r *notifytest.Request) (int, string) {
+				if r.Header.Get("Authorization") != "GenieKey api-key" {
+					return http.StatusUnauthorized, `{"message": "Could not authenticate"}`
+				}
+				return http.StatusAccepted, `{"result": "Request will be processed", "requestId": "43a29c5c"}`
+			})
+
+			cfg := config.DefaultOpsgenie()
+			cfg.APIKey = "api-key"
+			cfg.APIURL = srv.URL
+			cfg.Tags = []string{"render-farm"}
+			cfg.AutoResolve = tt.autoResolve
+
+			n, err := New(&config.Notifier{Name: "opsgenie", Type: config.NotifierOpsgenie, Opsgenie: cfg})
+			require.NoError(t, err)
+			n.(*Notifier).host = "render-01"
+
+			ctx := context.Background()
+			require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 101, alert.SeverityCritical, alert.StateFiring)))
+			require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 80, alert.SeverityCritical, alert.StateResolved)))
+
+			requests := srv.Requests()
+			require.Len(t, requests, 2)
+
+			require.Equal(t, "/v2/alerts", requests[0].Path)
+			require.JSONEq(t, `{
+				"message": "coretemp-isa-0000/Core 0 is at 101.0°C: at or above warn threshold 90 (90.0°C)",
+				"alias": "render-01/cores:coretemp-isa-0000/Core 0",
+				"description": "coretemp-isa-0000/Core 0 is at 101.0°C: at or above warn threshold 90 (90.0°C)\nHost: render-01",
+				"tags": ["render-farm"],
+				"details": {
+					"Sensor": "coretemp-isa-0000/Core 0",
+					"Value": "101.0°C",
+					"Severity": "critical",
+					"State": "firing",
+					"Rule": "cores",
+					"Host": "render-01"
+				},
+				"entity": "render-01",
+				"source": "sensor-monitor",
+				"priority": "P1"
+			}`, string(requests[0].Body))
+
+			require.Equal(t, tt.wantPath, requests[1].Path)
+			require.Equal(t, "identifierType=alias", requests[1].Query)
+			require.JSONEq(t, `{
+				"source": "sensor-monitor",
+				"note": "coretemp-isa-0000/Core 0 has recovered at 80.0°C after 5m0s"
+			}`, string(requests[1].Body))
+
+			cfg.APIKey = "revoked"
+			err = n.Notify(ctx, notifytest.Notification("Core 0", 91, alert.SeverityWarning, alert.StateFiring))
+			require.EqualError(t, err, `failed to create opsgenie alert: unexpected status 401: {"message": "Could not authenticate"}`)
+		})
+	}
+}
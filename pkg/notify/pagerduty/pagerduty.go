@@ -0,0 +1,137 @@
+package pagerduty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+)
+
+// Action is the action of an event.
+type Action string
+
+const (
+	// ActionTrigger opens an incident, or adds to the open incident with the same dedup key.
+	ActionTrigger Action = "trigger"
+
+	// ActionAcknowledge acknowledges the incident with the dedup key.
+	ActionAcknowledge Action = "acknowledge"
+
+	// ActionResolve resolves the incident with the dedup key.
+	ActionResolve Action = "resolve"
+)
+
+// event is an event of the Events API v2.
+type event struct {
+	RoutingKey  string   `json:"routing_key"`
+	EventAction Action   `json:"event_action"`
+	DedupKey    string   `json:"dedup_key"`
+	Client      string   `json:"client,omitempty"`
+	Payload     *payload `json:"payload,omitempty"`
+}
+
+// payload describes the alert of a trigger event.
+type payload struct {
+	Summary       string         `json:"summary"`
+	Source        string         `json:"source"`
+	Severity      string         `json:"severity"`
+	Timestamp     time.Time      `json:"timestamp"`
+	Component     string         `json:"component"`
+	Group         string         `json:"group"`
+	Class         string         `json:"class"`
+	CustomDetails map[string]any `json:"custom_details"`
+}
+
+// maxSummary is the longest summary PagerDuty accepts.
+const maxSummary = 1024
+
+// Notifier sends alerts to PagerDuty. Every alert of a sensor and rule is one incident, triggered when it fires and
+// resolved or acknowledged when it recovers.
+type Notifier struct {
+	name   string
+	cfg    *config.PagerDuty
+	host   string
+	client *notify.HTTPClient
+}
+
+// New creates a PagerDuty notifier from its configuration.
+func New(cfg *config.Notifier) (notify.Notifier, error) {
+	if cfg.PagerDuty == nil {
+		return nil, errors.New("missing pagerduty configuration")
+	}
+
+	return &Notifier{
+		name:   cfg.Name,
+		cfg:    cfg.PagerDuty,
+		host:   notify.Hostname(),
+		client: notify.NewHTTPClient(cfg.PagerDuty.HTTPOptions),
+	}, nil
+}
+
+// Name returns the name of the notifier.
+func (n *Notifier) Name() string {
+	return n.name
+}
+
+// Notify sends the event of the notification.
+func (n *Notifier) Notify(ctx context.Context, notification *alert.Notification) error {
+	e := &event{
+		RoutingKey:  n.cfg.RoutingKey,
+		EventAction: n.action(notification),
+		DedupKey:    notify.IncidentKey(notification, n.host),
+	}
+
+	if e.EventAction == ActionTrigger {
+		e.Client = "sensor-monitor"
+		e.Payload = n.payload(notification)
+	}
+
+	if _, err := n.client.JSON(ctx, http.MethodPost, n.cfg.URL, nil, e); err != nil {
+		return fmt.Errorf("failed to send %s event to pagerduty: %w", e.EventAction, err)
+	}
+
+	return nil
+}
+
+// action returns the action of the event of the notification.
+func (n *Notifier) action(notification *alert.Notification) Action {
+	switch {
+	case notification.State != alert.StateResolved:
+		return ActionTrigger
+	case n.cfg.AutoResolve:
+		return ActionResolve
+	default:
+		return ActionAcknowledge
+	}
+}
+
+// payload builds the payload of a trigger event for the notification.
+func (n *Notifier) payload(notification *alert.Notification) *payload {
+	details := make(map[string]any)
+	for _, f := range notify.Facts(notification, n.host) {
+		details[f.Name] = f.Value
+	}
+	details["Reason"] = notification.Reason
+	details["Thresholds"] = notification.Thresholds()
+
+	severity := "warning"
+	if notification.Severity >= alert.SeverityCritical {
+		severity = "critical"
+	}
+
+	return &payload{
+		Summary:       notify.Truncate(notification.Message()+" on "+n.host, maxSummary),
+		Source:        n.host,
+		Severity:      severity,
+		Timestamp:     notification.Time,
+		Component:     notification.Reading.ID(),
+		Group:         notification.Reading.Chip,
+		Class:         notification.Rule.Name,
+		CustomDetails: details,
+	}
+}
@@ -0,0 +1,123 @@
+package pagerduty
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/notifytest"
+)
+
+func TestNotifier_Notify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		autoResolve bool
+		wantActions []Action
+	}{
+		{
+			name:        "auto resolve",
+			autoResolve: true,
+			wantActions: []Action{ActionTrigger, ActionTrigger, ActionResolve},
+		},
+		{
+			name:        "acknowledge",
+			autoResolve: false,
+			wantActions: []Action{ActionTrigger, ActionTrigger, ActionAcknowledge},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := notifytest.NewServer(t, func(*notifytest.Request) (int, string) {
+				return http.StatusAccepted, `{"status": "success", "message": "Event processed"}`
+			})
+
+			cfg := config.DefaultPagerDuty()
+			cfg.RoutingKey = "R0UT1NGK3Y"
+			cfg.URL = srv.URL + "/v2/enqueue"
+			cfg.AutoResolve = tt.autoResolve
+
+			n, err := New(&config.Notifier{Name: "pagerduty", Type: config.NotifierPagerDuty, PagerDuty: cfg})
+			require.NoError(t, err)
+			n.(*Notifier).host = "render-01"
+
+			ctx := context.Background()
+			require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 91, alert.SeverityWarning, alert.StateFiring)))
+			require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 101, alert.SeverityCritical, alert.StateFiring)))
+			require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 80, alert.SeverityCritical, alert.StateResolved)))
+
+			requests := srv.Requests()
+			actions := make([]Action, 0, len(requests))
+			for _, r := range requests {
+				require.Equal(t, "/v2/enqueue", r.Path)
+
+				var e event
+				r.Decode(t, &e)
+				require.Equal(t, "render-01/cores:coretemp-isa-0000/Core 0", e.DedupKey)
+				actions = append(actions, e.EventAction)
+			}
+			require.Equal(t, tt.wantActions, actions)
+
+			require.JSONEq(t, `{
+				"routing_key": "R0UT1NGK3Y",
+				"event_action": "trigger",
+				"dedup_key": "render-01/cores:coretemp-isa-0000/Core 0",
+				"client": "sensor-monitor",
+				"payload": {
+					"summary": "coretemp-isa-0000/Core 0 is at 101.0°C: at or above warn threshold 90 (90.0°C) on render-01",
+					"source": "render-01",
+					"severity": "critical",
+					"timestamp": "2025-06-01T12:00:00Z",
+					"component": "coretemp-isa-0000/Core 0",
+					"group": "coretemp-isa-0000",
+					"class": "cores",
+					"custom_details": {
+						"Sensor": "coretemp-isa-0000/Core 0",
+						"Value": "101.0°C",
+						"Severity": "critical",
+						"State": "firing",
+						"Rule": "cores",
+						"Host": "render-01",
+						"Reason": "at or above warn threshold 90 (90.0°C)",
+						"Thresholds": {"warn": 90, "critical": 100}
+					}
+				}
+			}`, string(requests[1].Body))
+
+			// Resolving and acknowledging only need the dedup key of the incident.
+			require.JSONEq(t, `{
+				"routing_key": "R0UT1NGK3Y",
+				"event_action": "`+string(tt.wantActions[2])+`",
+				"dedup_key": "render-01/cores:coretemp-isa-0000/Core 0"
+			}`, string(requests[2].Body))
+		})
+	}
+}
+
+func TestNotifier_Notify_Errors(t *testing.T) {
+	t.Parallel()
+
+	srv := notifytest.NewServer(t, func(*notifytest.Request) (int, string) {
+		return http.StatusBadRequest, `{"status": "invalid event", "errors": ["'routing_key' is invalid"]}`
+	})
+
+	cfg := config.DefaultPagerDuty()
+	cfg.RoutingKey = "wrong"
+	cfg.URL = srv.URL
+
+	n, err := New(&config.Notifier{Name: "pagerduty", Type: config.NotifierPagerDuty, PagerDuty: cfg})
+	require.NoError(t, err)
+
+	err = n.Notify(context.Background(), notifytest.Notification("Core 0", 80, alert.SeverityWarning, alert.StateResolved))
+	require.EqualError(t, err, `failed to send resolve event to pagerduty: unexpected status 400: `+
+		`{"status": "invalid event", "errors": ["'routing_key' is invalid"]}`)
+	require.Len(t, srv.Requests(), 1)
+}
@@ -0,0 +1,143 @@
+package pushover
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+)
+
+// message is a message sent through the Pushover API.
+type message struct {
+	Token     string `json:"token"`
+	User      string `json:"user"`
+	Device    string `json:"device,omitempty"`
+	Title     string `json:"title"`
+	Message   string `json:"message"`
+	Priority  int    `json:"priority"`
+	Retry     int    `json:"retry,omitempty"`
+	Expire    int    `json:"expire,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// response is the response to sending a message. Emergency messages are given a receipt, used to cancel them.
+type response struct {
+	Status  int    `json:"status"`
+	Receipt string `json:"receipt"`
+}
+
+// cancel is the request cancelling the repeats of an emergency message.
+type cancel struct {
+	Token string `json:"token"`
+}
+
+// Notifier sends alerts with Pushover.
+type Notifier struct {
+	name     string
+	cfg      *config.Pushover
+	host     string
+	client   *notify.HTTPClient
+	receipts *notify.Threads
+}
+
+// New creates a Pushover notifier from its configuration.
+func New(cfg *config.Notifier) (notify.Notifier, error) {
+	if cfg.Pushover == nil {
+		return nil, errors.New("missing pushover configuration")
+	}
+
+	return &Notifier{
+		name:     cfg.Name,
+		cfg:      cfg.Pushover,
+		host:     notify.Hostname(),
+		client:   notify.NewHTTPClient(cfg.Pushover.HTTPOptions),
+		receipts: notify.NewThreads(),
+	}, nil
+}
+
+// Name returns the name of the notifier.
+func (n *Notifier) Name() string {
+	return n.name
+}
+
+// Notify sends the notification with the priority of its level. Emergency notifications repeat until they are
+// acknowledged, so they are cancelled once the alert resolves.
+func (n *Notifier) Notify(ctx context.Context, notification *alert.Notification) error {
+	key := notification.Key()
+	if notification.State == alert.StateResolved {
+		if err := n.cancel(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	msg := &message{
+		Token:     n.cfg.Token,
+		User:      n.cfg.UserKey,
+		Device:    n.cfg.Device,
+		Title:     notify.Title(notification),
+		Message:   notify.Summary(notification, n.host),
+		Priority:  n.cfg.Priority.For(notify.Level(notification)),
+		Timestamp: notification.Time.Unix(),
+	}
+
+	if msg.Priority == config.PushoverPriorityEmergency {
+		msg.Retry = int(n.cfg.Retry.Std().Seconds())
+		msg.Expire = int(n.cfg.Expire.Std().Seconds())
+	}
+
+	body, err := n.client.JSON(ctx, http.MethodPost, n.endpoint("messages.json"), nil, msg)
+	if err != nil {
+		return fmt.Errorf("failed to send to pushover: %w", err)
+	}
+
+	resp := new(response)
+	if err := json.Unmarshal(body, resp); err != nil {
+		return fmt.Errorf("failed to decode pushover response: %w", err)
+	}
+
+	if resp.Status != 1 {
+		return fmt.Errorf("failed to send to pushover: unexpected status %d", resp.Status)
+	}
+
+	if resp.Receipt != "" {
+		// An escalated alert replaces the emergency of the alert, so only its latest receipt is kept.
+		if err := n.cancel(ctx, key); err != nil {
+			return err
+		}
+		n.receipts.Set(key, resp.Receipt)
+	}
+
+	return nil
+}
+
+// cancel stops the repeats of the emergency notification of the alert with the given key, if it has one.
+func (n *Notifier) cancel(ctx context.Context, key string) error {
+	receipt, ok := n.receipts.Get(key)
+	if !ok {
+		return nil
+	}
+
+	endpoint := n.endpoint("receipts", receipt, "cancel.json")
+	if _, err := n.client.JSON(ctx, http.MethodPost, endpoint, nil, &cancel{Token: n.cfg.Token}); err != nil {
+		return fmt.Errorf("failed to cancel pushover emergency: %w", err)
+	}
+
+	n.receipts.Delete(key)
+	return nil
+}
+
+// endpoint returns the URL of an API endpoint.
+func (n *Notifier) endpoint(elems ...string) string {
+	for i, e := range elems {
+		elems[i] = url.PathEscape(e)
+	}
+
+	return strings.TrimSuffix(n.cfg.APIURL, "/") + "/" + strings.Join(elems, "/")
+}
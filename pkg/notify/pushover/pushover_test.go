@@ -0,0 +1,111 @@
+package pushover
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/notifytest"
+)
+
+func TestNotifier_Notify(t *testing.T) {
+	t.Parallel()
+
+	receipts := []string{"r1", "r2"}
+	srv := notifytest.NewServer(t, func(r *notifytest.Request) (int, string) {
+		var msg message
+		if r.Path == "/1/messages.json" {
+			r.Decode(t, &msg)
+		}
+
+		if msg.Priority != config.PushoverPriorityEmergency {
+			return http.StatusOK, `{"status": 1, "request": "req"}`
+		}
+
+		receipt := receipts[0]
+		receipts = receipts[1:]
+		return http.StatusOK, `{"status": 1, "request": "req", "receipt": "` + receipt + `"}`
+	})
+
+	cfg := config.DefaultPushover()
+	cfg.Token = "app-token"
+	cfg.UserKey = "user-key"
+	cfg.APIURL = srv.URL + "/1"
+
+	n, err := New(&config.Notifier{Name: "pushover", Type: config.NotifierPushover, Pushover: cfg})
+	require.NoError(t, err)
+	n.(*Notifier).host = "render-01"
+
+	ctx := context.Background()
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 91, alert.SeverityWarning, alert.StateFiring)))
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 101, alert.SeverityCritical, alert.StateFiring)))
+
+	// A reminder of the emergency replaces it, and it is cancelled once the alert resolves.
+	reminder := notifytest.Notification("Core 0", 102, alert.SeverityCritical, alert.StateFiring)
+	reminder.Reminder = true
+	require.NoError(t, n.Notify(ctx, reminder))
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 80, alert.SeverityCritical, alert.StateResolved)))
+
+	requests := srv.Requests()
+	paths := make([]string, 0, len(requests))
+	for _, r := range requests {
+		paths = append(paths, r.Path)
+	}
+	require.Equal(t, []string{
+		"/1/messages.json",
+		"/1/messages.json",
+		"/1/messages.json",
+		"/1/receipts/r1/cancel.json",
+		"/1/receipts/r2/cancel.json",
+		"/1/messages.json",
+	}, paths)
+
+	require.JSONEq(t, `{
+		"token": "app-token",
+		"user": "user-key",
+		"title": "⚠ Sensor Alert",
+		"message": "coretemp-isa-0000/Core 0 is at 91.0°C: at or above warn threshold 90 (90.0°C)\nHost: render-01",
+		"priority": 0,
+		"timestamp": 1748779200
+	}`, string(requests[0].Body))
+
+	var emergency message
+	requests[1].Decode(t, &emergency)
+	require.Equal(t, config.PushoverPriorityEmergency, emergency.Priority)
+	require.Equal(t, 60, emergency.Retry)
+	require.Equal(t, 3600, emergency.Expire)
+
+	require.JSONEq(t, `{"token": "app-token"}`, string(requests[3].Body))
+
+	var resolved message
+	requests[5].Decode(t, &resolved)
+	require.Equal(t, -1, resolved.Priority)
+	require.Zero(t, resolved.Retry)
+
+	_, ok := n.(*Notifier).receipts.Get(reminder.Key())
+	require.False(t, ok)
+}
+
+func TestNotifier_Notify_Errors(t *testing.T) {
+	t.Parallel()
+
+	srv := notifytest.NewServer(t, func(*notifytest.Request) (int, string) {
+		return http.StatusBadRequest, `{"user": "invalid", "errors": ["user identifier is invalid"], "status": 0}`
+	})
+
+	cfg := config.DefaultPushover()
+	cfg.Token = "app-token"
+	cfg.UserKey = "not-a-user"
+	cfg.APIURL = srv.URL
+
+	n, err := New(&config.Notifier{Name: "pushover", Type: config.NotifierPushover, Pushover: cfg})
+	require.NoError(t, err)
+
+	err = n.Notify(context.Background(), notifytest.Notification("Core 0", 91, alert.SeverityWarning, alert.StateFiring))
+	require.EqualError(t, err, `failed to send to pushover: unexpected status 400: `+
+		`{"user": "invalid", "errors": ["user identifier is invalid"], "status": 0}`)
+}
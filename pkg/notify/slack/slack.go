@@ -0,0 +1,155 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+)
+
+// message is a Slack message. The blocks are wrapped in an attachment so that they get the colour bar of the severity.
+type message struct {
+	Channel     string       `json:"channel,omitempty"`
+	Text        string       `json:"text"`
+	ThreadTS    string       `json:"thread_ts,omitempty"`
+	Attachments []attachment `json:"attachments"`
+}
+
+// attachment is a message attachment holding Block Kit blocks.
+type attachment struct {
+	Color  string  `json:"color"`
+	Blocks []block `json:"blocks"`
+}
+
+// block is a Block Kit layout block.
+type block struct {
+	Type     string  `json:"type"`
+	Text     *text   `json:"text,omitempty"`
+	Fields   []*text `json:"fields,omitempty"`
+	Elements []*text `json:"elements,omitempty"`
+}
+
+// text is a Block Kit text object.
+type text struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+// response is the response of the Web API.
+type response struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+	TS    string `json:"ts"`
+}
+
+// Notifier posts alerts to Slack.
+type Notifier struct {
+	name    string
+	cfg     *config.Slack
+	host    string
+	client  *notify.HTTPClient
+	threads *notify.Threads
+}
+
+// New creates a Slack notifier from its configuration.
+func New(cfg *config.Notifier) (notify.Notifier, error) {
+	if cfg.Slack == nil {
+		return nil, errors.New("missing slack configuration")
+	}
+
+	return &Notifier{
+		name:    cfg.Name,
+		cfg:     cfg.Slack,
+		host:    notify.Hostname(),
+		client:  notify.NewHTTPClient(cfg.Slack.HTTPOptions),
+		threads: notify.NewThreads(),
+	}, nil
+}
+
+// Name returns the name of the notifier.
+func (n *Notifier) Name() string {
+	return n.name
+}
+
+// Notify posts the notification. With a bot token, later notifications of an alert are posted as replies in the
+// thread of the message posted when it fired.
+func (n *Notifier) Notify(ctx context.Context, notification *alert.Notification) error {
+	msg := n.message(notification)
+	if n.cfg.Token == "" {
+		if _, err := n.client.JSON(ctx, http.MethodPost, n.cfg.WebhookURL, nil, msg); err != nil {
+			return fmt.Errorf("failed to post to slack: %w", err)
+		}
+		return nil
+	}
+
+	key := notification.Key()
+	msg.Channel = n.cfg.Channel
+	msg.ThreadTS, _ = n.threads.Get(key)
+
+	header := http.Header{"Authorization": {"Bearer " + n.cfg.Token}}
+	body, err := n.client.JSON(ctx, http.MethodPost, n.cfg.APIURL+"/chat.postMessage", header, msg)
+	if err != nil {
+		return fmt.Errorf("failed to post to slack: %w", err)
+	}
+
+	resp := new(response)
+	if err := json.Unmarshal(body, resp); err != nil {
+		return fmt.Errorf("failed to decode slack response: %w", err)
+	}
+
+	if !resp.OK {
+		return fmt.Errorf("failed to post to slack: %s", resp.Error)
+	}
+
+	switch {
+	case notification.State == alert.StateResolved:
+		n.threads.Delete(key)
+	case msg.ThreadTS == "":
+		n.threads.Set(key, resp.TS)
+	}
+
+	return nil
+}
+
+// message builds the message for the notification.
+func (n *Notifier) message(notification *alert.Notification) *message {
+	facts := notify.Facts(notification, n.host)
+	fields := make([]*text, 0, len(facts))
+	for _, f := range facts {
+		fields = append(fields, &text{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n%s", f.Name, escape(f.Value))})
+	}
+
+	title := notify.Title(notification)
+	return &message{
+		Text: title + ": " + notification.Message(),
+		Attachments: []attachment{
+			{
+				Color: notify.Color(notification),
+				Blocks: []block{
+					{Type: "header", Text: &text{Type: "plain_text", Text: title, Emoji: true}},
+					{Type: "section", Text: &text{Type: "mrkdwn", Text: escape(notification.Message())}},
+					{Type: "section", Fields: fields},
+					{Type: "context", Elements: []*text{
+						{Type: "mrkdwn", Text: fmt.Sprintf("<!date^%d^{date_short_pretty} {time_secs}|%s>",
+							notification.Time.Unix(), notification.Time.UTC().Format("2006-01-02 15:04:05 UTC"))},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// escaper escapes the characters Slack treats as control characters in mrkdwn.
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// escape escapes text for use in mrkdwn.
+func escape(s string) string {
+	return escaper.Replace(s)
+}
@@ -0,0 +1,104 @@
+package slack
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/notifytest"
+)
+
+func newNotifier(t *testing.T, modify func(s *config.Slack)) *Notifier {
+	t.Helper()
+
+	cfg := config.DefaultSlack()
+	modify(cfg)
+
+	n, err := New(&config.Notifier{Name: "slack", Type: config.NotifierSlack, Slack: cfg})
+	require.NoError(t, err)
+
+	notifier := n.(*Notifier)
+	notifier.host = "render-01"
+	return notifier
+}
+
+func TestNotifier_Notify_Webhook(t *testing.T) {
+	t.Parallel()
+
+	srv := notifytest.NewServer(t, func(*notifytest.Request) (int, string) {
+		return http.StatusOK, "ok"
+	})
+	n := newNotifier(t, func(s *config.Slack) {
+		s.WebhookURL = srv.URL + "/services/T000/B000/XXX"
+	})
+
+	require.NoError(t, n.Notify(context.Background(), notifytest.Notification("Core <0>", 101, alert.SeverityCritical, alert.StateFiring)))
+
+	requests := srv.Requests()
+	require.Len(t, requests, 1)
+	require.Equal(t, "/services/T000/B000/XXX", requests[0].Path)
+	require.JSONEq(t, `{
+		"text": "🔥 Sensor Critical!: coretemp-isa-0000/Core <0> is at 101.0°C: at or above warn threshold 90 (90.0°C)",
+		"attachments": [{
+			"color": "#d32f2f",
+			"blocks": [
+				{"type": "header", "text": {"type": "plain_text", "text": "🔥 Sensor Critical!", "emoji": true}},
+				{"type": "section", "text": {"type": "mrkdwn", "text": "coretemp-isa-0000/Core &lt;0&gt; is at 101.0°C: at or above warn threshold 90 (90.0°C)"}},
+				{"type": "section", "fields": [
+					{"type": "mrkdwn", "text": "*Sensor*\ncoretemp-isa-0000/Core &lt;0&gt;"},
+					{"type": "mrkdwn", "text": "*Value*\n101.0°C"},
+					{"type": "mrkdwn", "text": "*Severity*\ncritical"},
+					{"type": "mrkdwn", "text": "*State*\nfiring"},
+					{"type": "mrkdwn", "text": "*Rule*\ncores"},
+					{"type": "mrkdwn", "text": "*Host*\nrender-01"}
+				]},
+				{"type": "context", "elements": [
+					{"type": "mrkdwn", "text": "<!date^1748779200^{date_short_pretty} {time_secs}|2025-06-01 12:00:00 UTC>"}
+				]}
+			]
+		}]
+	}`, string(requests[0].Body))
+}
+
+func TestNotifier_Notify_Threads(t *testing.T) {
+	t.Parallel()
+
+	srv := notifytest.NewServer(t, func(r *notifytest.Request) (int, string) {
+		if r.Header.Get("Authorization") != "Bearer xoxb-token" {
+			return http.StatusOK, `{"ok": false, "error": "invalid_auth"}`
+		}
+		return http.StatusOK, `{"ok": true, "ts": "1748779200.000100"}`
+	})
+	n := newNotifier(t, func(s *config.Slack) {
+		s.Token = "xoxb-token"
+		s.Channel = "#alerts"
+		s.APIURL = srv.URL
+	})
+
+	ctx := context.Background()
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 91, alert.SeverityWarning, alert.StateFiring)))
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 101, alert.SeverityCritical, alert.StateFiring)))
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 80, alert.SeverityCritical, alert.StateResolved)))
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core 0", 92, alert.SeverityWarning, alert.StateFiring)))
+
+	threads := make([]string, 0)
+	for _, r := range srv.Requests() {
+		require.Equal(t, "/chat.postMessage", r.Path)
+
+		var msg message
+		r.Decode(t, &msg)
+		require.Equal(t, "#alerts", msg.Channel)
+		threads = append(threads, msg.ThreadTS)
+	}
+
+	// Updates of a firing alert reply in its thread, and a new breach after it resolves starts a new thread.
+	require.Equal(t, []string{"", "1748779200.000100", "1748779200.000100", ""}, threads)
+
+	n.cfg.Token = "revoked"
+	err := n.Notify(ctx, notifytest.Notification("Core 1", 91, alert.SeverityWarning, alert.StateFiring))
+	require.EqualError(t, err, "failed to post to slack: invalid_auth")
+}
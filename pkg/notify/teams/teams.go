@@ -0,0 +1,126 @@
+package teams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+)
+
+// message is a Teams message carrying an Adaptive Card.
+type message struct {
+	Type        string       `json:"type"`
+	Attachments []attachment `json:"attachments"`
+}
+
+// attachment is an Adaptive Card attached to a message.
+type attachment struct {
+	ContentType string `json:"contentType"`
+	Content     card   `json:"content"`
+}
+
+// card is an Adaptive Card.
+type card struct {
+	Schema  string    `json:"$schema"`
+	Type    string    `json:"type"`
+	Version string    `json:"version"`
+	Body    []element `json:"body"`
+}
+
+// element is an element of the body of an Adaptive Card.
+type element struct {
+	Type   string `json:"type"`
+	Text   string `json:"text,omitempty"`
+	Size   string `json:"size,omitempty"`
+	Weight string `json:"weight,omitempty"`
+	Color  string `json:"color,omitempty"`
+	Wrap   bool   `json:"wrap,omitempty"`
+	Facts  []fact `json:"facts,omitempty"`
+}
+
+// fact is a fact of a FactSet.
+type fact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// Notifier posts alerts to a Microsoft Teams webhook. Teams webhooks cannot reply to or edit messages, so resolved
+// alerts are posted as their own card.
+type Notifier struct {
+	name   string
+	cfg    *config.Teams
+	host   string
+	client *notify.HTTPClient
+}
+
+// New creates a Teams notifier from its configuration.
+func New(cfg *config.Notifier) (notify.Notifier, error) {
+	if cfg.Teams == nil {
+		return nil, errors.New("missing teams configuration")
+	}
+
+	return &Notifier{
+		name:   cfg.Name,
+		cfg:    cfg.Teams,
+		host:   notify.Hostname(),
+		client: notify.NewHTTPClient(cfg.Teams.HTTPOptions),
+	}, nil
+}
+
+// Name returns the name of the notifier.
+func (n *Notifier) Name() string {
+	return n.name
+}
+
+// Notify posts the notification as an Adaptive Card.
+func (n *Notifier) Notify(ctx context.Context, notification *alert.Notification) error {
+	if _, err := n.client.JSON(ctx, http.MethodPost, n.cfg.WebhookURL, nil, n.message(notification)); err != nil {
+		return fmt.Errorf("failed to post to teams: %w", err)
+	}
+
+	return nil
+}
+
+// color returns the Adaptive Card colour of the notification, matching notify.Color.
+func color(notification *alert.Notification) string {
+	switch {
+	case notification.State == alert.StateResolved:
+		return "Good"
+	case notification.Severity >= alert.SeverityCritical:
+		return "Attention"
+	default:
+		return "Warning"
+	}
+}
+
+// message builds the message for the notification.
+func (n *Notifier) message(notification *alert.Notification) *message {
+	facts := notify.Facts(notification, n.host)
+	factSet := make([]fact, 0, len(facts))
+	for _, f := range facts {
+		factSet = append(factSet, fact{Title: f.Name, Value: f.Value})
+	}
+
+	return &message{
+		Type: "message",
+		Attachments: []attachment{
+			{
+				ContentType: "application/vnd.microsoft.card.adaptive",
+				Content: card{
+					Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+					Type:    "AdaptiveCard",
+					Version: "1.4",
+					Body: []element{
+						{Type: "TextBlock", Text: notify.Title(notification), Size: "Medium", Weight: "Bolder", Color: color(notification)},
+						{Type: "TextBlock", Text: notification.Message(), Wrap: true},
+						{Type: "FactSet", Facts: factSet},
+					},
+				},
+			},
+		},
+	}
+}
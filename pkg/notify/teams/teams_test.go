@@ -0,0 +1,63 @@
+package teams
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/notifytest"
+)
+
+func TestNotifier_Notify(t *testing.T) {
+	t.Parallel()
+
+	srv := notifytest.NewServer(t, func(*notifytest.Request) (int, string) {
+		return http.StatusAccepted, ""
+	})
+
+	n, err := New(&config.Notifier{Name: "teams", Type: config.NotifierTeams, Teams: &config.Teams{
+		WebhookURL:  srv.URL + "/workflows/alerts",
+		HTTPOptions: config.DefaultHTTPOptions(),
+	}})
+	require.NoError(t, err)
+	n.(*Notifier).host = "render-01"
+
+	require.NoError(t, n.Notify(context.Background(), notifytest.Notification("Core 0", 101, alert.SeverityCritical, alert.StateFiring)))
+	require.NoError(t, n.Notify(context.Background(), notifytest.Notification("Core 0", 80, alert.SeverityCritical, alert.StateResolved)))
+
+	requests := srv.Requests()
+	require.Len(t, requests, 2)
+	require.Equal(t, "/workflows/alerts", requests[0].Path)
+	require.JSONEq(t, `{
+		"type": "message",
+		"attachments": [{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": {
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type": "AdaptiveCard",
+				"version": "1.4",
+				"body": [
+					{"type": "TextBlock", "text": "🔥 Sensor Critical!", "size": "Medium", "weight": "Bolder", "color": "Attention"},
+					{"type": "TextBlock", "text": "coretemp-isa-0000/Core 0 is at 101.0°C: at or above warn threshold 90 (90.0°C)", "wrap": true},
+					{"type": "FactSet", "facts": [
+						{"title": "Sensor", "value": "coretemp-isa-0000/Core 0"},
+						{"title": "Value", "value": "101.0°C"},
+						{"title": "Severity", "value": "critical"},
+						{"title": "State", "value": "firing"},
+						{"title": "Rule", "value": "cores"},
+						{"title": "Host", "value": "render-01"}
+					]}
+				]
+			}
+		}]
+	}`, string(requests[0].Body))
+
+	var resolved message
+	requests[1].Decode(t, &resolved)
+	require.Equal(t, "Good", resolved.Attachments[0].Content.Body[0].Color)
+	require.Equal(t, "coretemp-isa-0000/Core 0 has recovered at 80.0°C after 5m0s", resolved.Attachments[0].Content.Body[1].Text)
+}
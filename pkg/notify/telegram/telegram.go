@@ -0,0 +1,153 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+)
+
+// message is a message sent with the sendMessage method.
+type message struct {
+	ChatID              string           `json:"chat_id"`
+	Text                string           `json:"text"`
+	ParseMode           string           `json:"parse_mode"`
+	DisableNotification bool             `json:"disable_notification,omitempty"`
+	ReplyParameters     *replyParameters `json:"reply_parameters,omitempty"`
+}
+
+// replyParameters makes a message a reply to an earlier message.
+type replyParameters struct {
+	MessageID                int  `json:"message_id"`
+	AllowSendingWithoutReply bool `json:"allow_sending_without_reply"`
+}
+
+// response is the response of the Bot API.
+type response struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+	Result      struct {
+		MessageID int `json:"message_id"`
+	} `json:"result"`
+}
+
+// Notifier sends alerts with a Telegram bot.
+type Notifier struct {
+	name    string
+	cfg     *config.Telegram
+	host    string
+	client  *notify.HTTPClient
+	threads *notify.Threads
+}
+
+// New creates a Telegram notifier from its configuration.
+func New(cfg *config.Notifier) (notify.Notifier, error) {
+	if cfg.Telegram == nil {
+		return nil, errors.New("missing telegram configuration")
+	}
+
+	return &Notifier{
+		name:    cfg.Name,
+		cfg:     cfg.Telegram,
+		host:    notify.Hostname(),
+		client:  notify.NewHTTPClient(cfg.Telegram.HTTPOptions),
+		threads: notify.NewThreads(),
+	}, nil
+}
+
+// Name returns the name of the notifier.
+func (n *Notifier) Name() string {
+	return n.name
+}
+
+// Notify sends the notification. Later notifications of an alert are sent as replies to the message sent when it
+// fired.
+func (n *Notifier) Notify(ctx context.Context, notification *alert.Notification) error {
+	key := notification.Key()
+	msg := &message{
+		ChatID:              n.cfg.ChatID,
+		Text:                n.text(notification),
+		ParseMode:           "MarkdownV2",
+		DisableNotification: slices.Contains(n.cfg.Silent, notify.Level(notification)),
+	}
+
+	if id, ok := n.threads.Get(key); ok {
+		messageID, err := strconv.Atoi(id)
+		if err != nil {
+			return fmt.Errorf("invalid message id %q: %w", id, err)
+		}
+
+		msg.ReplyParameters = &replyParameters{MessageID: messageID, AllowSendingWithoutReply: true}
+	}
+
+	endpoint := strings.TrimSuffix(n.cfg.APIURL, "/") + "/bot" + n.cfg.Token + "/sendMessage"
+	body, err := n.client.JSON(ctx, http.MethodPost, endpoint, nil, msg)
+	if err != nil {
+		return fmt.Errorf("failed to send to telegram: %w", &redactedError{err: err, token: n.cfg.Token})
+	}
+
+	resp := new(response)
+	if err := json.Unmarshal(body, resp); err != nil {
+		return fmt.Errorf("failed to decode telegram response: %w", err)
+	}
+
+	if !resp.OK {
+		return fmt.Errorf("failed to send to telegram: %s", resp.Description)
+	}
+
+	switch {
+	case notification.State == alert.StateResolved:
+		n.threads.Delete(key)
+	case msg.ReplyParameters == nil:
+		n.threads.Set(key, strconv.Itoa(resp.Result.MessageID))
+	}
+
+	return nil
+}
+
+// redactedError hides the bot token in the message of an error, as the token is part of the URL of every method and
+// appears in the errors of failed requests.
+type redactedError struct {
+	err   error
+	token string
+}
+
+// Error returns the message of the error with the token replaced.
+func (e *redactedError) Error() string {
+	return strings.ReplaceAll(e.err.Error(), e.token, "<token>")
+}
+
+// Unwrap returns the error.
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// text builds the MarkdownV2 text of the notification.
+func (n *Notifier) text(notification *alert.Notification) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*\n%s\n", escape(notify.Title(notification)), escape(notification.Message()))
+	for _, f := range notify.Facts(notification, n.host) {
+		fmt.Fprintf(&b, "\n*%s:* %s", escape(f.Name), escape(f.Value))
+	}
+
+	return b.String()
+}
+
+// escaper escapes the characters reserved by MarkdownV2.
+var escaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`", ">", `\>`,
+	"#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// escape escapes text for use in MarkdownV2.
+func escape(s string) string {
+	return escaper.Replace(s)
+}
@@ -0,0 +1,78 @@
+package telegram
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/notifytest"
+)
+
+func newNotifier(t *testing.T, apiURL string) *Notifier {
+	t.Helper()
+
+	cfg := config.DefaultTelegram()
+	cfg.Token = "123:secret"
+	cfg.ChatID = "-1001234"
+	cfg.APIURL = apiURL
+	cfg.Retries = 0
+
+	n, err := New(&config.Notifier{Name: "telegram", Type: config.NotifierTelegram, Telegram: cfg})
+	require.NoError(t, err)
+
+	notifier := n.(*Notifier)
+	notifier.host = "render-01"
+	return notifier
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	t.Parallel()
+
+	srv := notifytest.NewServer(t, func(*notifytest.Request) (int, string) {
+		return http.StatusOK, `{"ok": true, "result": {"message_id": 77}}`
+	})
+	n := newNotifier(t, srv.URL)
+
+	ctx := context.Background()
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core (0)", 91, alert.SeverityWarning, alert.StateFiring)))
+	require.NoError(t, n.Notify(ctx, notifytest.Notification("Core (0)", 80, alert.SeverityWarning, alert.StateResolved)))
+
+	requests := srv.Requests()
+	require.Len(t, requests, 2)
+	require.Equal(t, "/bot123:secret/sendMessage", requests[0].Path)
+	require.JSONEq(t, `{
+		"chat_id": "-1001234",
+		"text": "*⚠ Sensor Alert*\ncoretemp\\-isa\\-0000/Core \\(0\\) is at 91\\.0°C: at or above warn threshold 90 \\(90\\.0°C\\)\n\n*Sensor:* coretemp\\-isa\\-0000/Core \\(0\\)\n*Value:* 91\\.0°C\n*Severity:* warning\n*State:* firing\n*Rule:* cores\n*Host:* render\\-01",
+		"parse_mode": "MarkdownV2"
+	}`, string(requests[0].Body))
+
+	// The recovery replies to the alert without a sound.
+	var resolved message
+	requests[1].Decode(t, &resolved)
+	require.True(t, resolved.DisableNotification)
+	require.Equal(t, &replyParameters{MessageID: 77, AllowSendingWithoutReply: true}, resolved.ReplyParameters)
+}
+
+func TestNotifier_Notify_Errors(t *testing.T) {
+	t.Parallel()
+
+	srv := notifytest.NewServer(t, func(*notifytest.Request) (int, string) {
+		return http.StatusOK, `{"ok": false, "error_code": 400, "description": "Bad Request: chat not found"}`
+	})
+
+	notification := notifytest.Notification("Core 0", 91, alert.SeverityWarning, alert.StateFiring)
+
+	err := newNotifier(t, srv.URL).Notify(context.Background(), notification)
+	require.EqualError(t, err, "failed to send to telegram: Bad Request: chat not found")
+
+	// The token is part of the URL, so it must not appear in errors that are logged.
+	srv.Close()
+	err = newNotifier(t, srv.URL).Notify(context.Background(), notification)
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "secret")
+	require.Contains(t, err.Error(), "/bot<token>/sendMessage")
+}
@@ -0,0 +1,225 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+)
+
+// SignatureHeader holds the HMAC-SHA256 signature of the request body, e.g. "sha256=4f8a...".
+const SignatureHeader = "X-Signature-256"
+
+// Payload describes an alert transition. It is the default JSON body of the request, and the data given to a custom
+// body template.
+type Payload struct {
+	// ID identifies the sensor, e.g. "coretemp-isa-0000/Core 0".
+	ID string `json:"id"`
+
+	// Key identifies the alert of the rule on the sensor.
+	Key string `json:"key"`
+
+	// Chip is the name of the chip the sensor belongs to.
+	Chip string `json:"chip"`
+
+	// Adapter is the bus the chip is attached to.
+	Adapter string `json:"adapter"`
+
+	// Feature is the name of the sensor on the chip.
+	Feature string `json:"feature"`
+
+	// Kind is the type of measurement, e.g. "temp" or "fan".
+	Kind string `json:"kind"`
+
+	// Value is the latest value of the sensor.
+	Value float64 `json:"value"`
+
+	// Unit is the unit of the value, e.g. "°C".
+	Unit string `json:"unit"`
+
+	// Threshold is the value that was crossed. It is zero for alerts raised by a hardware alarm flag.
+	Threshold float64 `json:"threshold"`
+
+	// Thresholds holds the value of every threshold of the rule, keyed by the rule field, e.g. "warn".
+	Thresholds map[string]float64 `json:"thresholds"`
+
+	// Rule is the name of the rule.
+	Rule string `json:"rule"`
+
+	// Severity is how serious the alert is, e.g. "critical".
+	Severity string `json:"severity"`
+
+	// State is the state the alert moved to, e.g. "firing" or "resolved".
+	State string `json:"state"`
+
+	// PreviousState is the state the alert moved from.
+	PreviousState string `json:"previous_state"`
+
+	// Reason describes why the alert was raised.
+	Reason string `json:"reason"`
+
+	// Message is the human readable description of the alert.
+	Message string `json:"message"`
+
+	// Reminder is true when the notification repeats an alert that is still firing.
+	Reminder bool `json:"reminder"`
+
+	// Suppressed is how many notifications were suppressed since the last one sent.
+	Suppressed int `json:"suppressed"`
+
+	// Host is the hostname of the machine the sensor is on.
+	Host string `json:"host"`
+
+	// Timestamp is when the transition happened.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NewPayload describes the notification.
+func NewPayload(n *alert.Notification, host string) *Payload {
+	return &Payload{
+		ID:            n.Reading.ID(),
+		Key:           n.Key(),
+		Chip:          n.Reading.Chip,
+		Adapter:       n.Reading.Adapter,
+		Feature:       n.Reading.Name,
+		Kind:          string(n.Reading.Kind),
+		Value:         n.Value,
+		Unit:          n.Reading.Kind.Unit(),
+		Threshold:     n.Threshold,
+		Thresholds:    n.Thresholds(),
+		Rule:          n.Rule.Name,
+		Severity:      n.Severity.String(),
+		State:         n.State.String(),
+		PreviousState: n.From.String(),
+		Reason:        n.Reason,
+		Message:       n.Message(),
+		Reminder:      n.Reminder,
+		Suppressed:    n.Suppressed,
+		Host:          host,
+		Timestamp:     n.Time,
+	}
+}
+
+// templateFuncs are the functions available to body templates.
+var templateFuncs = template.FuncMap{
+	// json encodes a value as JSON, e.g. {{ json .Message }} for a quoted and escaped string.
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// Notifier sends an HTTP request for every alert transition.
+type Notifier struct {
+	name     string
+	cfg      *config.Webhook
+	host     string
+	template *template.Template
+	client   *notify.HTTPClient
+}
+
+// New creates a webhook notifier from its configuration.
+func New(cfg *config.Notifier) (notify.Notifier, error) {
+	if cfg.Webhook == nil {
+		return nil, fmt.Errorf("missing webhook configuration")
+	}
+
+	n := &Notifier{
+		name:   cfg.Name,
+		cfg:    cfg.Webhook,
+		host:   notify.Hostname(),
+		client: notify.NewHTTPClient(cfg.Webhook.HTTPOptions),
+	}
+
+	if cfg.Webhook.Template != "" {
+		var err error
+		n.template, err = template.New(cfg.Name).Funcs(templateFuncs).Option("missingkey=error").Parse(cfg.Webhook.Template)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template: %w", err)
+		}
+	}
+
+	return n, nil
+}
+
+// Name returns the name of the notifier.
+func (n *Notifier) Name() string {
+	return n.name
+}
+
+// Notify sends the request for the notification.
+func (n *Notifier) Notify(ctx context.Context, notification *alert.Notification) error {
+	body, err := n.body(NewPayload(notification, n.host))
+	if err != nil {
+		return err
+	}
+
+	if _, err := n.client.Do(ctx, func(ctx context.Context) (*http.Request, error) {
+		return n.newRequest(ctx, body)
+	}); err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+
+	return nil
+}
+
+// body renders the request body for the payload.
+func (n *Notifier) body(payload *Payload) ([]byte, error) {
+	if n.template == nil {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode payload: %w", err)
+		}
+		return body, nil
+	}
+
+	var buf bytes.Buffer
+	if err := n.template.Execute(&buf, payload); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// newRequest creates the request carrying the body, signing it if a secret is configured.
+func (n *Notifier) newRequest(ctx context.Context, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, n.cfg.Method, n.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "sensor-monitor")
+	for k, v := range n.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	if n.cfg.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(n.cfg.Secret, body))
+	}
+
+	return req, nil
+}
+
+// Sign returns the signature of the body with the secret, in the form "sha256=<hex digest>".
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
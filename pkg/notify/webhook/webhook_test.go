@@ -0,0 +1,225 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// testNotification returns a critical alert that has just fired.
+func testNotification() *alert.Notification {
+	firedAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	return &alert.Notification{
+		Event: &alert.Event{
+			Alert: alert.Alert{
+				Reading: &sensors.Reading{
+					Chip:    "coretemp-isa-0000",
+					Adapter: "ISA adapter",
+					Feature: &sensors.Feature{
+						Name: "Core 0",
+						Kind: sensors.KindTemperature,
+						Values: map[sensors.Subfeature]float64{
+							sensors.SubfeatureInput: 101,
+							sensors.SubfeatureMax:   90,
+							sensors.SubfeatureCrit:  100,
+						},
+					},
+				},
+				Rule: &config.Rule{
+					Name:     "cores",
+					Warn:     &config.Threshold{Limit: sensors.SubfeatureMax, Offset: -5},
+					Critical: &config.Threshold{Limit: sensors.SubfeatureCrit},
+				},
+				State:     alert.StateFiring,
+				Severity:  alert.SeverityCritical,
+				Value:     101,
+				Threshold: 100,
+				Reason:    "at or above critical threshold crit (100.0°C)",
+				FiredAt:   firedAt,
+			},
+			From: alert.StatePending,
+			Time: firedAt,
+		},
+		Suppressed: 2,
+	}
+}
+
+// newNotifier creates a webhook notifier sending to the URL with quick retries.
+func newNotifier(t *testing.T, url string, modify func(w *config.Webhook)) *Notifier {
+	t.Helper()
+
+	cfg := config.DefaultWebhook()
+	cfg.URL = url
+	cfg.Backoff = config.Duration(time.Millisecond)
+	if modify != nil {
+		modify(cfg)
+	}
+
+	n, err := New(&config.Notifier{Name: "hook", Type: config.NotifierWebhook, Webhook: cfg})
+	require.NoError(t, err)
+	return n.(*Notifier)
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.Empty(t, r.Header.Get(SignatureHeader))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	n := newNotifier(t, srv.URL, nil)
+	require.Equal(t, "hook", n.Name())
+	require.NoError(t, n.Notify(context.Background(), testNotification()))
+
+	host, err := os.Hostname()
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]any{
+		"id":             "coretemp-isa-0000/Core 0",
+		"key":            "cores:coretemp-isa-0000/Core 0",
+		"chip":           "coretemp-isa-0000",
+		"adapter":        "ISA adapter",
+		"feature":        "Core 0",
+		"kind":           "temp",
+		"value":          101.0,
+		"unit":           "°C",
+		"threshold":      100.0,
+		"thresholds":     map[string]any{"warn": 85.0, "critical": 100.0},
+		"rule":           "cores",
+		"severity":       "critical",
+		"state":          "firing",
+		"previous_state": "pending",
+		"reason":         "at or above critical threshold crit (100.0°C)",
+		"message":        "coretemp-isa-0000/Core 0 is at 101.0°C: at or above critical threshold crit (100.0°C) (2 similar alerts suppressed)",
+		"reminder":       false,
+		"suppressed":     2.0,
+		"host":           host,
+		"timestamp":      "2025-06-01T12:00:00Z",
+	}, got)
+}
+
+func TestNotifier_Notify_Template(t *testing.T) {
+	t.Parallel()
+
+	const secret = "s3cret"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		require.Equal(t, http.MethodPut, r.Method)
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		require.Equal(t, "text/plain", r.Header.Get("Content-Type"))
+		require.Equal(t, Sign(secret, body), r.Header.Get(SignatureHeader))
+		require.Equal(t, `{"text": "CRITICAL coretemp-isa-0000/Core 0 101 °C", "summary": "coretemp-isa-0000/Core 0 is at 101.0°C: at or above critical threshold crit (100.0°C) (2 similar alerts suppressed)"}`, string(body))
+	}))
+	defer srv.Close()
+
+	n := newNotifier(t, srv.URL, func(w *config.Webhook) {
+		w.Method = http.MethodPut
+		w.Headers = map[string]string{"Authorization": "Bearer token", "Content-Type": "text/plain"}
+		w.Secret = secret
+		w.Template = `{"text": "{{ upper .Severity }} {{ .ID }} {{ .Value }} {{ .Unit }}", "summary": {{ json .Message }}}`
+	})
+	require.NoError(t, n.Notify(context.Background(), testNotification()))
+}
+
+func TestNotifier_Notify_Retry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantErr      string
+		wantAttempts int32
+	}{
+		{
+			name:         "succeeds after server errors",
+			statuses:     []int{http.StatusBadGateway, http.StatusTooManyRequests, http.StatusOK},
+			wantAttempts: 3,
+		},
+		{
+			name:         "gives up after the retries",
+			statuses:     []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError},
+			wantErr:      "failed to send webhook: giving up after 4 attempts: unexpected status 500: oops",
+			wantAttempts: 4,
+		},
+		{
+			name:         "client errors are not retried",
+			statuses:     []int{http.StatusBadRequest},
+			wantErr:      "failed to send webhook: unexpected status 400: oops",
+			wantAttempts: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				status := test.statuses[attempts.Add(1)-1]
+				w.WriteHeader(status)
+				if status >= 300 {
+					_, _ = w.Write([]byte("oops"))
+				}
+			}))
+			defer srv.Close()
+
+			err := newNotifier(t, srv.URL, nil).Notify(context.Background(), testNotification())
+			if test.wantErr != "" {
+				require.EqualError(t, err, test.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.wantAttempts, attempts.Load())
+		})
+	}
+}
+
+func TestNotifier_Notify_Timeout(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	n := newNotifier(t, srv.URL, func(w *config.Webhook) {
+		w.Timeout = config.Duration(10 * time.Millisecond)
+		w.Retries = 0
+	})
+
+	err := n.Notify(context.Background(), testNotification())
+	require.ErrorContains(t, err, "Client.Timeout exceeded")
+}
+
+func TestNew_InvalidTemplate(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.DefaultWebhook()
+	cfg.URL = "http://localhost"
+	cfg.Template = "{{ .ID"
+
+	_, err := New(&config.Notifier{Name: "hook", Type: config.NotifierWebhook, Webhook: cfg})
+	require.ErrorContains(t, err, "failed to parse template")
+}
@@ -0,0 +1,135 @@
+package graphite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/output"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// maxDatagram is the most bytes of lines sent in one UDP datagram, so that datagrams are not fragmented on a typical
+// network.
+const maxDatagram = 1400
+
+// Writer writes readings to Graphite in the plaintext protocol. Every value of a reading is a metric with the path
+// <prefix>.<host>.<chip>.<feature>.<value>, e.g. "sensors.render-01.coretemp-isa-0000.Core_0.input".
+type Writer struct {
+	name   string
+	cfg    *config.Graphite
+	host   string
+	dialer *net.Dialer
+}
+
+// New creates a Graphite writer from its configuration.
+func New(cfg *config.Output) (output.Writer, error) {
+	if cfg.Graphite == nil {
+		return nil, errors.New("missing graphite configuration")
+	}
+
+	return &Writer{
+		name:   cfg.Name,
+		cfg:    cfg.Graphite,
+		host:   notify.Hostname(),
+		dialer: &net.Dialer{Timeout: cfg.Graphite.Timeout.Std()},
+	}, nil
+}
+
+// Name returns the name of the writer.
+func (w *Writer) Name() string {
+	return w.name
+}
+
+// Encode encodes every value of every reading of the snapshot as a metric, timestamped in seconds.
+func (w *Writer) Encode(snapshot *sensors.Snapshot) []string {
+	timestamp := strconv.FormatInt(snapshot.Time.Unix(), 10)
+	lines := make([]string, 0, len(snapshot.Readings))
+	for _, r := range snapshot.Readings {
+		path := strings.Join([]string{sanitize(w.host), sanitize(r.Chip), sanitize(r.Name)}, ".")
+		if w.cfg.Prefix != "" {
+			path = w.cfg.Prefix + "." + path
+		}
+
+		for _, sf := range slices.Sorted(maps.Keys(r.Values)) {
+			v := r.Values[sf]
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			lines = append(lines, path+"."+sanitize(string(sf))+" "+strconv.FormatFloat(v, 'f', -1, 64)+" "+timestamp)
+		}
+	}
+
+	return lines
+}
+
+// Write sends the lines over a new connection. Over UDP the lines are split into datagrams of at most maxDatagram
+// bytes.
+func (w *Writer) Write(ctx context.Context, lines []string) error {
+	ctx, cancel := context.WithTimeout(ctx, w.cfg.Timeout.Std())
+	defer cancel()
+
+	conn, err := w.dialer.DialContext(ctx, string(w.cfg.Protocol), w.cfg.Address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to graphite: %w", err)
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		return fmt.Errorf("failed to set deadline: %w", err)
+	}
+
+	for _, packet := range w.packets(lines) {
+		if _, err := conn.Write([]byte(packet)); err != nil {
+			return fmt.Errorf("failed to send lines: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// packets groups the lines into what is written to the connection at once: everything for TCP, or datagrams of at
+// most maxDatagram bytes for UDP. A line longer than a datagram is sent on its own.
+func (w *Writer) packets(lines []string) []string {
+	if w.cfg.Protocol != config.GraphiteUDP {
+		return []string{strings.Join(lines, "\n") + "\n"}
+	}
+
+	packets := make([]string, 0)
+	var b strings.Builder
+	for _, line := range lines {
+		if b.Len() > 0 && b.Len()+len(line)+1 > maxDatagram {
+			packets = append(packets, b.String())
+			b.Reset()
+		}
+		b.WriteString(line + "\n")
+	}
+
+	if b.Len() > 0 {
+		packets = append(packets, b.String())
+	}
+
+	return packets
+}
+
+// sanitize replaces the characters of a path component that Graphite treats specially, or that are awkward in
+// queries, with underscores.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
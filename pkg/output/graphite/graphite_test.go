@@ -0,0 +1,135 @@
+package graphite
+
+import (
+	"context"
+	"io"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// newWriter creates a writer sending to the address over the protocol from the host render-01.
+func newWriter(t *testing.T, protocol config.GraphiteProtocol, address string) *Writer {
+	t.Helper()
+
+	cfg := config.DefaultGraphite()
+	cfg.Protocol = protocol
+	cfg.Address = address
+
+	w, err := New(&config.Output{Name: "graphite", Type: config.OutputGraphite, Graphite: cfg})
+	require.NoError(t, err)
+	w.(*Writer).host = "render-01.lab"
+	return w.(*Writer)
+}
+
+func TestWriter_Encode(t *testing.T) {
+	t.Parallel()
+
+	lines := newWriter(t, config.GraphiteTCP, "graphite:2003").Encode(&sensors.Snapshot{
+		Time: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		Readings: []*sensors.Reading{
+			{
+				Chip: "coretemp-isa-0000",
+				Feature: &sensors.Feature{
+					Name: "Core 0",
+					Kind: sensors.KindTemperature,
+					Values: map[sensors.Subfeature]float64{
+						sensors.SubfeatureInput: 91.5,
+						sensors.SubfeatureCrit:  100,
+						sensors.SubfeatureMax:   math.Inf(1),
+					},
+				},
+			},
+			{
+				Chip: "dell_smm-virtual-0",
+				Feature: &sensors.Feature{
+					Name:   "fan1 (left.front)",
+					Kind:   sensors.KindFan,
+					Values: map[sensors.Subfeature]float64{sensors.SubfeatureInput: 2400},
+				},
+			},
+		},
+	})
+
+	require.Equal(t, []string{
+		"sensors.render-01_lab.coretemp-isa-0000.Core_0.crit 100 1748779200",
+		"sensors.render-01_lab.coretemp-isa-0000.Core_0.input 91.5 1748779200",
+		"sensors.render-01_lab.dell_smm-virtual-0.fan1__left_front_.input 2400 1748779200",
+	}, lines)
+}
+
+func TestWriter_Write_TCP(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			received <- err.Error()
+			return
+		}
+		defer conn.Close()
+
+		data, _ := io.ReadAll(conn)
+		received <- string(data)
+	}()
+
+	w := newWriter(t, config.GraphiteTCP, listener.Addr().String())
+	require.NoError(t, w.Write(context.Background(), []string{"sensors.a 1 1", "sensors.b 2 1"}))
+	require.Equal(t, "sensors.a 1 1\nsensors.b 2 1\n", <-received)
+}
+
+func TestWriter_Write_UDP(t *testing.T) {
+	t.Parallel()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	// 66 bytes a line, so 21 lines fit in each datagram.
+	lines := make([]string, 0, 100)
+	for range 100 {
+		lines = append(lines, "sensors.render-01.coretemp-isa-0000.Core_0.input 91.5 1748779200")
+	}
+
+	w := newWriter(t, config.GraphiteUDP, conn.LocalAddr().String())
+	require.NoError(t, w.Write(context.Background(), lines))
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	want := strings.Join(lines, "\n") + "\n"
+	var got strings.Builder
+	datagrams := 0
+	buf := make([]byte, 65536)
+	for got.Len() < len(want) {
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		require.LessOrEqual(t, n, maxDatagram)
+		got.Write(buf[:n])
+		datagrams++
+	}
+	require.Equal(t, want, got.String())
+	require.Equal(t, 5, datagrams)
+}
+
+func TestWriter_Write_Unreachable(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	err = newWriter(t, config.GraphiteTCP, address).Write(context.Background(), []string{"sensors.a 1 1"})
+	require.ErrorContains(t, err, "failed to connect to graphite")
+}
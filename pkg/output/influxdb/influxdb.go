@@ -0,0 +1,160 @@
+package influxdb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/output"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// Writer writes readings to InfluxDB in the line protocol. Every reading is one point of the measurement, tagged with
+// the host, chip, adapter, feature and kind of the sensor, with a field for each of its values such as input, max and
+// crit.
+type Writer struct {
+	name   string
+	cfg    *config.InfluxDB
+	host   string
+	client *notify.HTTPClient
+}
+
+// New creates an InfluxDB writer from its configuration.
+func New(cfg *config.Output) (output.Writer, error) {
+	if cfg.InfluxDB == nil {
+		return nil, errors.New("missing influxdb configuration")
+	}
+
+	return &Writer{
+		name:   cfg.Name,
+		cfg:    cfg.InfluxDB,
+		host:   notify.Hostname(),
+		client: notify.NewHTTPClient(cfg.InfluxDB.HTTPOptions),
+	}, nil
+}
+
+// Name returns the name of the writer.
+func (w *Writer) Name() string {
+	return w.name
+}
+
+// Encode encodes every reading of the snapshot as a point, timestamped in nanoseconds.
+func (w *Writer) Encode(snapshot *sensors.Snapshot) []string {
+	timestamp := strconv.FormatInt(snapshot.Time.UnixNano(), 10)
+	lines := make([]string, 0, len(snapshot.Readings))
+	for _, r := range snapshot.Readings {
+		fields := make([]string, 0, len(r.Values))
+		for _, sf := range slices.Sorted(maps.Keys(r.Values)) {
+			v := r.Values[sf]
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				// The line protocol has no representation of these.
+				continue
+			}
+			fields = append(fields, keyEscaper.Replace(string(sf))+"="+strconv.FormatFloat(v, 'f', -1, 64))
+		}
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		var b strings.Builder
+		b.WriteString(measurementEscaper.Replace(w.cfg.Measurement))
+		// Tags are sorted by key, as InfluxDB recommends.
+		for _, tag := range [][2]string{
+			{"adapter", r.Adapter},
+			{"chip", r.Chip},
+			{"feature", r.Name},
+			{"host", w.host},
+			{"kind", r.Kind.String()},
+		} {
+			if tag[1] == "" {
+				// Tags may not have empty values.
+				continue
+			}
+			b.WriteString("," + tag[0] + "=" + keyEscaper.Replace(tag[1]))
+		}
+		b.WriteString(" " + strings.Join(fields, ",") + " " + timestamp)
+
+		lines = append(lines, b.String())
+	}
+
+	return lines
+}
+
+// Write sends the lines to the write API, compressed with gzip if configured.
+func (w *Writer) Write(ctx context.Context, lines []string) error {
+	body := []byte(strings.Join(lines, "\n") + "\n")
+	if w.cfg.Gzip {
+		var b bytes.Buffer
+		zw := gzip.NewWriter(&b)
+		if _, err := zw.Write(body); err != nil {
+			return fmt.Errorf("failed to compress lines: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("failed to compress lines: %w", err)
+		}
+		body = b.Bytes()
+	}
+
+	endpoint := w.endpoint()
+	_, err := w.client.Do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+		req.Header.Set("User-Agent", "sensor-monitor")
+		if w.cfg.Gzip {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+
+		switch {
+		case w.cfg.Version == 2:
+			req.Header.Set("Authorization", "Token "+w.cfg.Token)
+		case w.cfg.Username != "":
+			req.SetBasicAuth(w.cfg.Username, w.cfg.Password)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to post lines: %w", err)
+	}
+
+	return nil
+}
+
+// endpoint returns the URL of the write API of the configured version.
+func (w *Writer) endpoint() string {
+	query := url.Values{"precision": {"ns"}}
+	path := "/write"
+	if w.cfg.Version == 2 {
+		path = "/api/v2/write"
+		query.Set("org", w.cfg.Org)
+		query.Set("bucket", w.cfg.Bucket)
+	} else {
+		query.Set("db", w.cfg.Database)
+		if w.cfg.RetentionPolicy != "" {
+			query.Set("rp", w.cfg.RetentionPolicy)
+		}
+	}
+
+	return strings.TrimSuffix(w.cfg.URL, "/") + path + "?" + query.Encode()
+}
+
+// measurementEscaper escapes a measurement name.
+var measurementEscaper = strings.NewReplacer(`,`, `\,`, ` `, `\ `)
+
+// keyEscaper escapes tag keys, tag values and field keys.
+var keyEscaper = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `)
@@ -0,0 +1,242 @@
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/eclipse/paho.golang/packets"
+	paho5 "github.com/eclipse/paho.golang/paho"
+	paho3 "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+)
+
+// message is an application message published to a topic.
+type message struct {
+	topic   string
+	payload []byte
+	qos     byte
+	retain  bool
+}
+
+// connectOptions are the options of the connection to the broker.
+type connectOptions struct {
+	version   config.MQTTVersion
+	clientID  string
+	username  string
+	password  string
+	keepAlive time.Duration
+	timeout   time.Duration
+
+	// will is published by the broker if the connection is lost without the client disconnecting.
+	will *message
+}
+
+// client is a connection to an MQTT broker that publishes messages. MQTT 3.1.1 is spoken by the Eclipse Paho client,
+// and MQTT 5 by the Eclipse Paho MQTT 5 client, over a connection already established with the broker.
+type client interface {
+	// publish publishes the messages, waiting until the broker has acknowledged those with a QoS above 0.
+	publish(ctx context.Context, messages []*message) error
+
+	// closed reports whether the connection has failed or been closed.
+	closed() bool
+
+	// close closes the connection without disconnecting, so the broker publishes the will.
+	close()
+
+	// disconnect disconnects from the broker and closes the connection, so the broker discards the will.
+	disconnect()
+}
+
+// connect connects to the broker over the connection, speaking the MQTT version of the options.
+func connect(ctx context.Context, conn net.Conn, opts *connectOptions) (client, error) {
+	if opts.version == config.MQTTVersion5 {
+		return connect5(ctx, conn, opts)
+	}
+
+	return connect311(ctx, conn, opts)
+}
+
+// client311 is a connection to an MQTT 3.1.1 broker.
+type client311 struct {
+	conn   net.Conn
+	client paho3.Client
+
+	// dropped is set once the connection has been closed, before the client notices.
+	dropped atomic.Bool
+}
+
+// connect311 connects to an MQTT 3.1.1 broker over the connection.
+func connect311(ctx context.Context, conn net.Conn, opts *connectOptions) (*client311, error) {
+	o := paho3.NewClientOptions().
+		AddBroker((&url.URL{Scheme: "tcp", Host: conn.RemoteAddr().String()}).String()).
+		SetCustomOpenConnectionFn(func(*url.URL, paho3.ClientOptions) (net.Conn, error) {
+			return conn, nil
+		}).
+		SetProtocolVersion(4).
+		SetClientID(opts.clientID).
+		SetUsername(opts.username).
+		SetPassword(opts.password).
+		SetCleanSession(true).
+		SetKeepAlive(opts.keepAlive).
+		SetConnectTimeout(opts.timeout).
+		SetWriteTimeout(opts.timeout).
+		SetAutoReconnect(false).
+		SetConnectRetry(false)
+	if opts.will != nil {
+		o.SetBinaryWill(opts.will.topic, opts.will.payload, opts.will.qos, opts.will.retain)
+	}
+
+	c := &client311{conn: conn, client: paho3.NewClient(o)}
+	if err := wait(ctx, c.client.Connect()); err != nil {
+		c.close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// publish publishes the messages, waiting for their acknowledgements once every message has been sent.
+func (c *client311) publish(ctx context.Context, messages []*message) error {
+	tokens := make([]paho3.Token, 0, len(messages))
+	for _, m := range messages {
+		tokens = append(tokens, c.client.Publish(m.topic, m.qos, m.retain, m.payload))
+	}
+
+	for _, token := range tokens {
+		if err := wait(ctx, token); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// closed reports whether the connection has failed or been closed.
+func (c *client311) closed() bool {
+	return c.dropped.Load() || !c.client.IsConnectionOpen()
+}
+
+// close closes the connection without disconnecting, so the broker publishes the will.
+func (c *client311) close() {
+	c.dropped.Store(true)
+	_ = c.conn.Close()
+}
+
+// disconnect disconnects from the broker and closes the connection, so the broker discards the will.
+func (c *client311) disconnect() {
+	c.dropped.Store(true)
+	c.client.Disconnect(0)
+	_ = c.conn.Close()
+}
+
+// wait waits for the token to complete, returning its error.
+func wait(ctx context.Context, token paho3.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// client5 is a connection to an MQTT 5 broker.
+type client5 struct {
+	conn   net.Conn
+	client *paho5.Client
+
+	// dropped is set once the connection has been closed, before the client notices.
+	dropped atomic.Bool
+}
+
+// connect5 connects to an MQTT 5 broker over the connection.
+func connect5(ctx context.Context, conn net.Conn, opts *connectOptions) (*client5, error) {
+	c := &client5{
+		conn: conn,
+		client: paho5.NewClient(paho5.ClientConfig{
+			Conn:          packets.NewThreadSafeConn(conn),
+			PacketTimeout: opts.timeout,
+		}),
+	}
+
+	cp := &paho5.Connect{
+		ClientID:     opts.clientID,
+		KeepAlive:    uint16(opts.keepAlive / time.Second), //nolint:gosec // Validated.
+		CleanStart:   true,
+		Username:     opts.username,
+		UsernameFlag: opts.username != "",
+		Password:     []byte(opts.password),
+		PasswordFlag: opts.password != "",
+	}
+	if opts.will != nil {
+		cp.WillMessage = &paho5.WillMessage{
+			Topic:   opts.will.topic,
+			Payload: opts.will.payload,
+			QoS:     opts.will.qos,
+			Retain:  opts.will.retain,
+		}
+	}
+
+	ca, err := c.client.Connect(ctx, cp)
+	if err != nil {
+		c.close()
+		if ca != nil {
+			return nil, fmt.Errorf("connection refused: reason code 0x%02x", ca.ReasonCode)
+		}
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// publish publishes the messages in turn, waiting for the acknowledgement of each.
+func (c *client5) publish(ctx context.Context, messages []*message) error {
+	for _, m := range messages {
+		resp, err := c.client.Publish(ctx, &paho5.Publish{
+			Topic:   m.topic,
+			Payload: m.payload,
+			QoS:     m.qos,
+			Retain:  m.retain,
+		})
+		if err != nil {
+			return err
+		}
+
+		// A PUBREC with a reason code of a failure ends the delivery without an error.
+		if resp != nil && resp.ReasonCode >= 0x80 {
+			return fmt.Errorf("rejected with reason code 0x%02x", resp.ReasonCode)
+		}
+	}
+
+	return nil
+}
+
+// closed reports whether the connection has failed or been closed.
+func (c *client5) closed() bool {
+	if c.dropped.Load() {
+		return true
+	}
+
+	select {
+	case <-c.client.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// close closes the connection without disconnecting, so the broker publishes the will.
+func (c *client5) close() {
+	c.dropped.Store(true)
+	_ = c.conn.Close()
+}
+
+// disconnect disconnects from the broker and closes the connection, so the broker discards the will.
+func (c *client5) disconnect() {
+	c.dropped.Store(true)
+	_ = c.client.Disconnect(&paho5.Disconnect{})
+}
@@ -0,0 +1,381 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/output"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+const (
+	// online and offline are the payloads of the availability topic.
+	online  = "online"
+	offline = "offline"
+
+	// separator separates the topic of a line from its payload. Topics may not contain it.
+	separator = "\x00"
+)
+
+// entity describes how Home Assistant shows the sensors of a kind.
+type entity struct {
+	deviceClass string
+	unit        string
+	stateClass  string
+	icon        string
+}
+
+// entities are the Home Assistant entities of the kinds of sensors. Sensors of other kinds are still published but
+// are not discovered.
+var entities = map[sensors.Kind]entity{
+	sensors.KindTemperature: {deviceClass: "temperature", unit: "°C", stateClass: "measurement"},
+	sensors.KindFan:         {unit: "rpm", stateClass: "measurement", icon: "mdi:fan"},
+	sensors.KindVoltage:     {deviceClass: "voltage", unit: "V", stateClass: "measurement"},
+	sensors.KindCurrent:     {deviceClass: "current", unit: "A", stateClass: "measurement"},
+	sensors.KindPower:       {deviceClass: "power", unit: "W", stateClass: "measurement"},
+	sensors.KindEnergy:      {deviceClass: "energy", unit: "J", stateClass: "total_increasing"},
+	sensors.KindHumidity:    {deviceClass: "humidity", unit: "%", stateClass: "measurement"},
+	sensors.KindCooling:     {stateClass: "measurement", icon: "mdi:fan"},
+}
+
+// discoveryConfig is the Home Assistant discovery config of a sensor.
+type discoveryConfig struct {
+	Name              string  `json:"name"`
+	UniqueID          string  `json:"unique_id"`
+	StateTopic        string  `json:"state_topic"`
+	ValueTemplate     string  `json:"value_template"`
+	DeviceClass       string  `json:"device_class,omitempty"`
+	UnitOfMeasurement string  `json:"unit_of_measurement,omitempty"`
+	StateClass        string  `json:"state_class,omitempty"`
+	Icon              string  `json:"icon,omitempty"`
+	AvailabilityTopic string  `json:"availability_topic"`
+	Device            *device `json:"device"`
+}
+
+// device is the Home Assistant device of the host, which every sensor is an entity of.
+type device struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+}
+
+// Writer publishes the readings of every sensor to its own topic, <prefix>/<host>/<chip>/<feature>, as a JSON object
+// of its values such as {"input": 45, "max": 80}. The availability of the monitor is published to
+// <prefix>/<host>/status, and set to offline by the broker if the monitor goes away. Home Assistant discovery configs
+// are published for every sensor when they are first seen, and again on every new connection.
+type Writer struct {
+	name   string
+	cfg    *config.MQTT
+	host   string
+	tls    *tls.Config
+	dialer *net.Dialer
+
+	// client is the connection to the broker, or nil before the first write.
+	client client
+
+	// discovery are the discovery configs of every sensor seen, by their topic, and announced those that have been
+	// published on the current connection.
+	discovery map[string][]byte
+	announced map[string]bool
+}
+
+// New creates an MQTT writer from its configuration.
+func New(cfg *config.Output) (output.Writer, error) {
+	if cfg.MQTT == nil {
+		return nil, errors.New("missing mqtt configuration")
+	}
+
+	var tlsConfig *tls.Config
+	if cfg.MQTT.UseTLS() {
+		var err error
+		if tlsConfig, err = newTLSConfig(cfg.MQTT); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Writer{
+		name:      cfg.Name,
+		cfg:       cfg.MQTT,
+		host:      notify.Hostname(),
+		tls:       tlsConfig,
+		dialer:    &net.Dialer{Timeout: cfg.MQTT.Timeout.Std()},
+		discovery: make(map[string][]byte),
+		announced: make(map[string]bool),
+	}, nil
+}
+
+// newTLSConfig creates the TLS configuration of the connection to the broker.
+func newTLSConfig(cfg *config.MQTT) (*tls.Config, error) {
+	u, err := url.Parse(cfg.Broker)
+	if err != nil {
+		return nil, fmt.Errorf("invalid broker URL: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         u.Hostname(),
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.TLS.InsecureSkipVerify, //nolint:gosec // Only when asked to.
+	}
+
+	if cfg.TLS.CAFile != "" {
+		pem, err := os.ReadFile(cfg.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.TLS.CAFile)
+		}
+	}
+
+	if cfg.TLS.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// Name returns the name of the writer.
+func (w *Writer) Name() string {
+	return w.name
+}
+
+// Encode encodes the values of every reading of the snapshot as a message to the topic of its sensor, recording the
+// discovery configs of sensors that have not been seen before.
+func (w *Writer) Encode(snapshot *sensors.Snapshot) []string {
+	lines := make([]string, 0, len(snapshot.Readings))
+	for _, r := range snapshot.Readings {
+		values := make(map[sensors.Subfeature]float64, len(r.Values))
+		for sf, v := range r.Values {
+			if !math.IsNaN(v) && !math.IsInf(v, 0) {
+				values[sf] = v
+			}
+		}
+
+		payload, err := json.Marshal(values)
+		if err != nil || len(values) == 0 {
+			continue
+		}
+
+		topic := w.stateTopic(r)
+		lines = append(lines, topic+separator+string(payload))
+
+		if w.cfg.Discovery {
+			w.discover(r, topic)
+		}
+	}
+
+	return lines
+}
+
+// Write publishes the messages encoded by Encode, connecting to the broker first if needed. A failed connection is
+// closed, to be connected again by the next write.
+func (w *Writer) Write(ctx context.Context, lines []string) error {
+	ctx, cancel := context.WithTimeout(ctx, w.cfg.Timeout.Std())
+	defer cancel()
+
+	if w.client == nil || w.client.closed() {
+		if err := w.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	messages := w.announcements()
+	for _, line := range lines {
+		topic, payload, _ := strings.Cut(line, separator)
+		messages = append(messages, &message{
+			topic:   topic,
+			payload: []byte(payload),
+			qos:     byte(w.cfg.QoS), //nolint:gosec // Validated to be 0, 1 or 2.
+			retain:  w.cfg.Retain,
+		})
+	}
+
+	if err := w.client.publish(ctx, messages); err != nil {
+		w.client.close()
+		return fmt.Errorf("failed to publish: %w", err)
+	}
+
+	for _, m := range messages {
+		if _, ok := w.discovery[m.topic]; ok {
+			w.announced[m.topic] = true
+		}
+	}
+
+	return nil
+}
+
+// Close marks the monitor offline and disconnects from the broker.
+func (w *Writer) Close(ctx context.Context) error {
+	if w.client == nil || w.client.closed() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.cfg.Timeout.Std())
+	defer cancel()
+
+	status := &message{topic: w.availabilityTopic(), payload: []byte(offline), qos: 1, retain: true}
+	err := w.client.publish(ctx, []*message{status})
+	w.client.disconnect()
+	if err != nil {
+		return fmt.Errorf("failed to publish availability: %w", err)
+	}
+
+	return nil
+}
+
+// connect connects to the broker with a will marking the monitor offline, then marks it online.
+func (w *Writer) connect(ctx context.Context) error {
+	u, err := url.Parse(w.cfg.Broker)
+	if err != nil {
+		return fmt.Errorf("invalid broker URL: %w", err)
+	}
+
+	conn, err := w.dialer.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return fmt.Errorf("failed to connect to broker: %w", err)
+	}
+
+	if w.tls != nil {
+		tlsConn := tls.Client(conn, w.tls)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return fmt.Errorf("failed to connect to broker: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	clientID := w.cfg.ClientID
+	if clientID == "" {
+		clientID = "sensor-monitor-" + w.host
+	}
+
+	availability := w.availabilityTopic()
+	c, err := connect(ctx, conn, &connectOptions{
+		version:   w.cfg.Version,
+		clientID:  clientID,
+		username:  w.cfg.Username,
+		password:  w.cfg.Password,
+		keepAlive: w.cfg.KeepAlive.Std(),
+		timeout:   w.cfg.Timeout.Std(),
+		will:      &message{topic: availability, payload: []byte(offline), qos: 1, retain: true},
+	})
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to connect to broker: %w", err)
+	}
+
+	w.client = c
+	clear(w.announced)
+
+	status := &message{topic: availability, payload: []byte(online), qos: 1, retain: true}
+	if err := c.publish(ctx, []*message{status}); err != nil {
+		c.close()
+		return fmt.Errorf("failed to publish availability: %w", err)
+	}
+
+	return nil
+}
+
+// announcements returns the messages of the discovery configs not yet published on the current connection.
+func (w *Writer) announcements() []*message {
+	messages := make([]*message, 0)
+	for _, topic := range slices.Sorted(maps.Keys(w.discovery)) {
+		if !w.announced[topic] {
+			messages = append(messages, &message{topic: topic, payload: w.discovery[topic], qos: 1, retain: true})
+		}
+	}
+
+	return messages
+}
+
+// discover records the discovery config of the sensor of the reading, if it is of a kind Home Assistant can show.
+func (w *Writer) discover(r *sensors.Reading, stateTopic string) {
+	e, ok := entities[r.Kind]
+	if !ok {
+		return
+	}
+
+	node := objectID(w.host)
+	object := objectID(r.Chip + "_" + r.Name)
+	topic := strings.Join([]string{w.cfg.DiscoveryPrefix, "sensor", node, object, "config"}, "/")
+	if _, ok := w.discovery[topic]; ok {
+		return
+	}
+
+	payload, err := json.Marshal(&discoveryConfig{
+		Name:              r.Chip + " " + r.Name,
+		UniqueID:          "sensor-monitor_" + node + "_" + object,
+		StateTopic:        stateTopic,
+		ValueTemplate:     "{{ value_json.input }}",
+		DeviceClass:       e.deviceClass,
+		UnitOfMeasurement: e.unit,
+		StateClass:        e.stateClass,
+		Icon:              e.icon,
+		AvailabilityTopic: w.availabilityTopic(),
+		Device: &device{
+			Identifiers:  []string{"sensor-monitor_" + node},
+			Name:         w.host,
+			Manufacturer: "sensor-monitor",
+			Model:        "Hardware sensors",
+		},
+	})
+	if err != nil {
+		return
+	}
+
+	w.discovery[topic] = payload
+}
+
+// stateTopic returns the topic the readings of a sensor are published to.
+func (w *Writer) stateTopic(r *sensors.Reading) string {
+	return strings.Join([]string{w.cfg.TopicPrefix, topicLevel(w.host), topicLevel(r.Chip), topicLevel(r.Name)}, "/")
+}
+
+// availabilityTopic returns the topic the availability of the monitor is published to.
+func (w *Writer) availabilityTopic() string {
+	return w.cfg.TopicPrefix + "/" + topicLevel(w.host) + "/status"
+}
+
+// topicLevel replaces the characters that may not appear within one level of a topic with underscores.
+func topicLevel(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '+', '#', 0:
+			return '_'
+		default:
+			return r
+		}
+	}, s)
+}
+
+// objectID replaces the characters not allowed in the node and object IDs of discovery topics with underscores.
+func objectID(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
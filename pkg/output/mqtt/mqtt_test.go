@@ -0,0 +1,545 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// testSnapshot is a snapshot of a core temperature sensor, a fan and a sensor of an unknown kind.
+var testSnapshot = &sensors.Snapshot{
+	Time: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+	Readings: []*sensors.Reading{
+		{
+			Chip:    "coretemp-isa-0000",
+			Adapter: "ISA adapter",
+			Feature: &sensors.Feature{
+				Name: "Core 0",
+				Kind: sensors.KindTemperature,
+				Values: map[sensors.Subfeature]float64{
+					sensors.SubfeatureInput: 91.5,
+					sensors.SubfeatureCrit:  100,
+				},
+			},
+		},
+		{
+			Chip: "dell_smm-isa-0000",
+			Feature: &sensors.Feature{
+				Name:   "fan1",
+				Kind:   sensors.KindFan,
+				Values: map[sensors.Subfeature]float64{sensors.SubfeatureInput: 1200},
+			},
+		},
+		{
+			Chip:    "acpitz-acpi-0",
+			Feature: &sensors.Feature{Name: "beep_enable", Values: map[sensors.Subfeature]float64{"input": 1}},
+		},
+	},
+}
+
+// The types of the MQTT control packets the broker sends or receives.
+const (
+	packetConnect  = 1
+	packetConnack  = 2
+	packetPublish  = 3
+	packetPuback   = 4
+	packetPubrec   = 5
+	packetPubrel   = 6
+	packetPubcomp  = 7
+	packetPingreq  = 12
+	packetPingresp = 13
+)
+
+// The flags of a CONNECT packet.
+const (
+	connectWill       = 0x04
+	connectWillRetain = 0x20
+	connectPassword   = 0x40
+	connectUsername   = 0x80
+)
+
+// connectPacket is a CONNECT packet received by the broker.
+type connectPacket struct {
+	level     byte
+	clientID  string
+	keepAlive uint16
+	will      *message
+	username  string
+	password  string
+}
+
+// broker is a minimal MQTT broker that records the connections and messages of the clients connected to it.
+type broker struct {
+	t        *testing.T
+	listener net.Listener
+
+	// refuse is the return code of the CONNACK packets sent to clients.
+	refuse byte
+
+	mu       sync.Mutex
+	conns    []net.Conn
+	connects []*connectPacket
+	messages []*message
+}
+
+// newBroker starts a broker listening on a local port, answering connections with the return code.
+func newBroker(t *testing.T, refuse byte) *broker {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	b := &broker{t: t, listener: listener, refuse: refuse}
+	t.Cleanup(func() {
+		_ = listener.Close()
+		b.drop()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			b.mu.Lock()
+			b.conns = append(b.conns, conn)
+			b.mu.Unlock()
+
+			go b.serve(conn)
+		}
+	}()
+
+	return b
+}
+
+// url returns the URL of the broker.
+func (b *broker) url() string {
+	return "mqtt://" + b.listener.Addr().String()
+}
+
+// drop closes the connection of every client, without the clients disconnecting.
+func (b *broker) drop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, conn := range b.conns {
+		_ = conn.Close()
+	}
+	b.conns = nil
+}
+
+// received returns the CONNECT packets and the messages received so far.
+func (b *broker) received() ([]*connectPacket, []*message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]*connectPacket(nil), b.connects...), append([]*message(nil), b.messages...)
+}
+
+// wait waits for the broker to receive n messages, as those published with QoS 0 are not acknowledged, and returns
+// the CONNECT packets and the messages received.
+func (b *broker) wait(n int) ([]*connectPacket, []*message) {
+	b.t.Helper()
+
+	require.Eventually(b.t, func() bool {
+		_, messages := b.received()
+		return len(messages) >= n
+	}, time.Second, time.Millisecond)
+
+	return b.received()
+}
+
+// serve answers the packets of a client until it goes away.
+func (b *broker) serve(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	header, body, err := readPacket(r)
+	if err != nil || header>>4 != packetConnect {
+		return
+	}
+
+	connect := parseConnect(body)
+	b.mu.Lock()
+	b.connects = append(b.connects, connect)
+	b.mu.Unlock()
+
+	v5 := connect.level == 5
+	connack := []byte{0, b.refuse}
+	if v5 {
+		connack = append(connack, 0)
+	}
+	if _, err := conn.Write(packet(packetConnack<<4, connack)); err != nil || b.refuse != 0 {
+		return
+	}
+
+	for {
+		header, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+
+		var reply []byte
+		switch header >> 4 {
+		case packetPublish:
+			m, id := parsePublish(header, body, v5)
+			b.mu.Lock()
+			b.messages = append(b.messages, m)
+			b.mu.Unlock()
+
+			switch m.qos {
+			case 1:
+				reply = packet(packetPuback<<4, binary.BigEndian.AppendUint16(nil, id))
+			case 2:
+				reply = packet(packetPubrec<<4, binary.BigEndian.AppendUint16(nil, id))
+			}
+		case packetPubrel:
+			reply = packet(packetPubcomp<<4, body[:2])
+		case packetPingreq:
+			reply = packet(packetPingresp<<4, nil)
+		}
+
+		if reply != nil {
+			if _, err := conn.Write(reply); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// parseConnect decodes the body of a CONNECT packet.
+func parseConnect(body []byte) *connectPacket {
+	_, body = readString(body)
+	c := &connectPacket{level: body[0], keepAlive: binary.BigEndian.Uint16(body[2:])}
+	flags := body[1]
+	body = body[4:]
+	if c.level == 5 {
+		body = body[1:] // No properties.
+	}
+
+	c.clientID, body = readString(body)
+	if flags&connectWill != 0 {
+		if c.level == 5 {
+			body = body[1:] // No will properties.
+		}
+
+		c.will = &message{qos: flags >> 3 & 0x03, retain: flags&connectWillRetain != 0}
+		var payload string
+		c.will.topic, body = readString(body)
+		payload, body = readString(body)
+		c.will.payload = []byte(payload)
+	}
+	if flags&connectUsername != 0 {
+		c.username, body = readString(body)
+	}
+	if flags&connectPassword != 0 {
+		c.password, _ = readString(body)
+	}
+
+	return c
+}
+
+// parsePublish decodes a PUBLISH packet, returning its message and packet identifier.
+func parsePublish(header byte, body []byte, v5 bool) (*message, uint16) {
+	m := &message{qos: header >> 1 & 0x03, retain: header&0x01 != 0}
+	m.topic, body = readString(body)
+
+	var id uint16
+	if m.qos > 0 {
+		id = binary.BigEndian.Uint16(body)
+		body = body[2:]
+	}
+	if v5 {
+		body = body[1:] // No properties.
+	}
+
+	m.payload = body
+	return m, id
+}
+
+// packet encodes a control packet of the fixed header byte and the body.
+func packet(header byte, body []byte) []byte {
+	b := make([]byte, 0, len(body)+5)
+	b = append(b, header)
+	b = binary.AppendUvarint(b, uint64(len(body)))
+	return append(b, body...)
+}
+
+// readPacket reads a control packet, returning its fixed header byte and its body.
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+
+	return header, body, nil
+}
+
+// readString reads a string prefixed with its length, returning the rest of the data.
+func readString(b []byte) (string, []byte) {
+	n := int(binary.BigEndian.Uint16(b))
+	return string(b[2 : 2+n]), b[2+n:]
+}
+
+// newWriter creates a writer publishing to the broker for the host render-01.
+func newWriter(t *testing.T, cfg *config.MQTT) *Writer {
+	t.Helper()
+
+	w, err := New(&config.Output{Name: "mqtt", Type: config.OutputMQTT, MQTT: cfg})
+	require.NoError(t, err)
+	w.(*Writer).host = "render-01"
+	return w.(*Writer)
+}
+
+func TestWriter_Write(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		version config.MQTTVersion
+		qos     int
+		level   byte
+	}{
+		{name: "3.1.1 qos 0", version: config.MQTTVersion311, qos: 0, level: 4},
+		{name: "3.1.1 qos 1", version: config.MQTTVersion311, qos: 1, level: 4},
+		{name: "3.1.1 qos 2", version: config.MQTTVersion311, qos: 2, level: 4},
+		{name: "5 qos 0", version: config.MQTTVersion5, qos: 0, level: 5},
+		{name: "5 qos 1", version: config.MQTTVersion5, qos: 1, level: 5},
+		{name: "5 qos 2", version: config.MQTTVersion5, qos: 2, level: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := newBroker(t, 0)
+			cfg := config.DefaultMQTT()
+			cfg.Broker = b.url()
+			cfg.Version = tt.version
+			cfg.QoS = tt.qos
+			cfg.Username = "monitor"
+			cfg.Password = "secret"
+
+			w := newWriter(t, cfg)
+			require.NoError(t, w.Write(context.Background(), w.Encode(testSnapshot)))
+
+			connects, messages := b.wait(6)
+			require.Equal(t, []*connectPacket{
+				{
+					level:     tt.level,
+					clientID:  "sensor-monitor-render-01",
+					keepAlive: 30,
+					will: &message{
+						topic:   "sensor-monitor/render-01/status",
+						payload: []byte("offline"),
+						qos:     1,
+						retain:  true,
+					},
+					username: "monitor",
+					password: "secret",
+				},
+			}, connects)
+
+			topics := make([]string, 0, len(messages))
+			for _, m := range messages {
+				topics = append(topics, m.topic)
+			}
+			require.Equal(t, []string{
+				"sensor-monitor/render-01/status",
+				"homeassistant/sensor/render-01/coretemp-isa-0000_Core_0/config",
+				"homeassistant/sensor/render-01/dell_smm-isa-0000_fan1/config",
+				"sensor-monitor/render-01/coretemp-isa-0000/Core 0",
+				"sensor-monitor/render-01/dell_smm-isa-0000/fan1",
+				"sensor-monitor/render-01/acpitz-acpi-0/beep_enable",
+			}, topics)
+
+			require.Equal(t, &message{
+				topic:   "sensor-monitor/render-01/status",
+				payload: []byte("online"),
+				qos:     1,
+				retain:  true,
+			}, messages[0])
+			require.Equal(t, &message{
+				topic:   "sensor-monitor/render-01/coretemp-isa-0000/Core 0",
+				payload: []byte(`{"crit":100,"input":91.5}`),
+				qos:     byte(tt.qos),
+				retain:  true,
+			}, messages[3])
+
+			// Discovery configs are only published once per connection.
+			require.NoError(t, w.Write(context.Background(), w.Encode(testSnapshot)))
+			_, messages = b.wait(9)
+			require.Len(t, messages, 9)
+		})
+	}
+}
+
+func TestWriter_Discovery(t *testing.T) {
+	t.Parallel()
+
+	b := newBroker(t, 0)
+	cfg := config.DefaultMQTT()
+	cfg.Broker = b.url()
+
+	w := newWriter(t, cfg)
+	require.NoError(t, w.Write(context.Background(), w.Encode(testSnapshot)))
+
+	_, messages := b.received()
+	require.Len(t, messages, 6)
+	require.True(t, messages[1].retain)
+	require.JSONEq(t, `{
+		"name": "coretemp-isa-0000 Core 0",
+		"unique_id": "sensor-monitor_render-01_coretemp-isa-0000_Core_0",
+		"state_topic": "sensor-monitor/render-01/coretemp-isa-0000/Core 0",
+		"value_template": "{{ value_json.input }}",
+		"device_class": "temperature",
+		"unit_of_measurement": "°C",
+		"state_class": "measurement",
+		"availability_topic": "sensor-monitor/render-01/status",
+		"device": {
+			"identifiers": ["sensor-monitor_render-01"],
+			"name": "render-01",
+			"manufacturer": "sensor-monitor",
+			"model": "Hardware sensors"
+		}
+	}`, string(messages[1].payload))
+	require.JSONEq(t, `{
+		"name": "dell_smm-isa-0000 fan1",
+		"unique_id": "sensor-monitor_render-01_dell_smm-isa-0000_fan1",
+		"state_topic": "sensor-monitor/render-01/dell_smm-isa-0000/fan1",
+		"value_template": "{{ value_json.input }}",
+		"unit_of_measurement": "rpm",
+		"state_class": "measurement",
+		"icon": "mdi:fan",
+		"availability_topic": "sensor-monitor/render-01/status",
+		"device": {
+			"identifiers": ["sensor-monitor_render-01"],
+			"name": "render-01",
+			"manufacturer": "sensor-monitor",
+			"model": "Hardware sensors"
+		}
+	}`, string(messages[2].payload))
+
+	// Without discovery only the availability and the readings are published.
+	cfg = config.DefaultMQTT()
+	cfg.Broker = newBroker(t, 0).url()
+	cfg.Discovery = false
+
+	w = newWriter(t, cfg)
+	require.NoError(t, w.Write(context.Background(), w.Encode(testSnapshot)))
+	require.Empty(t, w.discovery)
+}
+
+func TestWriter_Write_Reconnect(t *testing.T) {
+	t.Parallel()
+
+	b := newBroker(t, 0)
+	cfg := config.DefaultMQTT()
+	cfg.Broker = b.url()
+
+	w := newWriter(t, cfg)
+	require.NoError(t, w.Write(context.Background(), w.Encode(testSnapshot)))
+
+	b.drop()
+	require.Eventually(t, w.client.closed, time.Second, 10*time.Millisecond)
+
+	// The new connection is marked online and announces every sensor again.
+	require.NoError(t, w.Write(context.Background(), w.Encode(testSnapshot)))
+
+	connects, messages := b.received()
+	require.Len(t, connects, 2)
+	require.Len(t, messages, 12)
+	require.Equal(t, "sensor-monitor/render-01/status", messages[6].topic)
+	require.Equal(t, "homeassistant/sensor/render-01/coretemp-isa-0000_Core_0/config", messages[7].topic)
+}
+
+func TestWriter_Close(t *testing.T) {
+	t.Parallel()
+
+	b := newBroker(t, 0)
+	cfg := config.DefaultMQTT()
+	cfg.Broker = b.url()
+
+	w := newWriter(t, cfg)
+	require.NoError(t, w.Close(context.Background()), "closing before connecting does nothing")
+	require.NoError(t, w.Write(context.Background(), w.Encode(testSnapshot)))
+	require.NoError(t, w.Close(context.Background()))
+	require.True(t, w.client.closed())
+
+	_, messages := b.wait(7)
+	require.Equal(t, &message{
+		topic:   "sensor-monitor/render-01/status",
+		payload: []byte("offline"),
+		qos:     1,
+		retain:  true,
+	}, messages[6])
+}
+
+func TestWriter_Write_Refused(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		version config.MQTTVersion
+		code    byte
+		want    string
+	}{
+		{
+			name:    "3.1.1",
+			version: config.MQTTVersion311,
+			code:    4,
+			want:    "failed to connect to broker: bad user name or password",
+		},
+		{
+			name:    "5",
+			version: config.MQTTVersion5,
+			code:    0x86,
+			want:    "failed to connect to broker: connection refused: reason code 0x86",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := newBroker(t, tt.code)
+			cfg := config.DefaultMQTT()
+			cfg.Broker = b.url()
+			cfg.Version = tt.version
+
+			w := newWriter(t, cfg)
+			err := w.Write(context.Background(), w.Encode(testSnapshot))
+			require.EqualError(t, err, tt.want)
+
+			// Nothing is published to a broker that refused the connection.
+			_, messages := b.received()
+			require.Empty(t, messages)
+		})
+	}
+}
+
+func TestTopicLevel_ObjectID(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "nvme_0_temp_1_", topicLevel("nvme/0+temp#1\x00"))
+	require.Equal(t, "Core_0_fan-1", objectID("Core 0.fan-1"))
+}
@@ -0,0 +1,334 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/output"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// scopeName is the name of the instrumentation scope of the metrics.
+const scopeName = "github.com/jacobbrewer1/sensor-monitor"
+
+// instrument is the name, unit and description of the metric of a kind of sensor.
+type instrument struct {
+	name        string
+	unit        string
+	description string
+}
+
+// instruments are the metrics of the kinds of sensors. Units are UCUM codes, as OpenTelemetry recommends.
+var instruments = map[sensors.Kind]instrument{
+	sensors.KindTemperature: {"sensor.temperature", "Cel", "Temperature of the sensor."},
+	sensors.KindFan:         {"sensor.fan", "rpm", "Speed of the fan."},
+	sensors.KindVoltage:     {"sensor.voltage", "V", "Voltage of the sensor."},
+	sensors.KindCurrent:     {"sensor.current", "A", "Current of the sensor."},
+	sensors.KindPower:       {"sensor.power", "W", "Power of the sensor."},
+	sensors.KindEnergy:      {"sensor.energy", "J", "Energy of the sensor."},
+	sensors.KindHumidity:    {"sensor.humidity", "%", "Relative humidity of the sensor."},
+	sensors.KindCooling:     {"sensor.cooling", "1", "State of the cooling device."},
+}
+
+// limits are the hardware limits exported as the limit metric of a kind, with a limit attribute naming the limit.
+var limits = []sensors.Subfeature{
+	sensors.SubfeatureMin,
+	sensors.SubfeatureMax,
+	sensors.SubfeatureCrit,
+	"lcrit",
+	"emergency",
+	sensors.SubfeaturePassive,
+	sensors.SubfeatureHot,
+}
+
+// attribute is a string attribute of a resource or data point.
+type attribute struct {
+	key   string
+	value string
+}
+
+// point is one value of a sensor, exported as a gauge with a single data point.
+type point struct {
+	instrument instrument
+	attributes []attribute
+	timestamp  int64
+	value      float64
+}
+
+// Writer exports readings as OpenTelemetry gauges over OTLP/HTTP, encoded as protobuf or JSON. The value of every
+// sensor is a data point of the metric of its kind, e.g. sensor.temperature, with chip, adapter, feature and kind
+// attributes. Its hardware limits are data points of the limit metric of the kind, e.g. sensor.temperature.limit.
+type Writer struct {
+	name     string
+	cfg      *config.OTLP
+	resource []attribute
+	client   *notify.HTTPClient
+}
+
+// New creates an OTLP writer from its configuration.
+func New(cfg *config.Output) (output.Writer, error) {
+	if cfg.OTLP == nil {
+		return nil, errors.New("missing otlp configuration")
+	}
+
+	return &Writer{
+		name:     cfg.Name,
+		cfg:      cfg.OTLP,
+		resource: resource(notify.Hostname(), dmiRoot),
+		client:   notify.NewHTTPClient(cfg.OTLP.HTTPOptions),
+	}, nil
+}
+
+// Name returns the name of the writer.
+func (w *Writer) Name() string {
+	return w.name
+}
+
+// Encode encodes every value of every reading of the snapshot as a metric, ready to be put together into a request
+// by Write.
+func (w *Writer) Encode(snapshot *sensors.Snapshot) []string {
+	lines := make([]string, 0, len(snapshot.Readings))
+	for _, p := range points(snapshot) {
+		if w.cfg.Encoding == config.OTLPJSON {
+			lines = append(lines, string(p.marshalJSON()))
+		} else {
+			lines = append(lines, string(p.marshalProto()))
+		}
+	}
+
+	return lines
+}
+
+// Write sends the metrics encoded by Encode in one export request.
+func (w *Writer) Write(ctx context.Context, lines []string) error {
+	contentType := "application/x-protobuf"
+	body := w.protoRequest(lines)
+	if w.cfg.Encoding == config.OTLPJSON {
+		contentType = "application/json"
+		body = w.jsonRequest(lines)
+	}
+
+	endpoint := strings.TrimSuffix(w.cfg.URL, "/") + "/v1/metrics"
+	_, err := w.client.Do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		for k, v := range w.cfg.Headers {
+			req.Header.Set(k, v)
+		}
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("User-Agent", "sensor-monitor")
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export metrics: %w", err)
+	}
+
+	return nil
+}
+
+// points returns the points of every value of every reading of the snapshot.
+func points(snapshot *sensors.Snapshot) []*point {
+	timestamp := snapshot.Time.UnixNano()
+	points := make([]*point, 0, len(snapshot.Readings))
+	for _, r := range snapshot.Readings {
+		inst, ok := instruments[r.Kind]
+		if !ok {
+			continue
+		}
+
+		attributes := []attribute{
+			{"chip", r.Chip},
+			{"adapter", r.Adapter},
+			{"feature", r.Name},
+			{"kind", r.Kind.String()},
+		}
+		add := func(inst instrument, value float64, extra ...attribute) {
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				return
+			}
+
+			points = append(points, &point{
+				instrument: inst,
+				attributes: slices.Concat(attributes, extra),
+				timestamp:  timestamp,
+				value:      value,
+			})
+		}
+
+		if v, ok := r.Input(); ok {
+			add(inst, v)
+		}
+
+		limit := instrument{
+			name:        inst.name + ".limit",
+			unit:        inst.unit,
+			description: "Limit of the sensor reported by the hardware.",
+		}
+		for _, sf := range limits {
+			if v, ok := r.Values[sf]; ok {
+				add(limit, v, attribute{"limit", string(sf)})
+			}
+		}
+	}
+
+	return points
+}
+
+// marshalProto encodes the point as the metrics field of a ScopeMetrics message. Repeated fields may be split across
+// the message, so the encoded metrics of many points are simply concatenated.
+func (p *point) marshalProto() []byte {
+	dp := make([]byte, 0, 128)
+	for _, a := range p.attributes {
+		if a.value != "" {
+			dp = appendKeyValue(dp, fieldDataPointAttributes, a.key, a.value)
+		}
+	}
+	dp = appendFixed64(dp, fieldDataPointTimeUnixNano, uint64(p.timestamp)) //nolint:gosec // Readings are after 1970.
+	dp = appendDouble(dp, fieldDataPointAsDouble, p.value)
+
+	metric := appendString(nil, fieldMetricName, p.instrument.name)
+	metric = appendString(metric, fieldMetricDescription, p.instrument.description)
+	metric = appendString(metric, fieldMetricUnit, p.instrument.unit)
+	metric = appendMessage(metric, fieldMetricGauge, appendMessage(nil, fieldGaugeDataPoints, dp))
+
+	return appendMessage(nil, fieldScopeMetricsMetrics, metric)
+}
+
+// protoRequest encodes an ExportMetricsServiceRequest of the resource and the encoded metrics.
+func (w *Writer) protoRequest(lines []string) []byte {
+	res := make([]byte, 0, 256)
+	for _, a := range w.resource {
+		res = appendKeyValue(res, fieldResourceAttributes, a.key, a.value)
+	}
+
+	scope := appendString(nil, fieldScopeName, scopeName)
+	scopeMetrics := appendMessage(nil, fieldScopeMetricsScope, scope)
+	for _, line := range lines {
+		scopeMetrics = append(scopeMetrics, line...)
+	}
+
+	resourceMetrics := appendMessage(nil, fieldResourceMetricsResource, res)
+	resourceMetrics = appendMessage(resourceMetrics, fieldResourceMetricsScopeMetrics, scopeMetrics)
+
+	return appendMessage(nil, fieldRequestResourceMetrics, resourceMetrics)
+}
+
+// The messages of the JSON encoding of OTLP. Field names are in lower camel case, and 64 bit integers are strings.
+type (
+	jsonKeyValue struct {
+		Key   string       `json:"key"`
+		Value jsonAnyValue `json:"value"`
+	}
+
+	jsonAnyValue struct {
+		StringValue string `json:"stringValue"`
+	}
+
+	jsonMetric struct {
+		Name        string    `json:"name"`
+		Description string    `json:"description,omitempty"`
+		Unit        string    `json:"unit,omitempty"`
+		Gauge       jsonGauge `json:"gauge"`
+	}
+
+	jsonGauge struct {
+		DataPoints []jsonDataPoint `json:"dataPoints"`
+	}
+
+	jsonDataPoint struct {
+		Attributes   []jsonKeyValue `json:"attributes,omitempty"`
+		TimeUnixNano string         `json:"timeUnixNano"`
+		AsDouble     float64        `json:"asDouble"`
+	}
+
+	jsonRequest struct {
+		ResourceMetrics []jsonResourceMetrics `json:"resourceMetrics"`
+	}
+
+	jsonResourceMetrics struct {
+		Resource     jsonResource       `json:"resource"`
+		ScopeMetrics []jsonScopeMetrics `json:"scopeMetrics"`
+	}
+
+	jsonResource struct {
+		Attributes []jsonKeyValue `json:"attributes"`
+	}
+
+	jsonScopeMetrics struct {
+		Scope   jsonScope         `json:"scope"`
+		Metrics []json.RawMessage `json:"metrics"`
+	}
+
+	jsonScope struct {
+		Name string `json:"name"`
+	}
+)
+
+// jsonAttributes converts attributes to their JSON encoding, leaving out those without a value.
+func jsonAttributes(attributes []attribute) []jsonKeyValue {
+	kvs := make([]jsonKeyValue, 0, len(attributes))
+	for _, a := range attributes {
+		if a.value != "" {
+			kvs = append(kvs, jsonKeyValue{Key: a.key, Value: jsonAnyValue{StringValue: a.value}})
+		}
+	}
+
+	return kvs
+}
+
+// marshalJSON encodes the point as a Metric message.
+func (p *point) marshalJSON() []byte {
+	b, _ := json.Marshal(&jsonMetric{ //nolint:errcheck // The message only holds strings and finite numbers.
+		Name:        p.instrument.name,
+		Description: p.instrument.description,
+		Unit:        p.instrument.unit,
+		Gauge: jsonGauge{
+			DataPoints: []jsonDataPoint{
+				{
+					Attributes:   jsonAttributes(p.attributes),
+					TimeUnixNano: strconv.FormatInt(p.timestamp, 10),
+					AsDouble:     p.value,
+				},
+			},
+		},
+	})
+
+	return b
+}
+
+// jsonRequest encodes an ExportMetricsServiceRequest of the resource and the encoded metrics.
+func (w *Writer) jsonRequest(lines []string) []byte {
+	metrics := make([]json.RawMessage, 0, len(lines))
+	for _, line := range lines {
+		metrics = append(metrics, json.RawMessage(line))
+	}
+
+	b, _ := json.Marshal(&jsonRequest{ //nolint:errcheck // The metrics were encoded by json.
+		ResourceMetrics: []jsonResourceMetrics{
+			{
+				Resource: jsonResource{Attributes: jsonAttributes(w.resource)},
+				ScopeMetrics: []jsonScopeMetrics{
+					{
+						Scope:   jsonScope{Name: scopeName},
+						Metrics: metrics,
+					},
+				},
+			},
+		},
+	})
+
+	return b
+}
@@ -0,0 +1,269 @@
+package otlp
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify/notifytest"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// testSnapshot is a snapshot of a core temperature sensor with a critical limit and a sensor of an unknown kind.
+var testSnapshot = &sensors.Snapshot{
+	Time: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+	Readings: []*sensors.Reading{
+		{
+			Chip:    "coretemp-isa-0000",
+			Adapter: "ISA adapter",
+			Feature: &sensors.Feature{
+				Name: "Core 0",
+				Kind: sensors.KindTemperature,
+				Values: map[sensors.Subfeature]float64{
+					sensors.SubfeatureInput:     91.5,
+					sensors.SubfeatureCrit:      100,
+					sensors.SubfeatureCritAlarm: 0,
+				},
+			},
+		},
+		{
+			Chip:    "acpitz-acpi-0",
+			Feature: &sensors.Feature{Name: "beep_enable", Values: map[sensors.Subfeature]float64{"input": 1}},
+		},
+	},
+}
+
+// newWriter creates a writer sending to the server with the given encoding, describing the host render-01.
+func newWriter(t *testing.T, url string, encoding config.OTLPEncoding) *Writer {
+	t.Helper()
+
+	cfg := config.DefaultOTLP()
+	cfg.URL = url
+	cfg.Encoding = encoding
+	cfg.Headers = map[string]string{"Authorization": "Bearer token"}
+
+	w, err := New(&config.Output{Name: "otlp", Type: config.OutputOTLP, OTLP: cfg})
+	require.NoError(t, err)
+	w.(*Writer).resource = []attribute{{"host.name", "render-01"}, {"hw.vendor", "Dell Inc."}}
+	return w.(*Writer)
+}
+
+// fields decodes a protobuf message into the values of each of its fields. Length-delimited values are returned as
+// they are, and fixed64 values as their eight bytes.
+func fields(t *testing.T, b []byte) map[int][][]byte {
+	t.Helper()
+
+	decoded := make(map[int][][]byte)
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		require.Positive(t, n)
+		b = b[n:]
+
+		field := int(tag >> 3) //nolint:gosec // Field numbers are small.
+		switch tag & 7 {
+		case wireBytes:
+			size, n := binary.Uvarint(b)
+			require.Positive(t, n)
+			b = b[n:]
+			decoded[field] = append(decoded[field], b[:size])
+			b = b[size:]
+		case wireFixed64:
+			decoded[field] = append(decoded[field], b[:8])
+			b = b[8:]
+		default:
+			require.Failf(t, "unexpected wire type", "field %d has wire type %d", field, tag&7)
+		}
+	}
+
+	return decoded
+}
+
+// keyValues decodes KeyValue messages with string values into a map.
+func keyValues(t *testing.T, messages [][]byte) map[string]string {
+	t.Helper()
+
+	kvs := make(map[string]string, len(messages))
+	for _, m := range messages {
+		kv := fields(t, m)
+		kvs[string(kv[fieldKeyValueKey][0])] = string(fields(t, kv[fieldKeyValueValue][0])[fieldAnyValueString][0])
+	}
+
+	return kvs
+}
+
+func TestWriter_Write_Protobuf(t *testing.T) {
+	t.Parallel()
+
+	server := notifytest.NewServer(t, func(*notifytest.Request) (int, string) {
+		return http.StatusOK, ""
+	})
+
+	w := newWriter(t, server.URL, config.OTLPProtobuf)
+	require.NoError(t, w.Write(context.Background(), w.Encode(testSnapshot)))
+
+	requests := server.Requests()
+	require.Len(t, requests, 1)
+	require.Equal(t, "/v1/metrics", requests[0].Path)
+	require.Equal(t, "application/x-protobuf", requests[0].Header.Get("Content-Type"))
+	require.Equal(t, "Bearer token", requests[0].Header.Get("Authorization"))
+
+	request := fields(t, requests[0].Body)
+	require.Len(t, request[fieldRequestResourceMetrics], 1)
+	resourceMetrics := fields(t, request[fieldRequestResourceMetrics][0])
+
+	res := fields(t, resourceMetrics[fieldResourceMetricsResource][0])
+	require.Equal(t, map[string]string{"host.name": "render-01", "hw.vendor": "Dell Inc."},
+		keyValues(t, res[fieldResourceAttributes]))
+
+	scopeMetrics := fields(t, resourceMetrics[fieldResourceMetricsScopeMetrics][0])
+	scope := fields(t, scopeMetrics[fieldScopeMetricsScope][0])
+	require.Equal(t, scopeName, string(scope[fieldScopeName][0]))
+
+	type dataPoint struct {
+		name, unit string
+		attributes map[string]string
+		time       uint64
+		value      float64
+	}
+
+	got := make([]dataPoint, 0)
+	for _, m := range scopeMetrics[fieldScopeMetricsMetrics] {
+		metric := fields(t, m)
+		gauge := fields(t, metric[fieldMetricGauge][0])
+		require.Len(t, gauge[fieldGaugeDataPoints], 1)
+
+		dp := fields(t, gauge[fieldGaugeDataPoints][0])
+		got = append(got, dataPoint{
+			name:       string(metric[fieldMetricName][0]),
+			unit:       string(metric[fieldMetricUnit][0]),
+			attributes: keyValues(t, dp[fieldDataPointAttributes]),
+			time:       binary.LittleEndian.Uint64(dp[fieldDataPointTimeUnixNano][0]),
+			value:      math.Float64frombits(binary.LittleEndian.Uint64(dp[fieldDataPointAsDouble][0])),
+		})
+	}
+
+	attributes := map[string]string{
+		"chip":    "coretemp-isa-0000",
+		"adapter": "ISA adapter",
+		"feature": "Core 0",
+		"kind":    "temp",
+	}
+	require.Equal(t, []dataPoint{
+		{
+			name:       "sensor.temperature",
+			unit:       "Cel",
+			attributes: attributes,
+			time:       1748779200000000000,
+			value:      91.5,
+		},
+		{
+			name: "sensor.temperature.limit",
+			unit: "Cel",
+			attributes: map[string]string{
+				"chip":    "coretemp-isa-0000",
+				"adapter": "ISA adapter",
+				"feature": "Core 0",
+				"kind":    "temp",
+				"limit":   "crit",
+			},
+			time:  1748779200000000000,
+			value: 100,
+		},
+	}, got)
+}
+
+func TestWriter_Write_JSON(t *testing.T) {
+	t.Parallel()
+
+	server := notifytest.NewServer(t, func(*notifytest.Request) (int, string) {
+		return http.StatusOK, "{}"
+	})
+
+	w := newWriter(t, server.URL+"/", config.OTLPJSON)
+	require.NoError(t, w.Write(context.Background(), w.Encode(testSnapshot)))
+
+	requests := server.Requests()
+	require.Len(t, requests, 1)
+	require.Equal(t, "/v1/metrics", requests[0].Path)
+	require.Equal(t, "application/json", requests[0].Header.Get("Content-Type"))
+	require.JSONEq(t, `{
+		"resourceMetrics": [{
+			"resource": {
+				"attributes": [
+					{"key": "host.name", "value": {"stringValue": "render-01"}},
+					{"key": "hw.vendor", "value": {"stringValue": "Dell Inc."}}
+				]
+			},
+			"scopeMetrics": [{
+				"scope": {"name": "github.com/jacobbrewer1/sensor-monitor"},
+				"metrics": [
+					{
+						"name": "sensor.temperature",
+						"description": "Temperature of the sensor.",
+						"unit": "Cel",
+						"gauge": {"dataPoints": [{
+							"attributes": [
+								{"key": "chip", "value": {"stringValue": "coretemp-isa-0000"}},
+								{"key": "adapter", "value": {"stringValue": "ISA adapter"}},
+								{"key": "feature", "value": {"stringValue": "Core 0"}},
+								{"key": "kind", "value": {"stringValue": "temp"}}
+							],
+							"timeUnixNano": "1748779200000000000",
+							"asDouble": 91.5
+						}]}
+					},
+					{
+						"name": "sensor.temperature.limit",
+						"description": "Limit of the sensor reported by the hardware.",
+						"unit": "Cel",
+						"gauge": {"dataPoints": [{
+							"attributes": [
+								{"key": "chip", "value": {"stringValue": "coretemp-isa-0000"}},
+								{"key": "adapter", "value": {"stringValue": "ISA adapter"}},
+								{"key": "feature", "value": {"stringValue": "Core 0"}},
+								{"key": "kind", "value": {"stringValue": "temp"}},
+								{"key": "limit", "value": {"stringValue": "crit"}}
+							],
+							"timeUnixNano": "1748779200000000000",
+							"asDouble": 100
+						}]}
+					}
+				]
+			}]
+		}]
+	}`, string(requests[0].Body))
+
+	// Every line is a complete Metric message.
+	for _, line := range w.Encode(testSnapshot) {
+		require.True(t, json.Valid([]byte(line)))
+	}
+}
+
+func TestResource(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "sys_vendor"), []byte("Dell Inc.\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "product_name"), []byte("Precision 7960 Tower\n"), 0o600))
+
+	require.Equal(t, []attribute{
+		{"service.name", "sensor-monitor"},
+		{"host.name", "render-01"},
+		{"host.arch", runtime.GOARCH},
+		{"os.type", runtime.GOOS},
+		{"hw.vendor", "Dell Inc."},
+		{"hw.model", "Precision 7960 Tower"},
+	}, resource("render-01", root))
+
+	require.Len(t, resource("render-01", filepath.Join(root, "missing")), 4, "a missing DMI table is left out")
+}
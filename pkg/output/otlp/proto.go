@@ -0,0 +1,83 @@
+package otlp
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// The wire types of the protobuf fields that are written.
+const (
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+// The numbers of the fields of the OTLP messages that are written, from opentelemetry/proto/collector/metrics/v1,
+// opentelemetry/proto/metrics/v1, opentelemetry/proto/resource/v1 and opentelemetry/proto/common/v1.
+const (
+	fieldRequestResourceMetrics = 1
+
+	fieldResourceMetricsResource     = 1
+	fieldResourceMetricsScopeMetrics = 2
+
+	fieldResourceAttributes = 1
+
+	fieldScopeMetricsScope   = 1
+	fieldScopeMetricsMetrics = 2
+
+	fieldScopeName = 1
+
+	fieldMetricName        = 1
+	fieldMetricDescription = 2
+	fieldMetricUnit        = 3
+	fieldMetricGauge       = 5
+
+	fieldGaugeDataPoints = 1
+
+	fieldDataPointTimeUnixNano = 3
+	fieldDataPointAsDouble     = 4
+	fieldDataPointAttributes   = 7
+
+	fieldKeyValueKey   = 1
+	fieldKeyValueValue = 2
+
+	fieldAnyValueString = 1
+)
+
+// appendTag appends the tag of a field.
+func appendTag(b []byte, field, wire int) []byte {
+	return binary.AppendUvarint(b, uint64(field)<<3|uint64(wire)) //nolint:gosec // Field numbers are small constants.
+}
+
+// appendMessage appends a length-delimited field holding an encoded message.
+func appendMessage(b []byte, field int, msg []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(msg)))
+	return append(b, msg...)
+}
+
+// appendString appends a string field. Empty strings are the default and are left out.
+func appendString(b []byte, field int, s string) []byte {
+	if s == "" {
+		return b
+	}
+
+	return appendMessage(b, field, []byte(s))
+}
+
+// appendFixed64 appends a fixed64 field.
+func appendFixed64(b []byte, field int, v uint64) []byte {
+	b = appendTag(b, field, wireFixed64)
+	return binary.LittleEndian.AppendUint64(b, v)
+}
+
+// appendDouble appends a double field.
+func appendDouble(b []byte, field int, v float64) []byte {
+	return appendFixed64(b, field, math.Float64bits(v))
+}
+
+// appendKeyValue appends a KeyValue field with a string value.
+func appendKeyValue(b []byte, field int, key, value string) []byte {
+	kv := appendString(nil, fieldKeyValueKey, key)
+	kv = appendMessage(kv, fieldKeyValueValue, appendString(nil, fieldAnyValueString, value))
+	return appendMessage(b, field, kv)
+}
@@ -0,0 +1,39 @@
+package otlp
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// dmiRoot is where the kernel exposes the DMI table describing the hardware.
+const dmiRoot = "/sys/class/dmi/id"
+
+// resource returns the attributes of the resource the metrics describe: the monitor, the host and, when the kernel
+// exposes its DMI table, the vendor and model of the hardware.
+func resource(host, dmiRoot string) []attribute {
+	attributes := []attribute{
+		{"service.name", "sensor-monitor"},
+		{"host.name", host},
+		{"host.arch", runtime.GOARCH},
+		{"os.type", runtime.GOOS},
+	}
+
+	for _, dmi := range []struct{ key, file string }{
+		{"hw.vendor", "sys_vendor"},
+		{"hw.model", "product_name"},
+	} {
+		data, err := os.ReadFile(filepath.Join(dmiRoot, dmi.file))
+		if err != nil {
+			// Not every machine has a DMI table, e.g. most ARM boards.
+			continue
+		}
+
+		if value := strings.TrimSpace(string(data)); value != "" {
+			attributes = append(attributes, attribute{dmi.key, value})
+		}
+	}
+
+	return attributes
+}
@@ -0,0 +1,233 @@
+// Package output pushes the readings of every snapshot to time series databases such as InfluxDB and Graphite.
+package output
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/alert"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// Writer writes readings to a time series database in its own protocol. Readings are encoded into lines, one per
+// point, that are buffered until they are written. Lines are usually text, but may be any encoding of a point that the
+// writer can put together into a request, such as a protobuf message.
+type Writer interface {
+	// Name identifies the writer in logs.
+	Name() string
+
+	// Encode encodes the readings of a snapshot into lines of the protocol.
+	Encode(snapshot *sensors.Snapshot) []string
+
+	// Write sends lines encoded by Encode to the database.
+	Write(ctx context.Context, lines []string) error
+}
+
+// Closer is implemented by writers that keep a connection open between writes, such as an MQTT client.
+type Closer interface {
+	// Close closes the connection, telling the database the monitor has gone away if its protocol can.
+	Close(ctx context.Context) error
+}
+
+// Factory creates a writer from its configuration.
+type Factory func(cfg *config.Output) (Writer, error)
+
+// Registry holds the factory of every output type.
+type Registry struct {
+	factories map[config.OutputType]Factory
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[config.OutputType]Factory),
+	}
+}
+
+// Register adds the factory for an output type, replacing any already registered.
+func (r *Registry) Register(t config.OutputType, factory Factory) {
+	r.factories[t] = factory
+}
+
+// New creates the writer described by the configuration.
+func (r *Registry) New(cfg *config.Output) (Writer, error) {
+	factory, ok := r.factories[cfg.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown output type %q", cfg.Type)
+	}
+
+	w, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s output %q: %w", cfg.Type, cfg.Name, err)
+	}
+
+	return w, nil
+}
+
+// buffer holds the lines of a writer that have not been sent yet.
+type buffer struct {
+	cfg      *config.Output
+	writer   Writer
+	interval time.Duration
+	size     int
+
+	// lines are the lines waiting to be sent, oldest first.
+	lines []string
+
+	// dropped counts the lines dropped because the buffer was full since lines were last sent.
+	dropped int
+
+	// flushedAt is when the lines were last sent, or sending them was last attempted.
+	flushedAt time.Time
+}
+
+// Pusher buffers the readings of every snapshot for every configured output and sends them each flush interval.
+// While an output's endpoint is down its lines stay buffered, up to its buffer size.
+type Pusher struct {
+	clock   alert.Clock
+	buffers []*buffer
+}
+
+// NewPusher creates the writers of the outputs described by the configuration.
+func NewPusher(registry *Registry, cfgs []*config.Output, clock alert.Clock) (*Pusher, error) {
+	now := clock.Now()
+	buffers := make([]*buffer, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		w, err := registry.New(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		buffers = append(buffers, &buffer{
+			cfg:       cfg,
+			writer:    w,
+			interval:  cfg.FlushInterval.Std(),
+			size:      cfg.BufferSize,
+			flushedAt: now,
+		})
+	}
+
+	return &Pusher{clock: clock, buffers: buffers}, nil
+}
+
+// Push buffers the readings of the snapshot for every output, then sends the buffered lines of the outputs whose
+// flush interval has passed. An output failing does not stop the others; every failure is returned joined together.
+func (p *Pusher) Push(ctx context.Context, snapshot *sensors.Snapshot) error {
+	for _, b := range p.buffers {
+		b.add(b.writer.Encode(snapshot))
+	}
+
+	return p.Flush(ctx, false)
+}
+
+// Flush sends the buffered lines of every output whose flush interval has passed, or of every output when force is
+// true, e.g. when the monitor is shutting down.
+func (p *Pusher) Flush(ctx context.Context, force bool) error {
+	now := p.clock.Now()
+	errs := make([]error, 0)
+	for _, b := range p.buffers {
+		if !force && now.Sub(b.flushedAt) < b.interval {
+			continue
+		}
+
+		if err := b.flush(ctx, now); err != nil {
+			errs = append(errs, fmt.Errorf("failed to write to %s: %w", b.writer.Name(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close sends the buffered lines of every output, then closes the outputs that keep a connection open. It is called
+// when the monitor is shutting down or the outputs are replaced.
+func (p *Pusher) Close(ctx context.Context) error {
+	errs := []error{p.Flush(ctx, true)}
+	for _, b := range p.buffers {
+		if c, ok := b.writer.(Closer); ok {
+			if err := c.Close(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("failed to close %s: %w", b.writer.Name(), err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Adopt takes over the outputs of prev whose configuration has not changed, with their buffered lines and open
+// connections, so that reloading the configuration neither drops readings nor reconnects. It must be called before the
+// pusher is used. The outputs of prev that are not taken over are flushed and closed; every failure is returned joined
+// together.
+func (p *Pusher) Adopt(ctx context.Context, prev *Pusher) error {
+	adopted := make(map[*buffer]bool, len(prev.buffers))
+	for i, b := range p.buffers {
+		for _, old := range prev.buffers {
+			if !adopted[old] && reflect.DeepEqual(old.cfg, b.cfg) {
+				adopted[old] = true
+				p.buffers[i] = old
+				break
+			}
+		}
+	}
+
+	errs := make([]error, 0)
+	for _, b := range prev.buffers {
+		if adopted[b] {
+			continue
+		}
+
+		if err := b.flush(ctx, p.clock.Now()); err != nil {
+			errs = append(errs, fmt.Errorf("failed to write to %s: %w", b.writer.Name(), err))
+		}
+
+		if c, ok := b.writer.(Closer); ok {
+			if err := c.Close(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("failed to close %s: %w", b.writer.Name(), err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// add appends lines to the buffer, dropping the oldest lines once it is full.
+func (b *buffer) add(lines []string) {
+	b.lines = append(b.lines, lines...)
+	if over := len(b.lines) - b.size; over > 0 {
+		b.dropped += over
+		b.lines = slices.Delete(b.lines, 0, over)
+	}
+}
+
+// flush sends the buffered lines, keeping them to be sent again if they could not be. Lines the endpoint rejected for
+// good, e.g. with a 400 for a malformed point, are dropped rather than sent again forever.
+func (b *buffer) flush(ctx context.Context, now time.Time) error {
+	b.flushedAt = now
+	if len(b.lines) == 0 {
+		return nil
+	}
+
+	if err := b.writer.Write(ctx, b.lines); err != nil {
+		statusErr := new(notify.StatusError)
+		if errors.As(err, &statusErr) && statusErr.Permanent() {
+			dropped := len(b.lines) + b.dropped
+			b.lines = nil
+			b.dropped = 0
+			return fmt.Errorf("%w (%d lines dropped)", err, dropped)
+		}
+
+		if b.dropped > 0 {
+			return fmt.Errorf("%w (%d lines buffered, %d dropped)", err, len(b.lines), b.dropped)
+		}
+		return fmt.Errorf("%w (%d lines buffered)", err, len(b.lines))
+	}
+
+	b.lines = nil
+	b.dropped = 0
+	return nil
+}
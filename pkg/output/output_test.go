@@ -0,0 +1,289 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jacobbrewer1/sensor-monitor/pkg/config"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/notify"
+	"github.com/jacobbrewer1/sensor-monitor/pkg/sensors"
+)
+
+// fakeWriter encodes a snapshot as one line per reading and records the writes it is sent, optionally failing them.
+type fakeWriter struct {
+	name   string
+	err    error
+	writes [][]string
+	closed bool
+}
+
+func (w *fakeWriter) Name() string {
+	return w.name
+}
+
+func (w *fakeWriter) Encode(snapshot *sensors.Snapshot) []string {
+	lines := make([]string, 0, len(snapshot.Readings))
+	for _, r := range snapshot.Readings {
+		v, _ := r.Input()
+		lines = append(lines, r.ID()+" "+strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	return lines
+}
+
+func (w *fakeWriter) Write(_ context.Context, lines []string) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	w.writes = append(w.writes, lines)
+	return nil
+}
+
+func (w *fakeWriter) Close(context.Context) error {
+	w.closed = true
+	return nil
+}
+
+// manualClock returns a time that the test moves forward.
+type manualClock struct {
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	return c.now
+}
+
+// snapshot returns a snapshot of a single core temperature sensor with the given value.
+func snapshot(value float64) *sensors.Snapshot {
+	return &sensors.Snapshot{
+		Readings: []*sensors.Reading{
+			{
+				Chip: "coretemp-isa-0000",
+				Feature: &sensors.Feature{
+					Name:   "Core 0",
+					Kind:   sensors.KindTemperature,
+					Values: map[sensors.Subfeature]float64{sensors.SubfeatureInput: value},
+				},
+			},
+		},
+	}
+}
+
+// newPusher creates a pusher of a single fake writer with the given buffer size and a ten second flush interval.
+func newPusher(t *testing.T, w *fakeWriter, clock *manualClock, size int) *Pusher {
+	t.Helper()
+
+	registry := NewRegistry()
+	registry.Register(config.OutputInfluxDB, func(*config.Output) (Writer, error) {
+		return w, nil
+	})
+
+	p, err := NewPusher(registry, []*config.Output{
+		{
+			Name:          "influxdb",
+			Type:          config.OutputInfluxDB,
+			FlushInterval: config.Duration(10 * time.Second),
+			BufferSize:    size,
+		},
+	}, clock)
+	require.NoError(t, err)
+	return p
+}
+
+func TestRegistry_New(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	registry.Register(config.OutputGraphite, func(cfg *config.Output) (Writer, error) {
+		return &fakeWriter{name: cfg.Name}, nil
+	})
+	registry.Register(config.OutputInfluxDB, func(*config.Output) (Writer, error) {
+		return nil, errors.New("missing url")
+	})
+
+	w, err := registry.New(&config.Output{Name: "carbon", Type: config.OutputGraphite})
+	require.NoError(t, err)
+	require.Equal(t, "carbon", w.Name())
+
+	_, err = registry.New(&config.Output{Name: "influx", Type: config.OutputInfluxDB})
+	require.EqualError(t, err, `failed to create influxdb output "influx": missing url`)
+
+	_, err = registry.New(&config.Output{Name: "tsdb", Type: "opentsdb"})
+	require.EqualError(t, err, `unknown output type "opentsdb"`)
+}
+
+func TestPusher_Push(t *testing.T) {
+	t.Parallel()
+
+	w := &fakeWriter{name: "influxdb"}
+	clock := &manualClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	p := newPusher(t, w, clock, 100)
+
+	for i := range 3 {
+		require.NoError(t, p.Push(context.Background(), snapshot(float64(40+i))))
+		clock.now = clock.now.Add(4 * time.Second)
+	}
+	require.Empty(t, w.writes, "nothing is written before the flush interval has passed")
+
+	// The third push was at 8s; the fourth at 12s is past the interval.
+	require.NoError(t, p.Push(context.Background(), snapshot(43)))
+	require.Equal(t, [][]string{{
+		"coretemp-isa-0000/Core 0 40",
+		"coretemp-isa-0000/Core 0 41",
+		"coretemp-isa-0000/Core 0 42",
+		"coretemp-isa-0000/Core 0 43",
+	}}, w.writes)
+
+	require.NoError(t, p.Push(context.Background(), snapshot(44)))
+	require.NoError(t, p.Flush(context.Background(), true))
+	require.Equal(t, []string{"coretemp-isa-0000/Core 0 44"}, w.writes[1])
+}
+
+func TestPusher_Push_Buffer(t *testing.T) {
+	t.Parallel()
+
+	w := &fakeWriter{name: "influxdb", err: errors.New("connection refused")}
+	clock := &manualClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	p := newPusher(t, w, clock, 3)
+
+	clock.now = clock.now.Add(10 * time.Second)
+	require.EqualError(t, p.Push(context.Background(), snapshot(40)),
+		"failed to write to influxdb: connection refused (1 lines buffered)")
+
+	// Pushes while the endpoint is down are kept until the buffer is full, then the oldest are dropped.
+	for i := range 4 {
+		require.NoError(t, p.Push(context.Background(), snapshot(float64(41+i))))
+	}
+
+	require.EqualError(t, p.Flush(context.Background(), true),
+		"failed to write to influxdb: connection refused (3 lines buffered, 2 dropped)")
+
+	w.err = nil
+	require.NoError(t, p.Flush(context.Background(), true))
+	require.Equal(t, [][]string{{
+		"coretemp-isa-0000/Core 0 42",
+		"coretemp-isa-0000/Core 0 43",
+		"coretemp-isa-0000/Core 0 44",
+	}}, w.writes)
+
+	require.NoError(t, p.Flush(context.Background(), true))
+	require.Len(t, w.writes, 1, "an empty buffer is not written")
+}
+
+func TestPusher_Push_Rejected(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  int
+		wantErr string
+		want    int
+	}{
+		{
+			name:    "bad request",
+			status:  400,
+			wantErr: "failed to write to influxdb: unexpected status 400: invalid field format (2 lines dropped)",
+		},
+		{
+			name:    "unauthorized",
+			status:  401,
+			wantErr: "failed to write to influxdb: unexpected status 401: invalid field format (2 lines dropped)",
+		},
+		{
+			name:    "request timeout",
+			status:  408,
+			wantErr: "failed to write to influxdb: unexpected status 408: invalid field format (2 lines buffered)",
+			want:    2,
+		},
+		{
+			name:    "rate limited",
+			status:  429,
+			wantErr: "failed to write to influxdb: unexpected status 429: invalid field format (2 lines buffered)",
+			want:    2,
+		},
+		{
+			name:    "server error",
+			status:  503,
+			wantErr: "failed to write to influxdb: unexpected status 503: invalid field format (2 lines buffered)",
+			want:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := &fakeWriter{name: "influxdb", err: &notify.StatusError{StatusCode: tt.status, Body: "invalid field format"}}
+			clock := &manualClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+			p := newPusher(t, w, clock, 100)
+
+			// Lines rejected for good are dropped rather than sent again on every flush.
+			require.NoError(t, p.Push(context.Background(), snapshot(40)))
+			require.NoError(t, p.Push(context.Background(), snapshot(41)))
+			require.EqualError(t, p.Flush(context.Background(), true), tt.wantErr)
+			require.Len(t, p.buffers[0].lines, tt.want)
+		})
+	}
+}
+
+func TestPusher_Close(t *testing.T) {
+	t.Parallel()
+
+	w := &fakeWriter{name: "influxdb"}
+	clock := &manualClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	p := newPusher(t, w, clock, 100)
+
+	require.NoError(t, p.Push(context.Background(), snapshot(40)))
+	require.Empty(t, w.writes)
+
+	// Closing sends what is buffered before the writer is closed.
+	require.NoError(t, p.Close(context.Background()))
+	require.Equal(t, [][]string{{"coretemp-isa-0000/Core 0 40"}}, w.writes)
+	require.True(t, w.closed)
+}
+
+func TestPusher_Adopt(t *testing.T) {
+	t.Parallel()
+
+	created := make(map[string][]*fakeWriter)
+	registry := NewRegistry()
+	registry.Register(config.OutputInfluxDB, func(cfg *config.Output) (Writer, error) {
+		w := &fakeWriter{name: cfg.Name}
+		created[cfg.Name] = append(created[cfg.Name], w)
+		return w, nil
+	})
+
+	clock := &manualClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	output := func(name string, size int) *config.Output {
+		return &config.Output{
+			Name:          name,
+			Type:          config.OutputInfluxDB,
+			FlushInterval: config.Duration(10 * time.Second),
+			BufferSize:    size,
+		}
+	}
+
+	prev, err := NewPusher(registry, []*config.Output{output("kept", 100), output("changed", 100)}, clock)
+	require.NoError(t, err)
+	require.NoError(t, prev.Push(context.Background(), snapshot(40)))
+
+	p, err := NewPusher(registry, []*config.Output{output("changed", 50), output("kept", 100)}, clock)
+	require.NoError(t, err)
+	require.NoError(t, p.Adopt(context.Background(), prev))
+
+	// The changed output is flushed and closed, while the unchanged one is taken over with the lines it buffered.
+	require.Equal(t, [][]string{{"coretemp-isa-0000/Core 0 40"}}, created["changed"][0].writes)
+	require.True(t, created["changed"][0].closed)
+	require.Empty(t, created["kept"][0].writes)
+	require.False(t, created["kept"][0].closed)
+
+	require.NoError(t, p.Close(context.Background()))
+	require.Equal(t, [][]string{{"coretemp-isa-0000/Core 0 40"}}, created["kept"][0].writes)
+	require.Empty(t, created["kept"][1].writes)
+	require.Empty(t, created["changed"][1].writes)
+}
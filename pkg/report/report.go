@@ -0,0 +1,225 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is how a table is written.
+type Format string
+
+const (
+	// FormatTable is a table aligned for the terminal.
+	FormatTable Format = "table"
+
+	// FormatJSON is a JSON array of an object for each row.
+	FormatJSON Format = "json"
+
+	// FormatYAML is a YAML sequence of a mapping for each row.
+	FormatYAML Format = "yaml"
+
+	// FormatCSV is comma separated values with a header row.
+	FormatCSV Format = "csv"
+)
+
+// Formats is every format.
+var Formats = []Format{
+	FormatTable,
+	FormatJSON,
+	FormatYAML,
+	FormatCSV,
+}
+
+// ParseFormat returns the format with the given name.
+func ParseFormat(name string) (Format, error) {
+	if f := Format(name); slices.Contains(Formats, f) {
+		return f, nil
+	}
+
+	names := make([]string, 0, len(Formats))
+	for _, f := range Formats {
+		names = append(names, string(f))
+	}
+	return "", fmt.Errorf("unknown format %q, must be one of %s", name, strings.Join(names, ", "))
+}
+
+// Table is rows of values under named columns. Columns are named as they appear in JSON, YAML and CSV, e.g. "chip",
+// and upper cased in the header of a table.
+//
+// Values are strings, numbers, booleans, nil for a missing value, or map[string]float64 for a set of named values such
+// as the limits of a sensor.
+type Table struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// NewTable creates an empty table with the given columns.
+func NewTable(columns ...string) *Table {
+	return &Table{Columns: columns, Rows: make([][]any, 0)}
+}
+
+// Add adds a row of a value for each column.
+func (t *Table) Add(values ...any) {
+	t.Rows = append(t.Rows, values)
+}
+
+// Write writes the table in the format.
+func Write(w io.Writer, format Format, t *Table) error {
+	switch format {
+	case FormatTable:
+		return writeTable(w, t)
+	case FormatJSON:
+		return writeJSON(w, t)
+	case FormatYAML:
+		return writeYAML(w, t)
+	case FormatCSV:
+		return writeCSV(w, t)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// writeTable writes the table with its columns aligned. Missing values are shown as a dash.
+func writeTable(w io.Writer, t *Table) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	header := make([]string, 0, len(t.Columns))
+	for _, c := range t.Columns {
+		header = append(header, strings.ToUpper(c))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, row := range t.Rows {
+		cells := make([]string, 0, len(row))
+		for _, v := range row {
+			cell := format(v)
+			if cell == "" {
+				cell = "-"
+			}
+			cells = append(cells, cell)
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// writeCSV writes the table as comma separated values. Missing values are empty.
+func writeCSV(w io.Writer, t *Table) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.Columns); err != nil {
+		return err
+	}
+
+	for _, row := range t.Rows {
+		cells := make([]string, 0, len(row))
+		for _, v := range row {
+			cells = append(cells, format(v))
+		}
+
+		if err := cw.Write(cells); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeJSON writes the table as an indented JSON array, keeping the order of the columns in each object.
+func writeJSON(w io.Writer, t *Table) error {
+	rows := make([]json.RawMessage, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		var b strings.Builder
+		b.WriteByte('{')
+		for i, c := range t.Columns {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+
+			key, err := json.Marshal(c)
+			if err != nil {
+				return err
+			}
+
+			value, err := json.Marshal(finite(row[i]))
+			if err != nil {
+				return err
+			}
+
+			b.Write(key)
+			b.WriteByte(':')
+			b.Write(value)
+		}
+		b.WriteByte('}')
+
+		rows = append(rows, json.RawMessage(b.String()))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rows)
+}
+
+// writeYAML writes the table as a YAML sequence, keeping the order of the columns in each mapping.
+func writeYAML(w io.Writer, t *Table) error {
+	seq := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, row := range t.Rows {
+		mapping := &yaml.Node{Kind: yaml.MappingNode}
+		for i, c := range t.Columns {
+			value := new(yaml.Node)
+			if err := value.Encode(finite(row[i])); err != nil {
+				return err
+			}
+
+			mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: c}, value)
+		}
+		seq.Content = append(seq.Content, mapping)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(seq); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// format formats a value for a table or CSV. Named values are formatted as "name=value", sorted by name.
+func format(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]float64:
+		pairs := make([]string, 0, len(v))
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			pairs = append(pairs, k+"="+strconv.FormatFloat(v[k], 'f', -1, 64))
+		}
+		return strings.Join(pairs, " ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// finite replaces values JSON cannot represent, NaN and infinities, with nil.
+func finite(v any) any {
+	if f, ok := v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+		return nil
+	}
+
+	return v
+}
@@ -0,0 +1,112 @@
+package report
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testTable is a table of a temperature with limits and a fan without any.
+func testTable() *Table {
+	t := NewTable("id", "kind", "input", "limits", "alarm")
+	t.Add("coretemp-isa-0000/Core 0", "temp", 61.5, map[string]float64{"max": 80, "crit": 100}, false)
+	t.Add("dell_smm-isa-0000/fan1", "fan", 2400.0, map[string]float64{}, nil)
+	return t
+}
+
+func TestParseFormat(t *testing.T) {
+	t.Parallel()
+
+	f, err := ParseFormat("csv")
+	require.NoError(t, err)
+	require.Equal(t, FormatCSV, f)
+
+	_, err = ParseFormat("xml")
+	require.EqualError(t, err, `unknown format "xml", must be one of table, json, yaml, csv`)
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{
+			format: FormatTable,
+			want: "" +
+				"ID                        KIND  INPUT  LIMITS           ALARM\n" +
+				"coretemp-isa-0000/Core 0  temp  61.5   crit=100 max=80  false\n" +
+				"dell_smm-isa-0000/fan1    fan   2400   -                -\n",
+		},
+		{
+			format: FormatCSV,
+			want: "" +
+				"id,kind,input,limits,alarm\n" +
+				"coretemp-isa-0000/Core 0,temp,61.5,crit=100 max=80,false\n" +
+				"dell_smm-isa-0000/fan1,fan,2400,,\n",
+		},
+		{
+			format: FormatJSON,
+			want: `[
+  {
+    "id": "coretemp-isa-0000/Core 0",
+    "kind": "temp",
+    "input": 61.5,
+    "limits": {
+      "crit": 100,
+      "max": 80
+    },
+    "alarm": false
+  },
+  {
+    "id": "dell_smm-isa-0000/fan1",
+    "kind": "fan",
+    "input": 2400,
+    "limits": {},
+    "alarm": null
+  }
+]
+`,
+		},
+		{
+			format: FormatYAML,
+			want: `- id: coretemp-isa-0000/Core 0
+  kind: temp
+  input: 61.5
+  limits:
+    crit: 100
+    max: 80
+  alarm: false
+- id: dell_smm-isa-0000/fan1
+  kind: fan
+  input: 2400
+  limits: {}
+  alarm: null
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			t.Parallel()
+
+			var b bytes.Buffer
+			require.NoError(t, Write(&b, tt.format, testTable()))
+			require.Equal(t, tt.want, b.String())
+		})
+	}
+}
+
+func TestWrite_NaN(t *testing.T) {
+	t.Parallel()
+
+	table := NewTable("id", "input")
+	table.Add("acpitz-acpi-0/temp1", math.NaN())
+
+	var b bytes.Buffer
+	require.NoError(t, Write(&b, FormatJSON, table))
+	require.JSONEq(t, `[{"id": "acpitz-acpi-0/temp1", "input": null}]`, b.String())
+}
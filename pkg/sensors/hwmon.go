@@ -0,0 +1,348 @@
+package sensors
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// kindOrder is the order libsensors reports the features of a chip in.
+var kindOrder = []Kind{
+	KindVoltage,
+	KindFan,
+	KindTemperature,
+	KindPower,
+	KindEnergy,
+	KindCurrent,
+	KindHumidity,
+}
+
+// Hwmon is a source that reads chips directly from the kernel hwmon sysfs interface, without shelling out to
+// lm-sensors.
+type Hwmon struct {
+	// sysfsRoot is the mount point of sysfs, normally "/sys".
+	sysfsRoot string
+}
+
+// NewHwmon creates a new hwmon source that reads from the given sysfs root.
+func NewHwmon(sysfsRoot string) *Hwmon {
+	return &Hwmon{
+		sysfsRoot: sysfsRoot,
+	}
+}
+
+// Name returns the name of the source.
+func (h *Hwmon) Name() string {
+	return "hwmon"
+}
+
+// Read reads every chip under /sys/class/hwmon. A chip that cannot be read is skipped and reported in the error.
+func (h *Hwmon) Read(ctx context.Context) ([]*Chip, error) {
+	dirs, err := filepath.Glob(filepath.Join(h.sysfsRoot, "class", "hwmon", "hwmon*"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hwmon devices: %w", err)
+	}
+
+	if len(dirs) == 0 {
+		return nil, ErrNoChips
+	}
+
+	slices.SortFunc(dirs, compareNumericSuffix)
+
+	chips := make([]*Chip, 0, len(dirs))
+	errs := make([]error, 0)
+	for _, dir := range dirs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		chip, err := readHwmonChip(dir)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read %s: %w", filepath.Base(dir), err))
+			continue
+		}
+
+		if chip == nil {
+			continue
+		}
+
+		chips = append(chips, chip)
+	}
+
+	return chips, errors.Join(errs...)
+}
+
+// readHwmonChip reads a single hwmon device directory. A nil chip is returned if the device does not report a name,
+// which libsensors also ignores.
+func readHwmonChip(dir string) (*Chip, error) {
+	// Older drivers expose their attributes on the parent device rather than the hwmon class device.
+	attrDir := dir
+	name, err := readString(filepath.Join(attrDir, "name"))
+	if errors.Is(err, os.ErrNotExist) {
+		attrDir = filepath.Join(dir, "device")
+		name, err = readString(filepath.Join(attrDir, "name"))
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil //nolint:nilnil // Devices without a name are skipped.
+	} else if err != nil {
+		return nil, err
+	}
+
+	busID, adapter, err := hwmonBus(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	features, err := readHwmonFeatures(attrDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Chip{
+		Name:     name + "-" + busID,
+		Adapter:  adapter,
+		Features: features,
+	}, nil
+}
+
+// hwmonBus works out the bus part of the chip name and the adapter description the same way libsensors does.
+func hwmonBus(dir string) (busID, adapter string, err error) {
+	device, err := filepath.EvalSymlinks(filepath.Join(dir, "device"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "virtual-0", "Virtual device", nil
+	} else if err != nil {
+		return "", "", fmt.Errorf("failed to resolve device: %w", err)
+	}
+
+	subsystem, err := filepath.EvalSymlinks(filepath.Join(device, "subsystem"))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve device subsystem: %w", err)
+	}
+
+	devName := filepath.Base(device)
+	switch filepath.Base(subsystem) {
+	case "i2c":
+		var (
+			nr   int
+			addr int
+		)
+		if _, err := fmt.Sscanf(devName, "%d-%x", &nr, &addr); err != nil {
+			return "", "", fmt.Errorf("invalid i2c device name %q: %w", devName, err)
+		}
+
+		adapter, err := readString(filepath.Join(filepath.Dir(device), "name"))
+		if err != nil {
+			adapter = fmt.Sprintf("i2c-%d", nr)
+		}
+
+		return fmt.Sprintf("i2c-%d-%02x", nr, addr), adapter, nil
+	case "pci":
+		var domain, bus, slot, fn int
+		if _, err := fmt.Sscanf(devName, "%x:%x:%x.%x", &domain, &bus, &slot, &fn); err != nil {
+			return "", "", fmt.Errorf("invalid pci device name %q: %w", devName, err)
+		}
+
+		return fmt.Sprintf("pci-%04x", domain<<16+bus<<8+slot<<3+fn), "PCI adapter", nil
+	case "platform", "of_platform":
+		addr := 0
+		if _, after, ok := strings.Cut(devName, "."); ok {
+			if n, err := strconv.Atoi(after); err == nil {
+				addr = n
+			}
+		}
+
+		return fmt.Sprintf("isa-%04x", addr), "ISA adapter", nil
+	case "acpi":
+		return "acpi-0", "ACPI interface", nil
+	case "hid":
+		return "hid-0", "HID adapter", nil
+	case "scsi":
+		return "scsi-0", "SCSI adapter", nil
+	case "mdio_bus":
+		return "mdio-0", "MDIO adapter", nil
+	default:
+		return "virtual-0", "Virtual device", nil
+	}
+}
+
+// hwmonKey identifies a feature within a hwmon device.
+type hwmonKey struct {
+	kind  Kind
+	index int
+}
+
+// readHwmonFeatures reads every feature attribute in the given directory.
+func readHwmonFeatures(dir string) ([]*Feature, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attributes: %w", err)
+	}
+
+	features := make(map[hwmonKey]*Feature)
+	labels := make(map[hwmonKey]string)
+	pwm := make(map[int]float64)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		if index, ok := parsePWM(entry.Name()); ok {
+			if raw, err := readString(filepath.Join(dir, entry.Name())); err == nil {
+				if value, err := strconv.ParseFloat(raw, 64); err == nil {
+					pwm[index] = value
+				}
+			}
+			continue
+		}
+
+		kind, index, sf, ok := parseSubfeature(entry.Name())
+		if !ok || kind == KindUnknown {
+			continue
+		}
+
+		key := hwmonKey{kind: kind, index: index}
+		path := filepath.Join(dir, entry.Name())
+
+		if sf == "label" {
+			if label, err := readString(path); err == nil {
+				labels[key] = label
+			}
+			continue
+		}
+
+		raw, err := readString(path)
+		if err != nil {
+			// Drivers return errors such as EIO or ENODATA for attributes that are not currently readable.
+			continue
+		}
+
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			continue
+		}
+
+		feature, ok := features[key]
+		if !ok {
+			feature = &Feature{
+				Kind:   kind,
+				Values: make(map[Subfeature]float64),
+			}
+			features[key] = feature
+		}
+
+		feature.Values[sf] = value / hwmonDivisor(kind, sf)
+	}
+
+	// The duty cycle of a fan is reported on the fan, so that a fan that is driven but not spinning can be told.
+	for index, value := range pwm {
+		if feature, ok := features[hwmonKey{kind: KindFan, index: index}]; ok {
+			feature.Values[SubfeaturePWM] = value
+		}
+	}
+
+	keys := make([]hwmonKey, 0, len(features))
+	for key := range features {
+		keys = append(keys, key)
+	}
+
+	slices.SortFunc(keys, func(a, b hwmonKey) int {
+		if c := cmp.Compare(slices.Index(kindOrder, a.kind), slices.Index(kindOrder, b.kind)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.index, b.index)
+	})
+
+	result := make([]*Feature, 0, len(keys))
+	for _, key := range keys {
+		feature := features[key]
+		feature.Name = labels[key]
+		if feature.Name == "" {
+			feature.Name = string(key.kind) + strconv.Itoa(key.index)
+		}
+
+		result = append(result, feature)
+	}
+
+	return result, nil
+}
+
+// parsePWM returns the index of a pwm attribute such as "pwm1", which unlike other attributes has no subfeature.
+func parsePWM(name string) (int, bool) {
+	digits, ok := strings.CutPrefix(name, "pwm")
+	if !ok {
+		return 0, false
+	}
+
+	index, err := strconv.Atoi(digits)
+	if err != nil || index < 0 {
+		return 0, false
+	}
+
+	return index, true
+}
+
+// hwmonDivisor returns the divisor that converts a raw sysfs value into the unit reported by lm-sensors.
+func hwmonDivisor(kind Kind, sf Subfeature) float64 {
+	// Flags are reported as-is.
+	name := string(sf)
+	if strings.HasSuffix(name, "alarm") ||
+		strings.HasSuffix(name, "beep") ||
+		strings.HasSuffix(name, "fault") ||
+		name == "enable" ||
+		name == "type" {
+		return 1
+	}
+
+	// Intervals such as power1_average_interval are in milliseconds and are reported in seconds.
+	if strings.HasSuffix(name, "interval") {
+		return 1e3
+	}
+
+	switch kind {
+	case KindTemperature, KindVoltage, KindCurrent, KindHumidity:
+		// Millidegrees, millivolts, milliamps and milli-percent.
+		return 1e3
+	case KindPower, KindEnergy:
+		// Microwatts and microjoules.
+		return 1e6
+	default:
+		return 1
+	}
+}
+
+// readString reads a sysfs attribute and trims the trailing newline.
+func readString(path string) (string, error) {
+	b, err := os.ReadFile(path) //nolint:gosec // Paths are built from the sysfs root.
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
+
+// compareNumericSuffix orders paths such as "hwmon2" and "hwmon10" by their trailing number.
+func compareNumericSuffix(a, b string) int {
+	return cmp.Compare(numericSuffix(a), numericSuffix(b))
+}
+
+// numericSuffix returns the number at the end of the base name of the path, or -1 if there is none.
+func numericSuffix(path string) int {
+	base := filepath.Base(path)
+	i := len(base)
+	for i > 0 && base[i-1] >= '0' && base[i-1] <= '9' {
+		i--
+	}
+
+	n, err := strconv.Atoi(base[i:])
+	if err != nil {
+		return -1
+	}
+
+	return n
+}
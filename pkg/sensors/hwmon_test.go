@@ -0,0 +1,224 @@
+package sensors
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeSysfs creates a fake sysfs tree under root. Values starting with "->" are created as symlinks.
+func writeSysfs(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+
+		if target, ok := strings.CutPrefix(content, "->"); ok {
+			require.NoError(t, os.Symlink(target, full))
+			continue
+		}
+
+		require.NoError(t, os.WriteFile(full, []byte(content+"\n"), 0o600))
+	}
+}
+
+// dellLatitudeSysfs is the hwmon tree for the chips in testdata/dell_latitude.json.
+var dellLatitudeSysfs = map[string]string{
+	"bus/platform/.keep": "",
+	"bus/pci/.keep":      "",
+	"bus/acpi/.keep":     "",
+
+	"devices/platform/coretemp.0/subsystem":                      "->../../../bus/platform",
+	"devices/pci0000:00/0000:e1:00.0/subsystem":                  "->../../../bus/pci",
+	"devices/LNXSYSTM:00/PNP0C0A:00/power_supply/BAT0/subsystem": "->../../../../../bus/acpi",
+
+	"class/hwmon/hwmon1/name":             "coretemp",
+	"class/hwmon/hwmon1/device":           "->../../../devices/platform/coretemp.0",
+	"class/hwmon/hwmon1/temp1_label":      "Package id 0",
+	"class/hwmon/hwmon1/temp1_input":      "61000",
+	"class/hwmon/hwmon1/temp1_max":        "100000",
+	"class/hwmon/hwmon1/temp1_crit":       "100000",
+	"class/hwmon/hwmon1/temp1_crit_alarm": "0",
+	"class/hwmon/hwmon1/temp2_label":      "Core 0",
+	"class/hwmon/hwmon1/temp2_input":      "55000",
+	"class/hwmon/hwmon1/temp2_max":        "100000",
+	"class/hwmon/hwmon1/temp2_crit":       "100000",
+	"class/hwmon/hwmon1/temp2_crit_alarm": "0",
+	"class/hwmon/hwmon1/temp3_label":      "Core 1",
+	"class/hwmon/hwmon1/temp3_input":      "57000",
+	"class/hwmon/hwmon1/temp3_max":        "100000",
+	"class/hwmon/hwmon1/temp3_crit":       "100000",
+	"class/hwmon/hwmon1/temp3_crit_alarm": "0",
+
+	"class/hwmon/hwmon10/name":        "nvme",
+	"class/hwmon/hwmon10/device":      "->../../../devices/pci0000:00/0000:e1:00.0",
+	"class/hwmon/hwmon10/temp1_label": "Composite",
+	"class/hwmon/hwmon10/temp1_input": "38850",
+	"class/hwmon/hwmon10/temp1_max":   "83850",
+	"class/hwmon/hwmon10/temp1_min":   "-40150",
+	"class/hwmon/hwmon10/temp1_crit":  "87850",
+	"class/hwmon/hwmon10/temp1_alarm": "0",
+
+	"class/hwmon/hwmon4/name":        "dell_smm",
+	"class/hwmon/hwmon4/fan1_input":  "2369",
+	"class/hwmon/hwmon4/fan1_min":    "0",
+	"class/hwmon/hwmon4/fan1_max":    "5000",
+	"class/hwmon/hwmon4/fan2_input":  "0",
+	"class/hwmon/hwmon4/fan2_min":    "0",
+	"class/hwmon/hwmon4/fan2_max":    "5000",
+	"class/hwmon/hwmon4/temp1_input": "60000",
+	"class/hwmon/hwmon4/pwm1":        "128",
+
+	"class/hwmon/hwmon5/name":        "BAT0",
+	"class/hwmon/hwmon5/device":      "->../../../devices/LNXSYSTM:00/PNP0C0A:00/power_supply/BAT0",
+	"class/hwmon/hwmon5/in0_input":   "17209",
+	"class/hwmon/hwmon5/curr1_input": "1",
+
+	// Devices without a name are ignored by libsensors.
+	"class/hwmon/hwmon6/temp1_input": "12000",
+}
+
+func TestHwmon_Read(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeSysfs(t, root, dellLatitudeSysfs)
+
+	got, err := NewHwmon(root).Read(t.Context())
+	require.NoError(t, err)
+
+	f, err := os.Open("testdata/dell_latitude.json")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, f.Close())
+	})
+
+	lmsensors, err := ParseLMSensors(f)
+	require.NoError(t, err)
+
+	want := make(map[string]*Chip, len(lmsensors))
+	for _, chip := range lmsensors {
+		want[chip.Name] = chip
+	}
+
+	// lm-sensors does not report the duty cycle fans are driven at.
+	want["dell_smm-virtual-0"].Features[0].Values[SubfeaturePWM] = 128
+
+	names := make([]string, 0, len(got))
+	for _, chip := range got {
+		names = append(names, chip.Name)
+	}
+	require.Equal(t, []string{
+		"coretemp-isa-0000",
+		"dell_smm-virtual-0",
+		"BAT0-acpi-0",
+		"nvme-pci-e100",
+	}, names)
+
+	for _, chip := range got {
+		require.Equal(t, want[chip.Name], chip, chip.Name)
+	}
+}
+
+func TestHwmon_Read_Power(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeSysfs(t, root, map[string]string{
+		"class/hwmon/hwmon0/name":                    "power_meter",
+		"class/hwmon/hwmon0/power1_input":            "12500000",
+		"class/hwmon/hwmon0/power1_average":          "11000000",
+		"class/hwmon/hwmon0/power1_average_interval": "1500",
+		"class/hwmon/hwmon0/energy1_input":           "3000000",
+	})
+
+	got, err := NewHwmon(root).Read(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []*Chip{{
+		Name:    "power_meter-virtual-0",
+		Adapter: "Virtual device",
+		Features: []*Feature{
+			{
+				Name: "power1",
+				Kind: KindPower,
+				Values: map[Subfeature]float64{
+					SubfeatureInput:    12.5,
+					"average":          11,
+					"average_interval": 1.5,
+				},
+			},
+			{
+				Name:   "energy1",
+				Kind:   KindEnergy,
+				Values: map[Subfeature]float64{SubfeatureInput: 3},
+			},
+		},
+	}}, got)
+}
+
+func TestHwmon_Read_NoChips(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewHwmon(t.TempDir()).Read(t.Context())
+	require.ErrorIs(t, err, ErrNoChips)
+}
+
+func TestHwmon_Read_BrokenChip(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeSysfs(t, root, dellLatitudeSysfs)
+	writeSysfs(t, root, map[string]string{
+		"class/hwmon/hwmon7/name":          "broken",
+		"class/hwmon/hwmon7/device":        "->../../../devices/platform/broken.0",
+		"devices/platform/broken.0/uevent": "",
+	})
+
+	// The chip whose device has no subsystem is reported, and every other chip is still read.
+	got, err := NewHwmon(root).Read(t.Context())
+	require.ErrorContains(t, err, "failed to read hwmon7: failed to resolve device subsystem")
+	require.Len(t, got, 4)
+}
+
+func TestHwmon_Read_Canceled(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeSysfs(t, root, dellLatitudeSysfs)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := NewHwmon(root).Read(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestHwmonBus(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeSysfs(t, root, map[string]string{
+		"bus/i2c/.keep":                        "",
+		"devices/i2c-3/name":                   "SMBus I801 adapter at efa0",
+		"devices/i2c-3/3-004c/subsystem":       "->../../../bus/i2c",
+		"class/hwmon/hwmon0/device":            "->../../../devices/i2c-3/3-004c",
+		"bus/platform/.keep":                   "",
+		"devices/platform/it87.2592/subsystem": "->../../../bus/platform",
+		"class/hwmon/hwmon1/device":            "->../../../devices/platform/it87.2592",
+	})
+
+	busID, adapter, err := hwmonBus(filepath.Join(root, "class/hwmon/hwmon0"))
+	require.NoError(t, err)
+	require.Equal(t, "i2c-3-4c", busID)
+	require.Equal(t, "SMBus I801 adapter at efa0", adapter)
+
+	busID, adapter, err = hwmonBus(filepath.Join(root, "class/hwmon/hwmon1"))
+	require.NoError(t, err)
+	require.Equal(t, "isa-0a20", busID)
+	require.Equal(t, "ISA adapter", adapter)
+}
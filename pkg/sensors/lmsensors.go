@@ -0,0 +1,185 @@
+package sensors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+)
+
+const (
+	// adapterKey is the key lm-sensors uses for the adapter of a chip.
+	adapterKey = "Adapter"
+
+	// waitDelay is how long the output of a killed `sensors` process is waited for, in case a process it started
+	// holds it open.
+	waitDelay = time.Second
+)
+
+// LMSensors is a source that reads chips by running the lm-sensors `sensors` binary.
+type LMSensors struct{}
+
+// NewLMSensors creates a new lm-sensors source.
+func NewLMSensors() *LMSensors {
+	return new(LMSensors)
+}
+
+// Name returns the name of the source.
+func (s *LMSensors) Name() string {
+	return "lm-sensors"
+}
+
+// Read runs `sensors -j` and parses its output. The command is killed if it is still running once the context is
+// done.
+func (s *LMSensors) Read(ctx context.Context) ([]*Chip, error) {
+	cmd := exec.CommandContext(ctx, "sensors", "-j")
+	cmd.WaitDelay = waitDelay
+	output, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to execute sensors command: %w", err)
+	}
+
+	chips, err := ParseLMSensors(bytes.NewReader(output))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode sensors output: %w", err)
+	}
+
+	return chips, nil
+}
+
+// ParseLMSensors parses the JSON output of `sensors -j`. Chips and features are returned in the order they appear in
+// the document.
+func ParseLMSensors(r io.Reader) ([]*Chip, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	chips := make([]*Chip, 0)
+	for dec.More() {
+		name, err := readKey(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		chip, err := parseChip(dec, name)
+		if err != nil {
+			return nil, fmt.Errorf("chip %q: %w", name, err)
+		}
+
+		chips = append(chips, chip)
+	}
+
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+
+	return chips, nil
+}
+
+// parseChip parses the object holding the adapter and features of a single chip.
+func parseChip(dec *json.Decoder, name string) (*Chip, error) {
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	chip := &Chip{
+		Name:     name,
+		Features: make([]*Feature, 0),
+	}
+
+	for dec.More() {
+		key, err := readKey(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		if key == adapterKey {
+			if err := dec.Decode(&chip.Adapter); err != nil {
+				return nil, fmt.Errorf("adapter: %w", err)
+			}
+			continue
+		}
+
+		feature, err := parseFeature(dec, key)
+		if err != nil {
+			return nil, fmt.Errorf("feature %q: %w", key, err)
+		}
+
+		chip.Features = append(chip.Features, feature)
+	}
+
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+
+	return chip, nil
+}
+
+// parseFeature parses the object of subfeature values for a single feature.
+func parseFeature(dec *json.Decoder, name string) (*Feature, error) {
+	raw := make(map[string]float64)
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	feature := &Feature{
+		Name:   name,
+		Kind:   KindUnknown,
+		Values: make(map[Subfeature]float64, len(raw)),
+	}
+
+	for key, value := range raw {
+		kind, _, sf, ok := parseSubfeature(key)
+		if !ok {
+			continue
+		}
+
+		if feature.Kind == KindUnknown {
+			feature.Kind = kind
+		}
+
+		feature.Values[sf] = value
+	}
+
+	return feature, nil
+}
+
+// readKey reads the next object key from the decoder.
+func readKey(dec *json.Decoder) (string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+
+	key, ok := tok.(string)
+	if !ok {
+		return "", fmt.Errorf("expected object key, got %v", tok)
+	}
+
+	return key, nil
+}
+
+// expectDelim reads the next token from the decoder and ensures it is the given delimiter.
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("unexpected end of input, expected %q", want)
+		}
+		return err
+	}
+
+	if got, ok := tok.(json.Delim); !ok || got != want {
+		return fmt.Errorf("expected %q, got %v", want, tok)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package sensors
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLMSensors(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open("testdata/dell_latitude.json")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, f.Close())
+	})
+
+	chips, err := ParseLMSensors(f)
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(chips))
+	for _, chip := range chips {
+		names = append(names, chip.Name)
+	}
+	require.Equal(t, []string{
+		"coretemp-isa-0000",
+		"dell_ddv-virtual-0",
+		"ucsi_source_psy_USBC000:002-isa-0000",
+		"nvme-pci-e100",
+		"iwlwifi_1-virtual-0",
+		"dell_smm-virtual-0",
+		"BAT0-acpi-0",
+	}, names)
+
+	coretemp := chips[0]
+	require.Equal(t, "ISA adapter", coretemp.Adapter)
+	require.Len(t, coretemp.Features, 3)
+	require.Equal(t, &Feature{
+		Name: "Package id 0",
+		Kind: KindTemperature,
+		Values: map[Subfeature]float64{
+			SubfeatureInput:     61,
+			SubfeatureMax:       100,
+			SubfeatureCrit:      100,
+			SubfeatureCritAlarm: 0,
+		},
+	}, coretemp.Features[0])
+
+	ddv := chips[1]
+	require.Equal(t, "CPU Fan", ddv.Features[0].Name)
+	require.Equal(t, KindFan, ddv.Features[0].Kind)
+
+	usbc := chips[2]
+	require.Equal(t, KindVoltage, usbc.Features[0].Kind)
+	require.Equal(t, KindCurrent, usbc.Features[1].Kind)
+
+	nvme := chips[3]
+	crit, ok := nvme.Features[0].Value(SubfeatureCrit)
+	require.True(t, ok)
+	require.InDelta(t, 87.85, crit, 0.001)
+}
+
+// TestLMSensors_Read_Timeout runs a `sensors` that hangs with a child holding its output open. It is not parallel as it
+// changes PATH.
+func TestLMSensors_Read_Timeout(t *testing.T) {
+	dir := t.TempDir()
+	script := []byte("#!/bin/sh\nsleep 30\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sensors"), script, 0o700)) //nolint:gosec // Must be executable.
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := NewLMSensors().Read(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestParseLMSensors_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "empty input",
+			input: "",
+		},
+		{
+			name:  "not an object",
+			input: "[]",
+		},
+		{
+			name:  "feature is not an object",
+			input: `{"chip-0": {"Adapter": "ISA adapter", "temp1": 12}}`,
+		},
+		{
+			name:  "truncated",
+			input: `{"chip-0": {"Adapter": "ISA adapter"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := ParseLMSensors(strings.NewReader(test.input))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestParseSubfeature(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		key       string
+		wantKind  Kind
+		wantIndex int
+		wantSub   Subfeature
+		wantOK    bool
+	}{
+		{key: "temp1_input", wantKind: KindTemperature, wantIndex: 1, wantSub: SubfeatureInput, wantOK: true},
+		{key: "temp12_crit_alarm", wantKind: KindTemperature, wantIndex: 12, wantSub: SubfeatureCritAlarm, wantOK: true},
+		{key: "fan2_min", wantKind: KindFan, wantIndex: 2, wantSub: SubfeatureMin, wantOK: true},
+		{key: "in0_max", wantKind: KindVoltage, wantIndex: 0, wantSub: SubfeatureMax, wantOK: true},
+		{key: "curr1_input", wantKind: KindCurrent, wantIndex: 1, wantSub: SubfeatureInput, wantOK: true},
+		{key: "power1_average", wantKind: KindPower, wantIndex: 1, wantSub: "average", wantOK: true},
+		{key: "energy1_input", wantKind: KindEnergy, wantIndex: 1, wantSub: SubfeatureInput, wantOK: true},
+		{key: "humidity1_input", wantKind: KindHumidity, wantIndex: 1, wantSub: SubfeatureInput, wantOK: true},
+		{key: "intrusion0_alarm", wantKind: KindUnknown, wantIndex: 0, wantSub: SubfeatureAlarm, wantOK: true},
+		{key: "Adapter", wantOK: false},
+		{key: "temp_input", wantOK: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			t.Parallel()
+			kind, index, sub, ok := parseSubfeature(test.key)
+			require.Equal(t, test.wantOK, ok)
+			require.Equal(t, test.wantKind, kind)
+			require.Equal(t, test.wantIndex, index)
+			require.Equal(t, test.wantSub, sub)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package sensors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBackoff is returned by a Retrying source when it is read while it is waiting to retry a source that failed.
+var ErrBackoff = errors.New("waiting to retry")
+
+// Status is how well a source is being read.
+type Status string
+
+const (
+	// StatusOK means every chip of the source was read.
+	StatusOK Status = "ok"
+
+	// StatusPartial means the source returned chips, but some of its chips could not be read.
+	StatusPartial Status = "partial"
+
+	// StatusFailing means no chips of the source could be read.
+	StatusFailing Status = "failing"
+)
+
+// Health is the health of a source as of its latest read.
+type Health struct {
+	// Source is the name of the source.
+	Source string
+
+	// ConsecutiveFailures is how many reads in a row returned no chips. A partial read resets it.
+	ConsecutiveFailures int
+
+	// Err is the error of the latest read, or nil if every chip was read. It is kept after a partial read.
+	Err error
+
+	// LastSuccess is when the source last returned any chips, including after a partial read.
+	LastSuccess time.Time
+
+	// RetryAt is when a failing source is read again. It is zero unless the source is failing.
+	RetryAt time.Time
+}
+
+// Status returns the status of the source.
+func (h *Health) Status() Status {
+	switch {
+	case h.ConsecutiveFailures > 0:
+		return StatusFailing
+	case h.Err != nil:
+		return StatusPartial
+	default:
+		return StatusOK
+	}
+}
+
+// Retrying is a source that backs off from a source that fails, so that a broken source is not hammered on every poll,
+// and tracks its health.
+type Retrying struct {
+	source Source
+
+	// backoff is how long the source is left after its first failure. It doubles with every failure in a row, up to
+	// maxBackoff.
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	// now tells the time. It is replaced in tests.
+	now func() time.Time
+
+	health Health
+}
+
+// NewRetrying creates a source that reads the given source, leaving it for the backoff after a failure, doubling up to
+// the maximum backoff with every failure in a row.
+func NewRetrying(source Source, backoff, maxBackoff time.Duration) *Retrying {
+	return &Retrying{
+		source:     source,
+		backoff:    backoff,
+		maxBackoff: maxBackoff,
+		now:        time.Now,
+		health:     Health{Source: source.Name()},
+	}
+}
+
+// Name returns the name of the retried source.
+func (r *Retrying) Name() string {
+	return r.source.Name()
+}
+
+// Read reads the source, unless it is still backing off after failing, in which case ErrBackoff is returned along with
+// the error it last failed with.
+//
+// A read is a success if it returns any chips, and a failure if it returns none, whether or not the source returned an
+// error; a source returning no chips without an error fails with ErrNoChips. A partial read, returning chips along
+// with an error for those that could not be read, is a success: it resets the failures and the backoff and sets the
+// last success, while its error is returned and kept in the health of the source.
+func (r *Retrying) Read(ctx context.Context) ([]*Chip, error) {
+	if now := r.now(); now.Before(r.health.RetryAt) {
+		return nil, fmt.Errorf("%w until %s: %w", ErrBackoff, r.health.RetryAt.Format(time.TimeOnly), r.health.Err)
+	}
+
+	chips, err := r.source.Read(ctx)
+	if len(chips) == 0 && err == nil {
+		err = ErrNoChips
+	}
+
+	now := r.now()
+	r.health.Err = err
+	if len(chips) == 0 {
+		r.health.ConsecutiveFailures++
+		r.health.RetryAt = now.Add(r.delay())
+		return nil, err
+	}
+
+	r.health.ConsecutiveFailures = 0
+	r.health.RetryAt = time.Time{}
+	r.health.LastSuccess = now
+	return chips, err
+}
+
+// Health returns a copy of the health of the source.
+func (r *Retrying) Health() *Health {
+	h := r.health
+	return &h
+}
+
+// delay returns how long the source is left after the failures in a row so far.
+func (r *Retrying) delay() time.Duration {
+	d := r.backoff
+	for i := 1; i < r.health.ConsecutiveFailures && d < r.maxBackoff; i++ {
+		d *= 2
+	}
+
+	return min(d, r.maxBackoff)
+}
+
+// HealthOf returns the health of the source, or of every source it combines. Sources that do not track their health
+// are left out.
+func HealthOf(s Source) []*Health {
+	switch s := s.(type) {
+	case *Retrying:
+		return []*Health{s.Health()}
+	case *Multi:
+		return s.Health()
+	default:
+		return nil
+	}
+}
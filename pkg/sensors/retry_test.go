@@ -0,0 +1,174 @@
+package sensors
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSource returns the chips and error it is given, counting its reads.
+type fakeSource struct {
+	name  string
+	chips []*Chip
+	err   error
+	reads int
+}
+
+func (s *fakeSource) Name() string {
+	return s.name
+}
+
+func (s *fakeSource) Read(context.Context) ([]*Chip, error) {
+	s.reads++
+	return s.chips, s.err
+}
+
+func TestRetrying_Read(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	source := &fakeSource{name: "lm-sensors", err: errors.New("exit status 1")}
+	r := NewRetrying(source, time.Second, 3*time.Second)
+	r.now = func() time.Time { return now }
+
+	read := func(d time.Duration) error {
+		now = now.Add(d)
+		_, err := r.Read(t.Context())
+		return err
+	}
+
+	// Every failure in a row doubles how long the source is left, up to the maximum.
+	require.EqualError(t, read(0), "exit status 1")
+	require.Equal(t, StatusFailing, r.Health().Status())
+	require.Equal(t, now.Add(time.Second), r.Health().RetryAt)
+
+	require.ErrorIs(t, read(500*time.Millisecond), ErrBackoff)
+	require.Equal(t, 1, source.reads)
+
+	require.Error(t, read(500*time.Millisecond))
+	require.Equal(t, now.Add(2*time.Second), r.Health().RetryAt)
+	require.Error(t, read(2*time.Second))
+	require.Equal(t, now.Add(3*time.Second), r.Health().RetryAt)
+	require.Error(t, read(3*time.Second))
+	require.Equal(t, now.Add(3*time.Second), r.Health().RetryAt)
+	require.Equal(t, 4, r.Health().ConsecutiveFailures)
+	require.Equal(t, 4, source.reads)
+
+	// Some chips failing is not a failure of the source.
+	source.chips = []*Chip{{Name: "coretemp-isa-0000"}}
+	source.err = errors.New("failed to read hwmon7")
+	require.Error(t, read(3*time.Second))
+	require.Equal(t, &Health{
+		Source:      "lm-sensors",
+		Err:         source.err,
+		LastSuccess: now,
+	}, r.Health())
+	require.Equal(t, StatusPartial, r.Health().Status())
+
+	source.err = nil
+	require.NoError(t, read(0))
+	require.Equal(t, StatusOK, r.Health().Status())
+}
+
+func TestRetrying_Read_Partial(t *testing.T) {
+	t.Parallel()
+
+	chips := []*Chip{{Name: "coretemp-isa-0000"}}
+	failed := errors.New("failed to read hwmon7")
+
+	tests := []struct {
+		name         string
+		chips        []*Chip
+		err          error
+		wantErr      error
+		wantStatus   Status
+		wantFailures int
+		wantSuccess  bool
+	}{
+		{
+			name:        "every chip",
+			chips:       chips,
+			wantStatus:  StatusOK,
+			wantSuccess: true,
+		},
+		{
+			name:        "some chips",
+			chips:       chips,
+			err:         failed,
+			wantErr:     failed,
+			wantStatus:  StatusPartial,
+			wantSuccess: true,
+		},
+		{
+			name:         "no chips",
+			err:          failed,
+			wantErr:      failed,
+			wantStatus:   StatusFailing,
+			wantFailures: 2,
+		},
+		{
+			name:         "no chips without an error",
+			wantErr:      ErrNoChips,
+			wantStatus:   StatusFailing,
+			wantFailures: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			// The source has already failed once, and last succeeded an hour ago.
+			start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+			now := start.Add(time.Hour)
+			source := &fakeSource{name: "hwmon", chips: test.chips, err: test.err}
+			r := NewRetrying(source, time.Second, time.Minute)
+			r.now = func() time.Time { return now }
+			r.health.ConsecutiveFailures = 1
+			r.health.Err = errors.New("exit status 1")
+			r.health.LastSuccess = start
+
+			got, err := r.Read(t.Context())
+			if test.wantErr != nil {
+				require.ErrorIs(t, err, test.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			health := r.Health()
+			require.Equal(t, test.wantStatus, health.Status())
+			require.Equal(t, test.wantFailures, health.ConsecutiveFailures)
+			require.Equal(t, test.wantErr, health.Err)
+			if test.wantSuccess {
+				require.Equal(t, chips, got)
+				require.Equal(t, now, health.LastSuccess)
+				require.Zero(t, health.RetryAt)
+			} else {
+				require.Empty(t, got)
+				require.Equal(t, start, health.LastSuccess)
+				require.Equal(t, now.Add(2*time.Second), health.RetryAt)
+			}
+		})
+	}
+}
+
+func TestMulti_Read(t *testing.T) {
+	t.Parallel()
+
+	hwmon := &fakeSource{name: "hwmon", chips: []*Chip{{Name: "coretemp-isa-0000"}}}
+	lmsensors := &fakeSource{name: "lm-sensors", err: errors.New("exit status 1")}
+	m := NewMulti(NewRetrying(lmsensors, time.Second, time.Minute), hwmon)
+
+	// A failing source does not stop the others from being read.
+	chips, err := m.Read(t.Context())
+	require.EqualError(t, err, "failed to read lm-sensors: exit status 1")
+	require.Equal(t, hwmon.chips, chips)
+
+	health := HealthOf(m)
+	require.Len(t, health, 1)
+	require.Equal(t, "lm-sensors", health[0].Source)
+	require.Equal(t, 1, health[0].ConsecutiveFailures)
+}
@@ -0,0 +1,197 @@
+package sensors
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// Kind is the type of measurement reported by a sensor feature.
+type Kind string
+
+const (
+	// KindUnknown is used for features whose subfeature prefix is not recognised.
+	KindUnknown Kind = ""
+
+	// KindTemperature is a temperature in degrees Celsius.
+	KindTemperature Kind = "temp"
+
+	// KindFan is a fan speed in revolutions per minute.
+	KindFan Kind = "fan"
+
+	// KindVoltage is a voltage in volts.
+	KindVoltage Kind = "in"
+
+	// KindCurrent is a current in amps.
+	KindCurrent Kind = "curr"
+
+	// KindPower is a power draw in watts.
+	KindPower Kind = "power"
+
+	// KindEnergy is an energy total in joules.
+	KindEnergy Kind = "energy"
+
+	// KindHumidity is a relative humidity percentage.
+	KindHumidity Kind = "humidity"
+
+	// KindCooling is the current state of a kernel cooling device, such as a fan level or a CPU frequency step.
+	KindCooling Kind = "cooling"
+)
+
+// kinds is every known kind, used to recognise subfeature prefixes.
+var kinds = []Kind{
+	KindTemperature,
+	KindFan,
+	KindVoltage,
+	KindCurrent,
+	KindPower,
+	KindEnergy,
+	KindHumidity,
+}
+
+// Kinds returns every known kind.
+func Kinds() []Kind {
+	return append(slices.Clone(kinds), KindCooling)
+}
+
+// Unit returns the unit that values of the kind are reported in.
+func (k Kind) Unit() string {
+	switch k {
+	case KindTemperature:
+		return "°C"
+	case KindFan:
+		return "RPM"
+	case KindVoltage:
+		return "V"
+	case KindCurrent:
+		return "A"
+	case KindPower:
+		return "W"
+	case KindEnergy:
+		return "J"
+	case KindHumidity:
+		return "%RH"
+	case KindCooling:
+		return "state"
+	default:
+		return ""
+	}
+}
+
+// String returns the kind as it appears in subfeature names.
+func (k Kind) String() string {
+	if k == KindUnknown {
+		return "unknown"
+	}
+	return string(k)
+}
+
+// Format formats a value of the kind with its unit, e.g. "61.0°C" or "2400 RPM".
+func (k Kind) Format(v float64) string {
+	switch k {
+	case KindTemperature:
+		return strconv.FormatFloat(v, 'f', 1, 64) + k.Unit()
+	case KindFan, KindCooling:
+		return strconv.FormatFloat(v, 'f', 0, 64) + " " + k.Unit()
+	case KindUnknown:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return strconv.FormatFloat(v, 'f', 2, 64) + " " + k.Unit()
+	}
+}
+
+// Subfeature is the name of a single value of a feature, with the kind and index prefix removed (e.g. "input" for
+// "temp1_input").
+type Subfeature string
+
+const (
+	// SubfeatureInput is the current measured value.
+	SubfeatureInput Subfeature = "input"
+
+	// SubfeatureMin is the lower limit reported by the hardware.
+	SubfeatureMin Subfeature = "min"
+
+	// SubfeatureMax is the upper limit reported by the hardware.
+	SubfeatureMax Subfeature = "max"
+
+	// SubfeatureCrit is the critical limit reported by the hardware.
+	SubfeatureCrit Subfeature = "crit"
+
+	// SubfeatureAlarm is set to a non-zero value when the hardware has raised an alarm.
+	SubfeatureAlarm Subfeature = "alarm"
+
+	// SubfeatureCritAlarm is set to a non-zero value when the critical limit has been reached.
+	SubfeatureCritAlarm Subfeature = "crit_alarm"
+
+	// SubfeaturePassive is the kernel trip point at which passive cooling (throttling) starts.
+	SubfeaturePassive Subfeature = "passive"
+
+	// SubfeatureHot is the kernel trip point at which the platform is notified that the zone is hot.
+	SubfeatureHot Subfeature = "hot"
+
+	// SubfeaturePWM is the duty cycle a fan is driven at, from 0 to 255. It is only reported by the hwmon source, for
+	// fans with a pwm attribute of the same index.
+	SubfeaturePWM Subfeature = "pwm"
+)
+
+// subfeaturePattern splits a subfeature key such as "temp1_crit_alarm" into its kind, index and name.
+var subfeaturePattern = regexp.MustCompile(`^([a-z]+)(\d+)_([a-z_]+)$`)
+
+// parseSubfeature splits a raw subfeature key into its kind, index and subfeature name. The returned bool is false
+// if the key is not in the expected format.
+func parseSubfeature(key string) (Kind, int, Subfeature, bool) {
+	m := subfeaturePattern.FindStringSubmatch(key)
+	if m == nil {
+		return KindUnknown, 0, "", false
+	}
+
+	index, err := strconv.Atoi(m[2])
+	if err != nil {
+		return KindUnknown, 0, "", false
+	}
+
+	kind := KindUnknown
+	for _, k := range kinds {
+		if string(k) == m[1] {
+			kind = k
+			break
+		}
+	}
+
+	return kind, index, Subfeature(m[3]), true
+}
+
+// Chip is a single sensor chip, e.g. "coretemp-isa-0000".
+type Chip struct {
+	// Name is the name of the chip.
+	Name string
+
+	// Adapter is the bus adapter the chip is attached to, e.g. "ISA adapter".
+	Adapter string
+
+	// Features are the features of the chip in the order they were reported.
+	Features []*Feature
+}
+
+// Feature is a single measurement on a chip, e.g. "Core 0".
+type Feature struct {
+	// Name is the label of the feature.
+	Name string
+
+	// Kind is the type of measurement, inferred from the subfeature prefix.
+	Kind Kind
+
+	// Values holds the subfeature values of the feature.
+	Values map[Subfeature]float64
+}
+
+// Value returns the given subfeature value and whether it was reported.
+func (f *Feature) Value(sf Subfeature) (float64, bool) {
+	v, ok := f.Values[sf]
+	return v, ok
+}
+
+// Input returns the current measured value of the feature and whether it was reported.
+func (f *Feature) Input() (float64, bool) {
+	return f.Value(SubfeatureInput)
+}
@@ -0,0 +1,49 @@
+package sensors
+
+import (
+	"time"
+)
+
+// Reading is a single feature of a chip, flattened so that it can be addressed on its own.
+type Reading struct {
+	*Feature
+
+	// Chip is the name of the chip the feature belongs to.
+	Chip string
+
+	// Adapter is the bus adapter of the chip.
+	Adapter string
+}
+
+// ID returns the identifier of the reading in the form "chip/feature", e.g. "coretemp-isa-0000/Core 0".
+func (r *Reading) ID() string {
+	return r.Chip + "/" + r.Name
+}
+
+// Snapshot is the set of readings taken from every chip at a single point in time.
+type Snapshot struct {
+	// Time is when the snapshot was taken.
+	Time time.Time
+
+	// Readings are the readings of every feature of every chip, in the order they were reported.
+	Readings []*Reading
+}
+
+// NewSnapshot flattens the given chips into a snapshot taken at the given time.
+func NewSnapshot(at time.Time, chips []*Chip) *Snapshot {
+	readings := make([]*Reading, 0)
+	for _, chip := range chips {
+		for _, feature := range chip.Features {
+			readings = append(readings, &Reading{
+				Feature: feature,
+				Chip:    chip.Name,
+				Adapter: chip.Adapter,
+			})
+		}
+	}
+
+	return &Snapshot{
+		Time:     at,
+		Readings: readings,
+	}
+}
@@ -0,0 +1,75 @@
+package sensors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultSysfsRoot is the mount point of sysfs on a running host.
+const DefaultSysfsRoot = "/sys"
+
+// ErrNoChips is returned by a source when the host does not expose any chips through it.
+var ErrNoChips = errors.New("no sensor chips found")
+
+// Source is a provider of sensor chips.
+type Source interface {
+	// Name returns the name of the source.
+	Name() string
+
+	// Read reads the current values of every chip exposed by the source. It gives up once the context is done.
+	//
+	// A chip that cannot be read does not stop the others from being read: the chips that were read are returned
+	// along with an error describing those that were not. The read failed outright only if no chips are returned.
+	Read(ctx context.Context) ([]*Chip, error)
+}
+
+// Multi is a source that combines the chips of several sources.
+type Multi struct {
+	sources []Source
+}
+
+// NewMulti creates a new source that reads from each of the given sources in turn.
+func NewMulti(sources ...Source) *Multi {
+	return &Multi{
+		sources: sources,
+	}
+}
+
+// Name returns the names of the combined sources.
+func (m *Multi) Name() string {
+	names := make([]string, 0, len(m.sources))
+	for _, s := range m.sources {
+		names = append(names, s.Name())
+	}
+
+	return strings.Join(names, "+")
+}
+
+// Read reads the chips of every source, in the order the sources were given. A source failing does not stop the
+// others from being read.
+func (m *Multi) Read(ctx context.Context) ([]*Chip, error) {
+	chips := make([]*Chip, 0)
+	errs := make([]error, 0)
+	for _, s := range m.sources {
+		got, err := s.Read(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read %s: %w", s.Name(), err))
+		}
+
+		chips = append(chips, got...)
+	}
+
+	return chips, errors.Join(errs...)
+}
+
+// Health returns the health of every source that tracks it, in the order the sources were given.
+func (m *Multi) Health() []*Health {
+	health := make([]*Health, 0, len(m.sources))
+	for _, s := range m.sources {
+		health = append(health, HealthOf(s)...)
+	}
+
+	return health
+}
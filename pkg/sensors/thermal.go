@@ -0,0 +1,241 @@
+package sensors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Thermal is a source that reads the kernel thermal zones and the cooling devices bound to them. It is useful on
+// hosts that do not expose any hwmon chips, such as many ARM boards and virtual machines.
+type Thermal struct {
+	// sysfsRoot is the mount point of sysfs, normally "/sys".
+	sysfsRoot string
+}
+
+// NewThermal creates a new thermal zone source that reads from the given sysfs root.
+func NewThermal(sysfsRoot string) *Thermal {
+	return &Thermal{
+		sysfsRoot: sysfsRoot,
+	}
+}
+
+// Name returns the name of the source.
+func (t *Thermal) Name() string {
+	return "thermal"
+}
+
+// Read reads every zone under /sys/class/thermal. Each zone is reported as a chip with a temperature feature, named
+// after the zone type, followed by a cooling feature for each bound cooling device. A zone that cannot be read is
+// skipped and reported in the error.
+func (t *Thermal) Read(ctx context.Context) ([]*Chip, error) {
+	dirs, err := filepath.Glob(filepath.Join(t.sysfsRoot, "class", "thermal", "thermal_zone*"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list thermal zones: %w", err)
+	}
+
+	if len(dirs) == 0 {
+		return nil, ErrNoChips
+	}
+
+	slices.SortFunc(dirs, compareNumericSuffix)
+
+	chips := make([]*Chip, 0, len(dirs))
+	errs := make([]error, 0)
+	for _, dir := range dirs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		chip, err := readThermalZone(dir)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read %s: %w", filepath.Base(dir), err))
+			continue
+		}
+
+		if chip == nil {
+			continue
+		}
+
+		chips = append(chips, chip)
+	}
+
+	return chips, errors.Join(errs...)
+}
+
+// readThermalZone reads a single thermal zone directory. A nil chip is returned if the zone temperature cannot be
+// read, which happens for disabled zones.
+func readThermalZone(dir string) (*Chip, error) {
+	zoneType, err := readString(filepath.Join(dir, "type"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read zone type: %w", err)
+	}
+
+	temp, err := readMilli(filepath.Join(dir, "temp"))
+	if err != nil {
+		return nil, nil //nolint:nilnil // Zones without a readable temperature are skipped.
+	}
+
+	trips, err := readTripPoints(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	zone := &Feature{
+		Name: zoneType,
+		Kind: KindTemperature,
+		Values: map[Subfeature]float64{
+			SubfeatureInput: temp,
+		},
+	}
+
+	for sf, value := range trips {
+		zone.Values[sf] = value
+	}
+
+	cooling, err := readCoolingDevices(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Chip{
+		Name:     filepath.Base(dir),
+		Adapter:  "Thermal zone",
+		Features: append([]*Feature{zone}, cooling...),
+	}, nil
+}
+
+// readTripPoints reads the passive, hot and critical trip points of a zone. If a zone has several trip points of the
+// same type, the lowest is used as that is the first one the kernel acts on.
+func readTripPoints(dir string) (map[Subfeature]float64, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "trip_point_*_type"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trip points: %w", err)
+	}
+
+	trips := make(map[Subfeature]float64)
+	for _, path := range paths {
+		tripType, err := readString(path)
+		if err != nil {
+			continue
+		}
+
+		var sf Subfeature
+		switch tripType {
+		case "passive":
+			sf = SubfeaturePassive
+		case "hot":
+			sf = SubfeatureHot
+		case "critical":
+			sf = SubfeatureCrit
+		default:
+			continue
+		}
+
+		temp, err := readMilli(strings.TrimSuffix(path, "_type") + "_temp")
+		if err != nil {
+			continue
+		}
+
+		if existing, ok := trips[sf]; ok && existing <= temp {
+			continue
+		}
+
+		trips[sf] = temp
+	}
+
+	return trips, nil
+}
+
+// readCoolingDevices reads the cooling devices bound to a zone through its cdev* links.
+func readCoolingDevices(dir string) ([]*Feature, error) {
+	links, err := filepath.Glob(filepath.Join(dir, "cdev*"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cooling devices: %w", err)
+	}
+
+	slices.SortFunc(links, compareNumericSuffix)
+
+	features := make([]*Feature, 0, len(links))
+	seen := make(map[string]bool, len(links))
+	for _, link := range links {
+		// Skip the cdevN_trip_point and cdevN_weight attributes.
+		if strings.Contains(filepath.Base(link), "_") {
+			continue
+		}
+
+		device, err := filepath.EvalSymlinks(link)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", filepath.Base(link), err)
+		}
+
+		// A cooling device can be bound to several trip points of the same zone.
+		if seen[device] {
+			continue
+		}
+		seen[device] = true
+
+		feature, err := readCoolingDevice(device)
+		if err != nil {
+			continue
+		}
+
+		features = append(features, feature)
+	}
+
+	return features, nil
+}
+
+// readCoolingDevice reads the type and state of a single cooling device.
+func readCoolingDevice(dir string) (*Feature, error) {
+	deviceType, err := readString(filepath.Join(dir, "type"))
+	if err != nil {
+		return nil, err
+	}
+
+	cur, err := readFloat(filepath.Join(dir, "cur_state"))
+	if err != nil {
+		return nil, err
+	}
+
+	maxState, err := readFloat(filepath.Join(dir, "max_state"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Feature{
+		Name: deviceType + " " + strconv.Itoa(numericSuffix(dir)),
+		Kind: KindCooling,
+		Values: map[Subfeature]float64{
+			SubfeatureInput: cur,
+			SubfeatureMax:   maxState,
+		},
+	}, nil
+}
+
+// readFloat reads a sysfs attribute holding a single number.
+func readFloat(path string) (float64, error) {
+	raw, err := readString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseFloat(raw, 64)
+}
+
+// readMilli reads a sysfs attribute holding a value in thousandths, such as millidegrees Celsius.
+func readMilli(path string) (float64, error) {
+	v, err := readFloat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return v / 1e3, nil
+}
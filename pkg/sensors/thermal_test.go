@@ -0,0 +1,107 @@
+package sensors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestThermal_Read(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeSysfs(t, root, map[string]string{
+		"devices/virtual/thermal/cooling_device0/type":      "Processor",
+		"devices/virtual/thermal/cooling_device0/cur_state": "0",
+		"devices/virtual/thermal/cooling_device0/max_state": "3",
+		"devices/virtual/thermal/cooling_device1/type":      "pwm-fan",
+		"devices/virtual/thermal/cooling_device1/cur_state": "2",
+		"devices/virtual/thermal/cooling_device1/max_state": "4",
+
+		"class/thermal/thermal_zone0/type":               "cpu-thermal",
+		"class/thermal/thermal_zone0/temp":               "47236",
+		"class/thermal/thermal_zone0/trip_point_0_type":  "active",
+		"class/thermal/thermal_zone0/trip_point_0_temp":  "50000",
+		"class/thermal/thermal_zone0/trip_point_1_type":  "passive",
+		"class/thermal/thermal_zone0/trip_point_1_temp":  "85000",
+		"class/thermal/thermal_zone0/trip_point_2_type":  "passive",
+		"class/thermal/thermal_zone0/trip_point_2_temp":  "80000",
+		"class/thermal/thermal_zone0/trip_point_3_type":  "hot",
+		"class/thermal/thermal_zone0/trip_point_3_temp":  "95000",
+		"class/thermal/thermal_zone0/trip_point_4_type":  "critical",
+		"class/thermal/thermal_zone0/trip_point_4_temp":  "110000",
+		"class/thermal/thermal_zone0/cdev0":              "->../../../devices/virtual/thermal/cooling_device1",
+		"class/thermal/thermal_zone0/cdev0_trip_point":   "0",
+		"class/thermal/thermal_zone0/cdev1":              "->../../../devices/virtual/thermal/cooling_device0",
+		"class/thermal/thermal_zone0/cdev1_trip_point":   "1",
+		"class/thermal/thermal_zone0/cdev2":              "->../../../devices/virtual/thermal/cooling_device0",
+		"class/thermal/thermal_zone0/cdev2_trip_point":   "2",
+		"class/thermal/thermal_zone0/cdev2_weight":       "0",
+		"class/thermal/thermal_zone0/passive_delay_ms":   "250",
+		"class/thermal/thermal_zone0/available_policies": "step_wise",
+
+		// Disabled zones report an error when the temperature is read.
+		"class/thermal/thermal_zone1/type": "iwlwifi_1",
+
+		"class/thermal/thermal_zone2/type": "acpitz",
+		"class/thermal/thermal_zone2/temp": "27800",
+	})
+
+	chips, err := NewThermal(root).Read(t.Context())
+	require.NoError(t, err)
+
+	require.Equal(t, []*Chip{
+		{
+			Name:    "thermal_zone0",
+			Adapter: "Thermal zone",
+			Features: []*Feature{
+				{
+					Name: "cpu-thermal",
+					Kind: KindTemperature,
+					Values: map[Subfeature]float64{
+						SubfeatureInput:   47.236,
+						SubfeaturePassive: 80,
+						SubfeatureHot:     95,
+						SubfeatureCrit:    110,
+					},
+				},
+				{
+					Name: "pwm-fan 1",
+					Kind: KindCooling,
+					Values: map[Subfeature]float64{
+						SubfeatureInput: 2,
+						SubfeatureMax:   4,
+					},
+				},
+				{
+					Name: "Processor 0",
+					Kind: KindCooling,
+					Values: map[Subfeature]float64{
+						SubfeatureInput: 0,
+						SubfeatureMax:   3,
+					},
+				},
+			},
+		},
+		{
+			Name:    "thermal_zone2",
+			Adapter: "Thermal zone",
+			Features: []*Feature{
+				{
+					Name: "acpitz",
+					Kind: KindTemperature,
+					Values: map[Subfeature]float64{
+						SubfeatureInput: 27.8,
+					},
+				},
+			},
+		},
+	}, chips)
+}
+
+func TestThermal_Read_NoZones(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewThermal(t.TempDir()).Read(t.Context())
+	require.ErrorIs(t, err, ErrNoChips)
+}
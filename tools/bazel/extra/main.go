@@ -1,7 +1,7 @@
 package main
 
 import (
-	_ "gopkg.in/yaml.v2"
+	_ "gopkg.in/yaml.v3"
 )
 
 func main() {}